@@ -1,5 +1,11 @@
 package v1alpha1
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
 // PowerAction represents the power control operation on the baseboard management.
 type PowerAction string
 
@@ -10,6 +16,8 @@ const (
 	PowerCycle   PowerAction = "cycle"
 	PowerReset   PowerAction = "reset"
 	PowerStatus  PowerAction = "status"
+	// PowerNMI sends a diagnostic interrupt (NMI) to the Machine, for example to get a crash dump of a hung kernel.
+	PowerNMI PowerAction = "nmi"
 )
 
 // Pointer provides an easy way to retrieve the power action as a pointer for use in job
@@ -27,30 +35,537 @@ const (
 	BIOS  BootDevice = "bios"
 	CDROM BootDevice = "cdrom"
 	Safe  BootDevice = "safe"
+
+	// Network is an alias of PXE, the name some BMCs and tools use for booting from the network.
+	Network BootDevice = "network"
+
+	// None clears the boot override of the Machine, so that it boots from its boot order again. It is only
+	// supported as the single device of a OneTimeBootDeviceAction that is not persistent.
+	None BootDevice = "none"
+
+	// HTTPBoot boots the Machine with UEFI HTTP boot, from an http or https URL instead of with PXE. It is only
+	// supported by Redfish BMCs that accept the UefiHttp boot source.
+	HTTPBoot BootDevice = "httpboot"
 )
 
+// bootDeviceAliases maps the BootDevice aliases to the BootDevice they stand for.
+var bootDeviceAliases = map[BootDevice]BootDevice{
+	Network: PXE,
+}
+
+// Canonical returns the BootDevice an alias stands for, for example PXE for Network, or d when it is not an alias.
+func (d BootDevice) Canonical() BootDevice {
+	if c, ok := bootDeviceAliases[d]; ok {
+		return c
+	}
+	return d
+}
+
+// BootDeviceEntry represents a boot device with its own EFI boot flag.
+type BootDeviceEntry struct {
+	// Device is the boot device.
+	Device BootDevice `json:"device"`
+
+	// EFI instructs the machine to use EFI boot for Device, instead of legacy boot.
+	// When unset, the EFIBoot of the action applies.
+	// +optional
+	EFI *bool `json:"efi,omitempty"`
+}
+
 // OnTimeBootDeviceAction represents a baseboard management one time set boot device operation.
+// +kubebuilder:validation:XValidation:rule="(has(self.device) && size(self.device) > 0) != (has(self.entries) && size(self.entries) > 0)",message="exactly one of device and entries must be set"
 type OneTimeBootDeviceAction struct {
 	// Devices represents the boot devices, in order for setting one time boot.
-	// Currently only the first device in the slice is used to set one time boot.
-	Devices []BootDevice `json:"device"`
+	// A one time boot override takes a single device, so only the first device in the slice is used,
+	// unless Persistent is set, in which case the devices are set like in a PersistentBootDeviceAction.
+	// +optional
+	Devices []BootDevice `json:"device,omitempty"`
+
+	// Entries represents the boot devices in order, like Devices, each with its own EFI boot flag.
+	// Exactly one of Devices and Entries must be set.
+	// +optional
+	Entries []BootDeviceEntry `json:"entries,omitempty"`
 
 	// EFIBoot instructs the machine to use EFI boot.
 	EFIBoot bool `json:"efiBoot,omitempty"`
+
+	// Persistent instructs the BMC to keep the boot device across reboots instead of using it only for the next boot.
+	// It is equivalent to a PersistentBootDeviceAction.
+	// +optional
+	Persistent bool `json:"persistent,omitempty"`
+
+	// BootURL is the http or https URL the Machine boots from when the first boot device is httpboot, for example
+	// of a UEFI executable or an ISO image. When unset, the Machine boots from the URL offered by its DHCP server.
+	// +optional
+	BootURL string `json:"bootURL,omitempty"`
+}
+
+// BootAndPowerAction represents setting the one time boot device and then changing the power state of the Machine
+// over a single BMC connection, so that the Machine never boots before the boot override is set.
+type BootAndPowerAction struct {
+	// Device is the boot device of the next boot.
+	Device BootDevice `json:"device"`
+
+	// EFIBoot instructs the machine to use EFI boot.
+	// +optional
+	EFIBoot bool `json:"efiBoot,omitempty"`
+
+	// PowerAction is the power operation run once the boot device is set. on does not reboot a Machine that
+	// is already powered on, cycle and reset boot it from the device right away.
+	// +kubebuilder:validation:Enum=on;cycle;reset
+	// +kubebuilder:default=on
+	// +optional
+	PowerAction PowerAction `json:"powerAction,omitempty"`
+
+	// BootURL is the http or https URL the Machine boots from when Device is httpboot, for example of a UEFI
+	// executable or an ISO image. When unset, the Machine boots from the URL offered by its DHCP server.
+	// +optional
+	BootURL string `json:"bootURL,omitempty"`
+}
+
+// Power returns the PowerAction of the action, on when it is not set.
+func (a BootAndPowerAction) Power() PowerAction {
+	if a.PowerAction == "" {
+		return PowerOn
+	}
+
+	return a.PowerAction
+}
+
+// PersistentBootDeviceAction represents a baseboard management persistent set boot device operation.
+// Unlike OneTimeBootDeviceAction, the boot device set is kept by the BMC across reboots.
+// +kubebuilder:validation:XValidation:rule="(has(self.device) && size(self.device) > 0) != (has(self.entries) && size(self.entries) > 0)",message="exactly one of device and entries must be set"
+type PersistentBootDeviceAction struct {
+	// Devices represents the boot devices, in order for setting the persistent boot order.
+	// The full ordered list is set as the boot order of the computer system on Redfish BMCs, otherwise
+	// only the first device in the slice is used to set the persistent boot device.
+	// +optional
+	Devices []BootDevice `json:"device,omitempty"`
+
+	// Entries represents the boot devices in order, like Devices, each with its own EFI boot flag.
+	// Exactly one of Devices and Entries must be set.
+	// +optional
+	Entries []BootDeviceEntry `json:"entries,omitempty"`
+
+	// EFIBoot instructs the machine to use EFI boot.
+	EFIBoot bool `json:"efiBoot,omitempty"`
+}
+
+// BootEntries returns the boot devices of the action, with the EFIBoot of the action for the devices that
+// don't set their own EFI boot flag.
+func (a OneTimeBootDeviceAction) BootEntries() []BootDeviceEntry {
+	return bootEntries(a.Devices, a.Entries, a.EFIBoot)
 }
 
+// BootEntries returns the boot devices of the action, with the EFIBoot of the action for the devices that
+// don't set their own EFI boot flag.
+func (a PersistentBootDeviceAction) BootEntries() []BootDeviceEntry {
+	return bootEntries(a.Devices, a.Entries, a.EFIBoot)
+}
+
+// bootEntries returns entries, or devices when there are no entries, with efiBoot as the default EFI boot flag.
+func bootEntries(devices []BootDevice, entries []BootDeviceEntry, efiBoot bool) []BootDeviceEntry {
+	var out []BootDeviceEntry
+	if len(entries) == 0 {
+		for _, d := range devices {
+			out = append(out, BootDeviceEntry{Device: d, EFI: &efiBoot})
+		}
+		return out
+	}
+	for _, e := range entries {
+		efi := efiBoot
+		if e.EFI != nil {
+			efi = *e.EFI
+		}
+		out = append(out, BootDeviceEntry{Device: e.Device, EFI: &efi})
+	}
+
+	return out
+}
+
+// VirtualMediaKind represents the kind of virtual media device.
 type VirtualMediaKind string
 
 const (
 	// VirtualMediaCD represents a virtual CD-ROM.
 	VirtualMediaCD VirtualMediaKind = "CD"
+	// VirtualMediaUSB represents a virtual USB stick.
+	VirtualMediaUSB VirtualMediaKind = "USB"
 )
 
 // VirtualMediaAction represents a virtual media action.
 type VirtualMediaAction struct {
 	// mediaURL represents the URL of the image to be inserted into the virtual media, or empty to
-	// eject media.
+	// eject media. When set, it must be a http or https URL.
 	MediaURL string `json:"mediaURL,omitempty"`
 
+	// Kind represents the kind of virtual media device.
+	// +kubebuilder:validation:Enum=CD;USB
 	Kind VirtualMediaKind `json:"kind"`
+
+	// Eject instructs the BMC to eject any currently inserted virtual media.
+	// When true, mediaURL must be empty.
+	// +optional
+	Eject bool `json:"eject,omitempty"`
+}
+
+// GetBIOSConfigAction represents a baseboard management read of the BIOS configuration.
+// The BIOS attributes are stored in the Task status.
+type GetBIOSConfigAction struct{}
+
+// SetBIOSConfigAction represents a baseboard management change of BIOS attributes.
+type SetBIOSConfigAction struct {
+	// Attributes represents the BIOS attributes to set, keyed by attribute name.
+	// +kubebuilder:validation:MinProperties=1
+	Attributes map[string]string `json:"attributes"`
+}
+
+// GetSELAction represents a baseboard management read of the System Event Log (SEL).
+// The SEL entries are stored in the Task status, newest first.
+type GetSELAction struct {
+	// MaxEntries is the maximum number of SEL entries stored in the Task status.
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// ClearSELAction represents a baseboard management clear of the System Event Log (SEL).
+// Clearing the SEL is destructive, so the Task or Job must have the ConfirmClearSELAnnotation set to "true".
+type ClearSELAction struct{}
+
+// GetFirmwareInventoryAction represents a baseboard management read of the installed firmware versions.
+// The firmware components are stored in the Task status. IPMI has no firmware inventory, so a Redfish
+// capable provider is required.
+type GetFirmwareInventoryAction struct{}
+
+// SetBMCCredentialsAction represents a change of the password of a BMC account, for example to rotate the
+// default admin password during onboarding. Changing credentials is sensitive, so the Task or Job must have
+// the ConfirmSetBMCCredentialsAnnotation set to "true".
+type SetBMCCredentialsAction struct {
+	// Username is the name of the existing BMC account to change.
+	// +kubebuilder:validation:MinLength=1
+	Username string `json:"username"`
+
+	// PasswordSecretRef references the Secret holding the new password under the "password" key.
+	// Without a namespace, the Secret is in the namespace of the Task.
+	PasswordSecretRef corev1.SecretReference `json:"passwordSecretRef"`
+
+	// Role is the new role of the account, for example "Administrator". The role is kept when unset.
+	// +optional
+	Role string `json:"role,omitempty"`
+}
+
+// RedfishActionPassthroughAction represents a raw request to the Redfish service of the BMC, for example
+// to run a vendor specific OEM action. The request is sent with the credentials, TLS and proxy options of
+// the Connection, using HTTP basic authentication. Requests are not validated against the Redfish schema,
+// so the Task or Job must have the ConfirmRedfishPassthroughAnnotation set to "true".
+type RedfishActionPassthroughAction struct {
+	// Path is the path of the Redfish resource, for example
+	// "/redfish/v1/Systems/System.Embedded.1/Actions/Oem/DellRaid.ClearForeignConfig".
+	// +kubebuilder:validation:Pattern=`^/redfish/`
+	Path string `json:"path"`
+
+	// Method is the HTTP method of the request.
+	// +kubebuilder:validation:Enum=GET;POST;PATCH;DELETE
+	Method string `json:"method"`
+
+	// Body is the JSON body of the request.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Body *runtime.RawExtension `json:"body,omitempty"`
+}
+
+// RedfishResponse is the response of the BMC to a RedfishActionPassthroughAction.
+type RedfishResponse struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"statusCode"`
+
+	// Body is the body of the response, truncated to 32KiB.
+	// +optional
+	Body string `json:"body,omitempty"`
+
+	// Truncated reports whether Body was truncated.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// UpdateFirmwareAction represents a firmware update of a component of the Machine, for example the BIOS or
+// the BMC, with the Redfish SimpleUpdate action. The BMC downloads the image from ImageURL. The Task tracks
+// the update task of the BMC, storing its progress in the Task status, and completes once it succeeded.
+type UpdateFirmwareAction struct {
+	// Component is the Id of the Redfish firmware inventory member to update, for example "BMC" or "BIOS",
+	// or the full path of the member, for example "/redfish/v1/UpdateService/FirmwareInventory/BMC".
+	// +kubebuilder:validation:MinLength=1
+	Component string `json:"component"`
+
+	// ImageURL is the http or https URL the BMC downloads the firmware image from.
+	ImageURL string `json:"imageURL"`
+
+	// Checksum is the expected checksum of the image, in the form "sha256:<hex digest>". When set, the
+	// controller downloads the image and verifies it before starting the update.
+	// +kubebuilder:validation:Pattern=`^sha256:[0-9a-f]{64}$`
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// SetPowerLimitAction represents a baseboard management change of the power limit of the Machine, through the
+// Redfish power control of its chassis. The limit must be within the range the BMC allows, which is read
+// before the limit is set. IPMI-only providers don't support power limits, so a Redfish capable BMC is required.
+type SetPowerLimitAction struct {
+	// LimitWatts is the power limit to set, in watts.
+	// +kubebuilder:validation:Minimum=1
+	LimitWatts int `json:"limitWatts"`
+}
+
+// GetPowerLimitAction represents a baseboard management read of the power limit and the power consumption of
+// the Machine. They are stored in the Task status. A Redfish capable BMC is required.
+type GetPowerLimitAction struct{}
+
+// PowerLimit is the power limit and the power consumption of a Machine, in watts, read from the Redfish power
+// control of its chassis.
+type PowerLimit struct {
+	// LimitWatts is the power limit. It is 0 when no limit is set.
+	// +optional
+	LimitWatts int `json:"limitWatts,omitempty"`
+
+	// ConsumedWatts is the power consumption.
+	// +optional
+	ConsumedWatts int `json:"consumedWatts,omitempty"`
+
+	// AllowableMinWatts is the lowest power limit the BMC accepts. It is 0 when the BMC does not report it.
+	// +optional
+	AllowableMinWatts int `json:"allowableMinWatts,omitempty"`
+
+	// AllowableMaxWatts is the highest power limit the BMC accepts. It is 0 when the BMC does not report it.
+	// +optional
+	AllowableMaxWatts int `json:"allowableMaxWatts,omitempty"`
+}
+
+// GetBootDeviceAction represents a baseboard management read of the persistent boot order.
+// The boot order is stored in the Task status, so it can be compared to the desired boot order
+// before changing it. Providers that can't read the boot order report the boot override device
+// instead, which the Task condition message notes.
+type GetBootDeviceAction struct{}
+
+// GetSensorsAction represents a baseboard management read of the temperature, fan and voltage sensors.
+// The readings are stored in the Task status. IPMI-only providers don't report sensors, so a Redfish
+// capable provider is required.
+type GetSensorsAction struct{}
+
+// PowerCycleAction represents a power cycle that waits for the Machine to power back on.
+// Unlike a PowerAction of cycle, the Task only completes once the Machine reports it is powered on.
+type PowerCycleAction struct {
+	// WaitTimeout is how long to wait for the Machine to power back on after the power cycle.
+	// The Task fails when the Machine is not powered on within WaitTimeout. It should be shorter than the Task timeout.
+	// +kubebuilder:default="5m"
+	// +optional
+	WaitTimeout *metav1.Duration `json:"waitTimeout,omitempty"`
+}
+
+// ResetType is a Redfish ComputerSystem reset type.
+type ResetType string
+
+const (
+	ResetForceRestart    ResetType = "ForceRestart"
+	ResetGracefulRestart ResetType = "GracefulRestart"
+	ResetPowerCycle      ResetType = "PowerCycle"
+	ResetNmi             ResetType = "Nmi"
+)
+
+// ResetAction represents a reset of the Machine with a specific reset type.
+// Unlike a PowerAction of reset, whose semantics depend on the provider, the reset type is the same for all providers.
+// The Task fails when no provider supports the reset type.
+type ResetAction struct {
+	// ResetType is the Redfish reset type.
+	// +kubebuilder:validation:Enum=ForceRestart;GracefulRestart;PowerCycle;Nmi
+	ResetType ResetType `json:"resetType"`
+}
+
+// BMCResetType is the type of a reset of the BMC itself.
+type BMCResetType string
+
+const (
+	BMCResetCold BMCResetType = "cold"
+	BMCResetWarm BMCResetType = "warm"
+)
+
+// ResetBMCAction represents a reset of the BMC itself, without changing the power state of the Machine, for example
+// to recover a BMC that stopped responding correctly. The BMC is unreachable while it resets.
+type ResetBMCAction struct {
+	// Type is the type of the reset: a cold reset reboots the BMC, a warm reset restarts its services.
+	// +kubebuilder:validation:Enum=cold;warm
+	Type BMCResetType `json:"type"`
+
+	// WaitForReady makes the Task wait for the BMC to respond again after the reset, within the Task timeout.
+	// Otherwise the Task is completed once the BMC accepted the reset.
+	// +optional
+	WaitForReady bool `json:"waitForReady,omitempty"`
+}
+
+// IdentifyState is the state of the chassis identify LED.
+type IdentifyState string
+
+const (
+	IdentifyOn    IdentifyState = "on"
+	IdentifyOff   IdentifyState = "off"
+	IdentifyBlink IdentifyState = "blink"
+)
+
+// IdentifyAction represents a change of the chassis identify (locator) LED, to physically locate the Machine.
+// When the BMC can't blink the LED, it is turned on instead.
+type IdentifyAction struct {
+	// State is the state of the identify LED.
+	// +kubebuilder:validation:Enum=on;off;blink
+	State IdentifyState `json:"state"`
+
+	// DurationSeconds is how long the identify LED stays on or blinking before the controller turns it off.
+	// The Task is completed once the LED is turned off. When unset, the LED stays on or blinking until it
+	// is turned off, and the Task is completed right away.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DurationSeconds *int `json:"durationSeconds,omitempty"`
+}
+
+// VerifyConnectionAction represents a check of the connectivity and credentials of a BMC.
+// A session is opened and authentication confirmed, without changing the power or boot state.
+// The detected capabilities are stored in the Task status.
+type VerifyConnectionAction struct{}
+
+// SoftPowerOffAction represents a graceful power off that falls back to a hard power off.
+// A soft power off is requested first. When the Machine is still powered on after GracePeriod,
+// a hard power off is issued.
+type SoftPowerOffAction struct {
+	// GracePeriod is how long to wait for the soft power off before issuing a hard power off.
+	// It should be shorter than the Task timeout.
+	// +kubebuilder:default="5m"
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// FirmwareComponent represents the firmware installed on a hardware component.
+type FirmwareComponent struct {
+	// Name identifies the component, for example "BIOS", "BMC" or "NIC Broadcom BCM57416".
+	Name string `json:"name"`
+
+	// Version is the installed firmware version.
+	Version string `json:"version"`
+
+	// Updateable reports whether a BMC provider is available that supports installing firmware.
+	// +optional
+	Updateable bool `json:"updateable,omitempty"`
+}
+
+// SELEntry represents a single entry of the System Event Log.
+type SELEntry struct {
+	// ID is the BMC assigned identifier of the entry.
+	ID string `json:"id"`
+
+	// Timestamp is the time the entry was created, in the format reported by the BMC.
+	// +optional
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// Message describes the event.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Severity is the severity of the event, for example OK, Warning or Critical.
+	// It is empty when the BMC does not report a severity.
+	// +optional
+	Severity string `json:"severity,omitempty"`
+}
+
+// SensorReading represents a single reading of a temperature, fan or voltage sensor.
+type SensorReading struct {
+	// Name identifies the sensor, for example "CPU1 Temp" or "Fan 2".
+	Name string `json:"name"`
+
+	// Type is the kind of sensor, for example Temperature, Fan or Voltage.
+	Type string `json:"type"`
+
+	// Value is the sensor reading, in Units.
+	Value float64 `json:"value"`
+
+	// Units are the units of Value, for example "Cel", "RPM" or "V".
+	// +optional
+	Units string `json:"units,omitempty"`
+
+	// Health is the health of the sensor, for example OK, Warning or Critical.
+	// It is empty when the BMC does not report a health.
+	// +optional
+	Health string `json:"health,omitempty"`
+}
+
+// GetBMCNetworkAction represents a baseboard management read of the network configuration of the BMC itself,
+// through the Redfish EthernetInterfaces and ManagerNetworkProtocol of its manager. The configuration is stored
+// in the Task status. IPMI-only providers don't support it, so a Redfish capable BMC is required.
+type GetBMCNetworkAction struct{}
+
+// SetBMCNetworkAction represents a baseboard management change of the IPv4 configuration of an Ethernet interface
+// of the BMC itself. Changing the network configuration may cut off the controller and operators from the BMC,
+// so the Task or Job must have the ConfirmSetBMCNetworkAnnotation set to "true". A Redfish capable BMC is required.
+type SetBMCNetworkAction struct {
+	// Interface is the Redfish Id of the Ethernet interface of the BMC to change, for example "1" or "eth0".
+	// When unset, the first Ethernet interface of the BMC is changed.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// DHCP enables DHCPv4 on the interface when true. It can't be set together with Address.
+	// +optional
+	DHCP bool `json:"dhcp,omitempty"`
+
+	// Address is the static IPv4 address of the interface. DHCPv4 is disabled on the interface when it is set.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// SubnetMask is the IPv4 subnet mask of Address, for example "255.255.255.0". It is required with Address.
+	// +optional
+	SubnetMask string `json:"subnetMask,omitempty"`
+
+	// Gateway is the IPv4 default gateway of the interface.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// GetBootProgressAction represents a baseboard management read of the boot progress of the Machine, the last state
+// it reached during its power-on self-test (POST) and boot, from the Redfish BootProgress of its computer system.
+// The state is stored in the Task status. IPMI-only providers don't support it, so a Redfish capable BMC is required.
+type GetBootProgressAction struct{}
+
+// BMCNetwork is the network configuration of the BMC itself.
+type BMCNetwork struct {
+	// HostName is the host name of the BMC. It is empty when the BMC does not report it.
+	// +optional
+	HostName string `json:"hostName,omitempty"`
+
+	// Interfaces are the Ethernet interfaces of the BMC.
+	// +optional
+	Interfaces []BMCNetworkInterface `json:"interfaces,omitempty"`
+}
+
+// BMCNetworkInterface is the IPv4 configuration of an Ethernet interface of the BMC.
+type BMCNetworkInterface struct {
+	// ID is the Redfish Id of the interface, for example "1" or "eth0".
+	ID string `json:"id"`
+
+	// MACAddress is the MAC address of the interface.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+
+	// DHCP reports whether DHCPv4 is enabled on the interface.
+	// +optional
+	DHCP bool `json:"dhcp,omitempty"`
+
+	// Address is the IPv4 address of the interface.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// SubnetMask is the IPv4 subnet mask of Address.
+	// +optional
+	SubnetMask string `json:"subnetMask,omitempty"`
+
+	// Gateway is the IPv4 default gateway of the interface.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
 }
@@ -32,6 +32,9 @@ const (
 	JobFailed JobConditionType = "Failed"
 	// JobRunning represents a currently executing BMC job.
 	JobRunning JobConditionType = "Running"
+	// JobSuspended represents a Job that creates no more Tasks because its Spec.Suspend is set. It is set to
+	// False once Spec.Suspend is unset.
+	JobSuspended JobConditionType = "Suspended"
 )
 
 // MachineRef is used to reference a Machine object.
@@ -51,11 +54,44 @@ type JobSpec struct {
 
 	// Tasks represents a list of baseboard management actions to be executed.
 	// The tasks are executed sequentially. Controller waits for one task to complete before executing the next.
-	// If a single task fails, job execution stops and sets condition Failed.
+	// If a single task fails, job execution stops and sets condition Failed, unless ContinueOnError is set.
 	// Condition Completed is set only if all the tasks were successful.
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:UniqueItems=false
 	Tasks []Action `json:"tasks"`
+
+	// ContinueOnError makes the Job proceed to the next task when a task fails, instead of stopping.
+	// The Job sets condition Completed once all the tasks ran, and the status counts the successful
+	// and failed tasks.
+	// +optional
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
+	// IdempotentPower is set on the Tasks of the Job, see TaskSpec.IdempotentPower.
+	// +optional
+	IdempotentPower bool `json:"idempotentPower,omitempty"`
+
+	// Priority is set on the Tasks of the Job, see TaskSpec.Priority.
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// PropagateLabels are the keys of the labels and annotations of the Job that are copied to the Tasks
+	// it creates, for example to select the Tasks of a Job by its labels. Keys missing on the Job are ignored.
+	// +optional
+	PropagateLabels []string `json:"propagateLabels,omitempty"`
+
+	// DryRun makes the Job create its Tasks with DryRun set, see TaskSpec.DryRun, to check the connection and the
+	// feasibility of every task before running them. All the tasks are checked, even when a task is infeasible,
+	// and the result of each is reported in Status.DryRunResults.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Suspend makes the controller stop the Job from creating its next Task and set its Suspended condition,
+	// for example to freeze it during a maintenance window. The Task the Job is running is not suspended, set
+	// its Suspend to freeze it too. Unsetting it resumes the Job where it left off.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // JobStatus defines the observed state of Job.
@@ -72,6 +108,47 @@ type JobStatus struct {
 	// The completion time is only set when the job finishes successfully.
 	// +optional
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// CurrentTaskIndex is the index in Spec.Tasks of the Task currently executing,
+	// or of the Task that failed when the Job failed.
+	// +optional
+	CurrentTaskIndex int `json:"currentTaskIndex"`
+
+	// SucceededTasks is the number of tasks that completed successfully.
+	// +optional
+	SucceededTasks int `json:"succeededTasks,omitempty"`
+
+	// FailedTasks is the number of tasks that failed.
+	// +optional
+	FailedTasks int `json:"failedTasks,omitempty"`
+
+	// FinishedTasks are the indexes in Spec.Tasks of the Tasks that completed or failed, in the order they finished.
+	// A finished Task is never created again, so it does not run twice when it was deleted or when the controller
+	// restarted while the Job was running.
+	// +optional
+	FinishedTasks []int `json:"finishedTasks,omitempty"`
+
+	// SkippedTasks are the indexes in Spec.Tasks of the Tasks that were not executed because a previous Task failed.
+	// +optional
+	SkippedTasks []int `json:"skippedTasks,omitempty"`
+
+	// DryRunResults are the results of the Tasks of a Job with DryRun set, in the order they finished.
+	// +optional
+	DryRunResults []DryRunResult `json:"dryRunResults,omitempty"`
+}
+
+// DryRunResult is the feasibility of a task of a Job with DryRun set.
+type DryRunResult struct {
+	// TaskIndex is the index of the task in Spec.Tasks.
+	TaskIndex int `json:"taskIndex"`
+
+	// Feasible is true when the connection was verified and the providers of the BMC support the task.
+	Feasible bool `json:"feasible"`
+
+	// Message is the message of the Completed or Failed condition of the Task, describing why the task is
+	// infeasible.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 type JobCondition struct {
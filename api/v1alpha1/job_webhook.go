@@ -0,0 +1,194 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the Job webhooks with the manager.
+// defaultEFIBoot enables defaulting EFIBoot in the mutating webhook, see JobDefaulter.
+func (j *Job) SetupWebhookWithManager(mgr ctrl.Manager, defaultEFIBoot bool) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(j).
+		WithValidator(&JobValidator{}).
+		WithDefaulter(NewJobDefaulter(mgr.GetClient(), defaultEFIBoot)).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-bmc-tinkerbell-org-v1alpha1-job,mutating=true,failurePolicy=fail,sideEffects=None,groups=bmc.tinkerbell.org,resources=jobs,verbs=create,versions=v1alpha1,name=mjob.kb.io,admissionReviewVersions=v1
+
+// JobDefaulter defaults Job objects on create.
+// When defaulting EFIBoot is enabled and the capabilities of the Machine of the Job include UEFIOnlyCapability,
+// the EFIBoot of its OneTimeBootDeviceActions and BootAndPowerActions, and the EFI of their entries, default to
+// true. An efiBoot or efi set in the Job, even to false, is kept.
+// +kubebuilder:object:generate=false
+type JobDefaulter struct {
+	client         client.Reader
+	defaultEFIBoot bool
+}
+
+var _ admission.CustomDefaulter = &JobDefaulter{}
+
+// NewJobDefaulter returns a JobDefaulter that reads Machines with c. defaultEFIBoot enables defaulting EFIBoot,
+// otherwise Jobs are not changed.
+func NewJobDefaulter(c client.Reader, defaultEFIBoot bool) *JobDefaulter {
+	return &JobDefaulter{client: c, defaultEFIBoot: defaultEFIBoot}
+}
+
+// Default implements admission.CustomDefaulter.
+func (d *JobDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	job, ok := obj.(*Job)
+	if !ok {
+		return fmt.Errorf("expected a Job but got a %T", obj)
+	}
+	if !d.defaultEFIBoot {
+		return nil
+	}
+
+	var raw struct {
+		Spec struct {
+			Tasks []rawEFIBoot `json:"tasks"`
+		} `json:"spec"`
+	}
+	if err := decodeRequestObject(ctx, &raw); err != nil {
+		return fmt.Errorf("failed to decode Job: %w", err)
+	}
+	actions := make([]*Action, 0, len(job.Spec.Tasks))
+	for i := range job.Spec.Tasks {
+		actions = append(actions, &job.Spec.Tasks[i])
+	}
+
+	return defaultEFIBoot(ctx, d.client, job.Spec.MachineRef, actions, raw.Spec.Tasks)
+}
+
+// defaultEFIBoot sets the EFIBoot of the OneTimeBootDeviceActions and BootAndPowerActions of actions, and the EFI
+// of their entries, to true when they are unset and the Machine of ref has the UEFIOnlyCapability. raw are the
+// actions in the admission request, in the same order, so that an efiBoot set to false is kept.
+func defaultEFIBoot(ctx context.Context, c client.Reader, ref MachineRef, actions []*Action, raw []rawEFIBoot) error {
+	var unset []int
+	for i, a := range actions {
+		if i < len(raw) && raw[i].explicit() {
+			continue
+		}
+		oneTime := a.OneTimeBootDeviceAction != nil && !a.OneTimeBootDeviceAction.EFIBoot
+		bootAndPower := a.BootAndPowerAction != nil && !a.BootAndPowerAction.EFIBoot
+		if oneTime || bootAndPower {
+			unset = append(unset, i)
+		}
+	}
+	if len(unset) == 0 {
+		return nil
+	}
+
+	machine := &Machine{}
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if err := c.Get(ctx, key, machine); err != nil {
+		// The Machine may be created after the Job or Task, its firmware mode is unknown until then.
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Machine %s: %w", key, err)
+	}
+	if !slices.Contains(machine.Status.Capabilities, UEFIOnlyCapability) {
+		return nil
+	}
+	for _, i := range unset {
+		if a := actions[i].OneTimeBootDeviceAction; a != nil {
+			a.EFIBoot = true
+			// An efi set in an entry, even to false, is kept.
+			for j := range a.Entries {
+				if a.Entries[j].EFI == nil {
+					a.Entries[j].EFI = ptr.To(true)
+				}
+			}
+		}
+		if a := actions[i].BootAndPowerAction; a != nil {
+			a.EFIBoot = true
+		}
+	}
+
+	return nil
+}
+
+// rawEFIBoot is an action of the object of an admission request, used to tell whether its oneTimeBootDeviceAction
+// or bootAndPowerAction sets efiBoot. The decoded object can't tell an efiBoot of false from an unset efiBoot.
+type rawEFIBoot struct {
+	OneTimeBootDeviceAction map[string]json.RawMessage `json:"oneTimeBootDeviceAction"`
+	BootAndPowerAction      map[string]json.RawMessage `json:"bootAndPowerAction"`
+}
+
+// explicit returns true when the oneTimeBootDeviceAction or bootAndPowerAction of the action sets efiBoot.
+func (r rawEFIBoot) explicit() bool {
+	_, oneTime := r.OneTimeBootDeviceAction["efiBoot"]
+	_, bootAndPower := r.BootAndPowerAction["efiBoot"]
+
+	return oneTime || bootAndPower
+}
+
+// decodeRequestObject decodes the object of the admission request of ctx into v. v is left as it is when ctx has
+// no admission request.
+func decodeRequestObject(ctx context.Context, v any) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	return json.Unmarshal(req.Object.Raw, v)
+}
+
+//+kubebuilder:webhook:path=/validate-bmc-tinkerbell-org-v1alpha1-job,mutating=false,failurePolicy=fail,sideEffects=None,groups=bmc.tinkerbell.org,resources=jobs,verbs=create;update,versions=v1alpha1,name=vjob.kb.io,admissionReviewVersions=v1
+
+// JobValidator validates Job objects on create and update.
+// +kubebuilder:object:generate=false
+type JobValidator struct{}
+
+var _ admission.CustomValidator = &JobValidator{}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *JobValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	job, ok := obj.(*Job)
+	if !ok {
+		return nil, fmt.Errorf("expected a Job but got a %T", obj)
+	}
+
+	return nil, job.validate()
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *JobValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	job, ok := newObj.(*Job)
+	if !ok {
+		return nil, fmt.Errorf("expected a Job but got a %T", newObj)
+	}
+
+	return nil, job.validate()
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *JobValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate returns an Invalid error listing all invalid fields of the Job.
+func (j *Job) validate() error {
+	var allErrs field.ErrorList
+	for i, a := range j.Spec.Tasks {
+		allErrs = append(allErrs, validateAction(a, j.Annotations, field.NewPath("spec", "tasks").Index(i))...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Job").GroupKind(), j.Name, allErrs)
+}
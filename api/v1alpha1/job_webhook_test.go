@@ -0,0 +1,121 @@
+package v1alpha1_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+func TestJobDefault(t *testing.T) {
+	bootPXE := func(efiBoot bool) v1alpha1.Action {
+		return v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}, EFIBoot: efiBoot}}
+	}
+	tests := map[string]struct {
+		capabilities   []string
+		noMachine      bool
+		defaultEFIBoot bool
+		// raw is the Job of the admission request, when it differs from the JSON encoding of the Job.
+		raw         string
+		tasks       []v1alpha1.Action
+		wantEFIBoot []bool
+	}{
+		"uefi only machine": {
+			capabilities:   []string{"bootdevice", v1alpha1.UEFIOnlyCapability},
+			defaultEFIBoot: true,
+			tasks:          []v1alpha1.Action{bootPXE(false), {PowerAction: v1alpha1.PowerOn.Ptr()}, bootPXE(true)},
+			wantEFIBoot:    []bool{true, false, true},
+		},
+		"explicit efiBoot false is kept": {
+			capabilities:   []string{v1alpha1.UEFIOnlyCapability},
+			defaultEFIBoot: true,
+			raw:            `{"spec":{"tasks":[{"oneTimeBootDeviceAction":{"device":["pxe"],"efiBoot":false}},{"oneTimeBootDeviceAction":{"device":["pxe"]}}]}}`,
+			tasks:          []v1alpha1.Action{bootPXE(false), bootPXE(false)},
+			wantEFIBoot:    []bool{false, true},
+		},
+		"boot and power action": {
+			capabilities:   []string{v1alpha1.UEFIOnlyCapability},
+			defaultEFIBoot: true,
+			raw:            `{"spec":{"tasks":[{"bootAndPowerAction":{"device":"pxe"}},{"bootAndPowerAction":{"device":"pxe","efiBoot":false}}]}}`,
+			tasks: []v1alpha1.Action{
+				{BootAndPowerAction: &v1alpha1.BootAndPowerAction{Device: v1alpha1.PXE}},
+				{BootAndPowerAction: &v1alpha1.BootAndPowerAction{Device: v1alpha1.PXE}},
+			},
+			wantEFIBoot: []bool{true, false},
+		},
+		"machine that is not uefi only": {
+			capabilities:   []string{"bootdevice"},
+			defaultEFIBoot: true,
+			tasks:          []v1alpha1.Action{bootPXE(false)},
+			wantEFIBoot:    []bool{false},
+		},
+		"machine not found": {
+			noMachine:      true,
+			defaultEFIBoot: true,
+			tasks:          []v1alpha1.Action{bootPXE(false)},
+			wantEFIBoot:    []bool{false},
+		},
+		"defaulting disabled": {
+			capabilities: []string{v1alpha1.UEFIOnlyCapability},
+			tasks:        []v1alpha1.Action{bootPXE(false)},
+			wantEFIBoot:  []bool{false},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			machine := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+				Status:     v1alpha1.MachineStatus{Capabilities: tt.capabilities},
+			}
+			var objs []client.Object
+			if !tt.noMachine {
+				objs = append(objs, machine)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			job := &v1alpha1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+				Spec: v1alpha1.JobSpec{
+					MachineRef: v1alpha1.MachineRef{Name: machine.Name, Namespace: machine.Namespace},
+					Tasks:      tt.tasks,
+				},
+			}
+			raw := []byte(tt.raw)
+			if tt.raw == "" {
+				var err error
+				if raw, err = json.Marshal(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+
+			if err := v1alpha1.NewJobDefaulter(c, tt.defaultEFIBoot).Default(ctx, job); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			var got []bool
+			for _, a := range job.Spec.Tasks {
+				got = append(got, (a.OneTimeBootDeviceAction != nil && a.OneTimeBootDeviceAction.EFIBoot) || (a.BootAndPowerAction != nil && a.BootAndPowerAction.EFIBoot))
+			}
+			if diff := cmp.Diff(tt.wantEFIBoot, got); diff != "" {
+				t.Fatalf("unexpected efiBoot: %v", diff)
+			}
+		})
+	}
+}
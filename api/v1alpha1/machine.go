@@ -46,10 +46,33 @@ const (
 	ConditionFalse ConditionStatus = "False"
 )
 
+// MachineFinalizer is set on Machines while BMC connections are cached, so that the cached connections to the
+// BMC of a deleted Machine are closed.
+const MachineFinalizer = "bmc.tinkerbell.org/machine"
+
+// UEFIOnlyCapability is the capability of a Machine whose BMC only allows UEFI boot overrides.
+const UEFIOnlyCapability = "uefi-only"
+
 // MachineSpec defines desired machine state.
 type MachineSpec struct {
 	// Connection contains connection data for a Baseboard Management Controller.
 	Connection Connection `json:"connection"`
+
+	// DesiredPowerState is the power state the Machine should be in.
+	// It is only acted on when EnforcePowerState is true.
+	// +kubebuilder:validation:Enum=on;off
+	// +optional
+	DesiredPowerState PowerState `json:"desiredPowerState,omitempty"`
+
+	// EnforcePowerState enables setting the power state of the Machine to DesiredPowerState
+	// whenever the observed power state drifts from it.
+	// +optional
+	EnforcePowerState bool `json:"enforcePowerState,omitempty"`
+
+	// PowerCheckInterval is how often the power state of the Machine is polled.
+	// Defaults to the --machine-power-check-interval of the controller, 3m unless set.
+	// +optional
+	PowerCheckInterval *metav1.Duration `json:"powerCheckInterval,omitempty"`
 }
 
 // ProviderName is the bmclib specific provider name. Names are case insensitive.
@@ -68,6 +91,21 @@ type ProviderOptions struct {
 	// The default order is: ipmitool, asrockrack, gofish, intelamt, dell, supermicro, openbmc.
 	// +optional
 	PreferredOrder []ProviderName `json:"preferredOrder,omitempty"`
+
+	// PreferredProviders restricts the BMC providers that are tried to the ones listed, in the listed order.
+	// Entries match either a provider name, for example "ipmitool" or "gofish", or a protocol, for example "redfish" or "ipmi".
+	// Entries are case insensitive.
+	// +optional
+	PreferredProviders []string `json:"preferredProviders,omitempty"`
+
+	// DisablePortProbing restricts the BMC providers that are tried to the protocols with an explicitly configured
+	// port, so that no connection is attempted to the default ports of the other protocols. The port of Redfish is
+	// Redfish.Port, of IPMI IPMITOOL.Port and of Intel AMT IntelAMT.Port. Connection.Port configures IPMI, and
+	// Redfish unless it is 623. The RPC provider, which connects to its ConsumerURL, is tried when RPC is set.
+	// At least one port, or RPC, must be configured.
+	// +optional
+	DisablePortProbing bool `json:"disablePortProbing,omitempty"`
+
 	// IntelAMT contains the options to customize the IntelAMT provider.
 	// +optional
 	IntelAMT *IntelAMTOptions `json:"intelAMT,omitempty"`
@@ -88,28 +126,144 @@ type ProviderOptions struct {
 // Connection contains connection data for a Baseboard Management Controller.
 type Connection struct {
 	// Host is the host IP address or hostname of the Machine.
+	// IPv6 addresses may be written with or without brackets, for example "fd00::10" or "[fd00::10]".
 	// +kubebuilder:validation:MinLength=1
 	Host string `json:"host"`
 
 	// Port is the port number for connecting with the Machine.
-	// +kubebuilder:default:=623
+	// When set, it is used instead of the protocol default port for both IPMI (623) and Redfish (443).
+	// 0, like leaving it unset, uses the protocol default ports.
+	// The ports in ProviderOptions take precedence over Port.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
 	// +optional
 	Port int `json:"port"`
 
 	// AuthSecretRef is the SecretReference that contains authentication information of the Machine.
-	// The Secret must contain username and password keys. This is optional as it is not required when using
-	// the RPC provider.
+	// The Secret must contain username and password keys, or only a password key when Username is set.
+	// This is optional as it is not required when using the RPC provider. The Secret may be in another
+	// namespace, when the controller is allowed to read it.
+	// Without a namespace, the Secret is in the namespace of the Machine or Task.
 	// +optional
 	AuthSecretRef corev1.SecretReference `json:"authSecretRef"`
 
-	// InsecureTLS specifies trusted TLS connections.
+	// Username, when set, is used instead of the username in the AuthSecretRef Secret, while the password
+	// is still read from the Secret. It requires AuthSecretRef. Passwords can't be set inline, they are
+	// always read from a Secret or a credential provider.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// AuthProviderRef references credentials held by a credential provider configured on the controller,
+	// for example Vault. When set, it is used instead of AuthSecretRef.
+	// +optional
+	AuthProviderRef *AuthProviderRef `json:"authProviderRef,omitempty"`
+
+	// ClientCertSecretRef is the SecretReference of a kubernetes.io/tls Secret, with tls.crt and tls.key keys,
+	// that contains the client certificate presented to the BMC for Redfish TLS client authentication.
+	// The client certificate is used in addition to the username and password, which may be omitted
+	// for BMCs that authenticate with the client certificate only.
+	// Without a namespace, the Secret is in the namespace of the Machine or Task.
+	// +optional
+	ClientCertSecretRef *corev1.SecretReference `json:"clientCertSecretRef,omitempty"`
+
+	// CACertSecretRef references a Secret or ConfigMap with the PEM encoded CA bundle that the Redfish and other
+	// HTTPS certificates of the BMC are verified against, instead of the system root CAs. It is the secure
+	// alternative to InsecureTLS for BMCs with certificates issued by an internal CA.
+	// +optional
+	CACertSecretRef *CACertReference `json:"caCertSecretRef,omitempty"`
+
+	// InsecureTLS disables verification of the BMC TLS certificate.
+	// By default the certificate is verified against the system root CAs, or against CACertSecretRef when set.
+	// A Warning Event is recorded whenever a connection is made with InsecureTLS enabled.
 	InsecureTLS bool `json:"insecureTLS"`
 
+	// ProxyURL is the URL of the proxy that Redfish and other HTTP based providers connect to the BMC through,
+	// for example "socks5://jump.example.com:1080". The http, https and socks5 schemes are supported.
+	// When unset, the BMC is connected to directly.
+	// +optional
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// IPMIOptions contains options for IPMI connections.
+	// +optional
+	IPMIOptions *IPMIOptions `json:"ipmiOptions,omitempty"`
+
 	// ProviderOptions contains provider specific options.
 	// +optional
 	ProviderOptions *ProviderOptions `json:"providerOptions,omitempty"`
 }
 
+// IPMIOptions contains options for IPMI connections.
+type IPMIOptions struct {
+	// CipherSuite is the IPMI cipher suite ID used for IPMI sessions, for example 3 or 17.
+	// When unset, the cipher suite is negotiated with the BMC.
+	// ProviderOptions.IPMITOOL.CipherSuite takes precedence over CipherSuite.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=17
+	// +optional
+	CipherSuite *int `json:"cipherSuite,omitempty"`
+}
+
+// CACertKind is the kind of the object holding a CA bundle.
+type CACertKind string
+
+const (
+	CACertKindSecret    CACertKind = "Secret"
+	CACertKindConfigMap CACertKind = "ConfigMap"
+)
+
+// DefaultCACertKey is the key of the CA bundle in a Secret or ConfigMap when CACertReference.Key is unset.
+const DefaultCACertKey = "ca.crt"
+
+// CACertReference references the PEM encoded CA bundle in a Secret or ConfigMap.
+type CACertReference struct {
+	// Kind is the kind of the object holding the CA bundle, Secret or ConfigMap.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +kubebuilder:default=Secret
+	// +optional
+	Kind CACertKind `json:"kind,omitempty"`
+
+	// Name is the name of the Secret or ConfigMap.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Secret or ConfigMap.
+	// Without a namespace, it is in the namespace of the Machine or Task.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key is the key of the CA bundle in the Secret or ConfigMap. It defaults to "ca.crt".
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// CAKind returns the kind of the object holding the CA bundle, a Secret when Kind is unset.
+func (r CACertReference) CAKind() CACertKind {
+	if r.Kind == "" {
+		return CACertKindSecret
+	}
+	return r.Kind
+}
+
+// CAKey returns the key of the CA bundle, DefaultCACertKey when Key is unset.
+func (r CACertReference) CAKey() string {
+	if r.Key == "" {
+		return DefaultCACertKey
+	}
+	return r.Key
+}
+
+// AuthProviderRef references the username and password of a Machine in a credential provider.
+type AuthProviderRef struct {
+	// Name is the name of the credential provider, for example "vault".
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Path identifies the credentials within the provider, for example the Vault path "secret/data/bmc/node1".
+	// The credentials must contain username and password keys.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+}
+
 // MachineStatus defines the observed state of Machine.
 type MachineStatus struct {
 	// Power is the current power state of the Machine.
@@ -117,6 +271,46 @@ type MachineStatus struct {
 	// +optional
 	Power PowerState `json:"powerState,omitempty"`
 
+	// LastPowerStateUpdate is the last time the power state of the Machine was read from the BMC.
+	// +optional
+	LastPowerStateUpdate *metav1.Time `json:"lastPowerStateUpdate,omitempty"`
+
+	// LastEnforcementTime is the last time the power state of the Machine was set to DesiredPowerState.
+	// +optional
+	LastEnforcementTime *metav1.Time `json:"lastEnforcementTime,omitempty"`
+
+	// Manufacturer is the system manufacturer reported by the BMC, for example "Dell Inc.".
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+
+	// Model is the system model reported by the BMC.
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// SerialNumber is the system serial number reported by the BMC.
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// LastInventoryTime is the last time the Manufacturer, Model and SerialNumber were read from the BMC.
+	// It is also set when the read failed, so that the read is only retried after the inventory interval.
+	// +optional
+	LastInventoryTime *metav1.Time `json:"lastInventoryTime,omitempty"`
+
+	// Providers are the bmclib providers, for example "gofish" or "ipmitool", that opened a connection to the BMC
+	// when the Capabilities were detected.
+	// +optional
+	Providers []string `json:"providers,omitempty"`
+
+	// Capabilities are the capabilities of the Providers, for example "power", "bootdevice" or "virtualmedia".
+	// Tasks of Jobs for the Machine fail right away when their action requires a capability that is missing.
+	// The capability "uefi-only" is added when the BMC only allows UEFI boot overrides.
+	// +optional
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// LastCapabilitiesTime is the last time the Providers and Capabilities were detected.
+	// +optional
+	LastCapabilitiesTime *metav1.Time `json:"lastCapabilitiesTime,omitempty"`
+
 	// Conditions represents the latest available observations of an object's current state.
 	// +optional
 	Conditions []MachineCondition `json:"conditions,omitempty"`
@@ -182,6 +376,9 @@ func WithMachineConditionMessage(m string) MachineSetConditionOption {
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:path=machines,scope=Namespaced,categories=tinkerbell,singular=machine
+//+kubebuilder:printcolumn:name="Power",type=string,JSONPath=`.status.powerState`
+//+kubebuilder:printcolumn:name="Power Updated",type=date,JSONPath=`.status.lastPowerStateUpdate`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Machine is the Schema for the machines API.
 type Machine struct {
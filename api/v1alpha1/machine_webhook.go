@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the Machine webhooks with the manager.
+func (m *Machine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		WithValidator(&MachineValidator{}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-bmc-tinkerbell-org-v1alpha1-machine,mutating=false,failurePolicy=fail,sideEffects=None,groups=bmc.tinkerbell.org,resources=machines,verbs=create;update,versions=v1alpha1,name=vmachine.kb.io,admissionReviewVersions=v1
+
+// MachineValidator validates Machine objects on create and update.
+// +kubebuilder:object:generate=false
+type MachineValidator struct{}
+
+var _ admission.CustomValidator = &MachineValidator{}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *MachineValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	machine, ok := obj.(*Machine)
+	if !ok {
+		return nil, fmt.Errorf("expected a Machine but got a %T", obj)
+	}
+
+	return nil, machine.validate()
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *MachineValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	machine, ok := newObj.(*Machine)
+	if !ok {
+		return nil, fmt.Errorf("expected a Machine but got a %T", newObj)
+	}
+
+	return nil, machine.validate()
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *MachineValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate returns an Invalid error listing all invalid fields of the Machine.
+func (m *Machine) validate() error {
+	allErrs := validateConnection(m.Spec.Connection, field.NewPath("spec", "connection"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Machine").GroupKind(), m.Name, allErrs)
+}
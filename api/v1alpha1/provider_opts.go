@@ -12,8 +12,15 @@ type RedfishOptions struct {
 	// +optional
 	Port int `json:"port,omitempty"`
 	// UseBasicAuth for redfish calls. The default is false which means token based auth is used.
+	// It applies to both the generic and the Dell Redfish providers.
 	// +optional
 	UseBasicAuth bool `json:"useBasicAuth,omitempty"`
+	// VersionsNotCompatible are Redfish versions, for example "1.6.0", of BMC firmware that misbehaves with the
+	// Redfish providers. When the BMC reports one of them, the generic and the Dell Redfish providers are not used
+	// and other providers, like ipmitool, are used instead. When unset, the Redfish providers are used with any version.
+	// +kubebuilder:validation:items:Pattern=`^[0-9]+\.[0-9]+\.[0-9]+$`
+	// +optional
+	VersionsNotCompatible []string `json:"versionsNotCompatible,omitempty"`
 	// SystemName is the name of the system to use for redfish calls.
 	// With redfish implementations that manage multiple systems via a single endpoint, this allows for specifying the system to manage.
 	// +optional
@@ -48,6 +55,7 @@ type HMACAlgorithm string
 
 // HMACSecrets holds per Algorithm slice secrets.
 // These secrets will be used to create HMAC signatures.
+// References without a namespace are to Secrets in the namespace of the Machine or Task.
 type HMACSecrets map[HMACAlgorithm][]corev1.SecretReference
 
 // RPCOptions defines the configurable options to use when sending rpc notifications.
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"slices"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,15 +30,149 @@ const (
 	TaskCompleted TaskConditionType = "Completed"
 	// TaskFailed represents failure in Task execution.
 	TaskFailed TaskConditionType = "Failed"
+	// TaskUnsupported represents a Task that finished because none of the providers of the BMC supports its
+	// action, for example virtual media over IPMI. It is set instead of Failed, which is kept for actual errors.
+	TaskUnsupported TaskConditionType = "Unsupported"
+	// TaskPending represents a Task waiting for the controller to admit it, because the maximum
+	// number of Tasks in flight is reached.
+	TaskPending TaskConditionType = "Pending"
+	// TaskRunning represents a Task whose action is being executed or whose status is being checked on the BMC.
+	// It is removed once the Task has Completed or Failed, or while it waits to retry its action.
+	TaskRunning TaskConditionType = "Running"
+	// TaskSuspended represents a Task that is skipped by the controller because its Spec.Suspend is set. It is
+	// set to False once Spec.Suspend is unset.
+	TaskSuspended TaskConditionType = "Suspended"
+)
+
+// Reasons of the Task conditions set by the controller, for automation that shouldn't match on the Message.
+const (
+	// TaskReasonSucceeded is the reason of the Completed condition of a Task that completed.
+	TaskReasonSucceeded = "Succeeded"
+	// TaskReasonConnectionFailed is the reason of a Task that failed, or is retried, because the BMC could not be reached.
+	TaskReasonConnectionFailed = "ConnectionFailed"
+	// TaskReasonAuthFailed is the reason of a Task that failed because the BMC rejected the credentials.
+	TaskReasonAuthFailed = "AuthFailed"
+	// TaskReasonUnsupported is the reason of the Unsupported condition of a Task whose action no provider supports.
+	TaskReasonUnsupported = "Unsupported"
+	// TaskReasonTimeout is the reason of a Task that failed, or is retried, because an operation did not finish in time.
+	TaskReasonTimeout = "Timeout"
+	// TaskReasonActionFailed is the reason of a Task that failed, or is retried, because of any other error.
+	TaskReasonActionFailed = "ActionFailed"
+)
+
+// TaskFinalizer is set on unfinished Tasks, so that their deletion waits for or cancels the BMC operation in flight.
+const TaskFinalizer = "bmc.tinkerbell.org/task"
+
+// DeletePolicy defines what happens to the BMC operation in flight when a Task is deleted.
+type DeletePolicy string
+
+const (
+	// DeletePolicyWait removes a deleted Task once its BMC operation in flight has finished.
+	DeletePolicyWait DeletePolicy = "wait"
+	// DeletePolicyAbandon cancels the BMC operation in flight of a deleted Task and removes it right away.
+	DeletePolicyAbandon DeletePolicy = "abandon"
 )
 
 // TaskSpec defines the desired state of Task.
 type TaskSpec struct {
 	// Task defines the specific action to be performed.
+	// It must be empty when Actions is set.
+	// +optional
 	Task Action `json:"task"`
 
+	// Actions defines actions performed in order over a single BMC connection, for example setting the
+	// boot device and then power cycling the machine. When set, Task must be empty. The Task completes
+	// once all actions succeeded and fails on the first action that fails.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	Actions []Action `json:"actions,omitempty"`
+
 	// Connection represents the Machine connectivity information.
+	// It must be empty when MachineRef is set.
 	Connection Connection `json:"connection,omitempty"`
+
+	// MachineRef references the Machine whose Connection the Task uses, instead of an inline Connection.
+	// The Connection is read from the Machine each time the Task is reconciled, and Secret references without
+	// a namespace are to Secrets in the namespace of the Machine.
+	// +optional
+	MachineRef *MachineRef `json:"machineRef,omitempty"`
+
+	// Timeout bounds the BMC operations of the Task, including opening the BMC connection.
+	// When unset, a Task fails if it has not completed within 10 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RetryPolicy defines how the action is retried when it fails with a transient BMC error.
+	// When unset, the action is not retried.
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// TTLSecondsAfterFinished limits the lifetime of a Task that has finished, either Completed, Failed or Unsupported.
+	// The Task is deleted TTLSecondsAfterFinished seconds after its CompletionTime or FailureTime.
+	// When unset, the Task is never deleted automatically. When zero, the Task is deleted as soon as it finishes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// IdempotentPower makes a PowerAction of on, off or soft first read the power state of the Machine and
+	// skip the power operation when the Machine is already in the desired state.
+	// +optional
+	IdempotentPower bool `json:"idempotentPower,omitempty"`
+
+	// DeletePolicy defines what happens to the BMC operation in flight when the Task is deleted.
+	// With wait, the default, the Task is removed once the operation has finished. With abandon,
+	// the operation is cancelled and the Task is removed right away.
+	// +kubebuilder:validation:Enum=wait;abandon
+	// +optional
+	DeletePolicy DeletePolicy `json:"deletePolicy,omitempty"`
+
+	// Priority orders the Tasks waiting for the controller when the limits of Tasks in flight or of concurrent
+	// operations per BMC host are reached: Tasks with a higher priority run first. Defaults to 0.
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// HistoryLimit is the number of condition transitions kept in Status.History, oldest first.
+	// When unset or zero, no history is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HistoryLimit int32 `json:"historyLimit,omitempty"`
+
+	// DryRun makes the Task only verify that its actions can run: the BMC connection is opened and checked, and
+	// the capability each action requires is checked against the opened providers. No action is run.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Suspend makes the controller skip the Task, which keeps its definition and status, and set its Suspended
+	// condition, for example to freeze it during a maintenance window. An action in flight on the BMC is not
+	// cancelled, only its status is no longer checked. Unsetting it resumes the Task where it left off.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// RetryPolicy defines how a failed action is retried.
+// Only transient errors, for example connection resets or 503 responses, are retried.
+// Authentication and unsupported action errors fail the Task immediately.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times the action is retried after the first attempt.
+	// +kubebuilder:validation:Minimum=0
+	MaxRetries int `json:"maxRetries"`
+
+	// BackoffBase is the wait before the first retry. The wait doubles for every subsequent retry.
+	// Defaults to 5s.
+	// +optional
+	BackoffBase *metav1.Duration `json:"backoffBase,omitempty"`
+
+	// MaxBackoff caps the wait before a retry, which otherwise doubles without bound.
+	// +optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+
+	// MaxElapsedTime bounds the time from the first attempt of the action to the start of a retry. A retry that
+	// would start later is not run and the Task fails with a message that the retry budget was exhausted.
+	// +optional
+	MaxElapsedTime *metav1.Duration `json:"maxElapsedTime,omitempty"`
 }
 
 // Action represents the action to be performed.
@@ -45,22 +181,118 @@ type TaskSpec struct {
 // +kubebuilder:validation:MaxProperties:=1
 type Action struct {
 	// PowerAction represents a baseboard management power operation.
-	// +kubebuilder:validation:Enum=on;off;soft;status;cycle;reset
+	// +kubebuilder:validation:Enum=on;off;soft;status;cycle;reset;nmi
 	PowerAction *PowerAction `json:"powerAction,omitempty"`
 
 	// OneTimeBootDeviceAction represents a baseboard management one time set boot device operation.
 	OneTimeBootDeviceAction *OneTimeBootDeviceAction `json:"oneTimeBootDeviceAction,omitempty"`
 
+	// PersistentBootDeviceAction represents a baseboard management persistent set boot device operation.
+	PersistentBootDeviceAction *PersistentBootDeviceAction `json:"persistentBootDeviceAction,omitempty"`
+
 	// VirtualMediaAction represents a baseboard management virtual media insert/eject.
 	VirtualMediaAction *VirtualMediaAction `json:"virtualMediaAction,omitempty"`
+
+	// GetBIOSConfigAction represents a baseboard management read of the BIOS configuration.
+	GetBIOSConfigAction *GetBIOSConfigAction `json:"getBIOSConfigAction,omitempty"`
+
+	// SetBIOSConfigAction represents a baseboard management change of BIOS attributes.
+	SetBIOSConfigAction *SetBIOSConfigAction `json:"setBIOSConfigAction,omitempty"`
+
+	// GetSELAction represents a baseboard management read of the System Event Log.
+	GetSELAction *GetSELAction `json:"getSELAction,omitempty"`
+
+	// ClearSELAction represents a baseboard management clear of the System Event Log.
+	ClearSELAction *ClearSELAction `json:"clearSELAction,omitempty"`
+
+	// GetFirmwareInventoryAction represents a baseboard management read of the installed firmware versions.
+	GetFirmwareInventoryAction *GetFirmwareInventoryAction `json:"getFirmwareInventoryAction,omitempty"`
+
+	// SoftPowerOffAction represents a baseboard management soft power off with a fallback to a hard power off.
+	SoftPowerOffAction *SoftPowerOffAction `json:"softPowerOffAction,omitempty"`
+
+	// PowerCycleAction represents a baseboard management power cycle that waits for the Machine to power back on.
+	PowerCycleAction *PowerCycleAction `json:"powerCycleAction,omitempty"`
+
+	// VerifyConnectionAction represents a baseboard management connectivity and credentials check.
+	VerifyConnectionAction *VerifyConnectionAction `json:"verifyConnectionAction,omitempty"`
+
+	// ResetAction represents a baseboard management reset with a specific Redfish reset type.
+	ResetAction *ResetAction `json:"resetAction,omitempty"`
+
+	// IdentifyAction represents a baseboard management change of the chassis identify LED.
+	IdentifyAction *IdentifyAction `json:"identifyAction,omitempty"`
+
+	// GetSensorsAction represents a baseboard management read of the temperature, fan and voltage sensors.
+	GetSensorsAction *GetSensorsAction `json:"getSensorsAction,omitempty"`
+
+	// GetBootDeviceAction represents a baseboard management read of the persistent boot order.
+	GetBootDeviceAction *GetBootDeviceAction `json:"getBootDeviceAction,omitempty"`
+
+	// SetBMCCredentialsAction represents a baseboard management change of the password of a BMC account.
+	SetBMCCredentialsAction *SetBMCCredentialsAction `json:"setBMCCredentialsAction,omitempty"`
+
+	// RedfishActionPassthroughAction represents a raw request to the Redfish service of the BMC.
+	RedfishActionPassthroughAction *RedfishActionPassthroughAction `json:"redfishActionPassthroughAction,omitempty"`
+
+	// UpdateFirmwareAction represents a firmware update of a component of the Machine.
+	UpdateFirmwareAction *UpdateFirmwareAction `json:"updateFirmwareAction,omitempty"`
+
+	// SetPowerLimitAction represents a baseboard management change of the power limit of the Machine.
+	SetPowerLimitAction *SetPowerLimitAction `json:"setPowerLimitAction,omitempty"`
+
+	// GetPowerLimitAction represents a baseboard management read of the power limit and consumption of the Machine.
+	GetPowerLimitAction *GetPowerLimitAction `json:"getPowerLimitAction,omitempty"`
+
+	// GetBMCNetworkAction represents a baseboard management read of the network configuration of the BMC.
+	GetBMCNetworkAction *GetBMCNetworkAction `json:"getBMCNetworkAction,omitempty"`
+
+	// SetBMCNetworkAction represents a baseboard management change of the network configuration of the BMC.
+	SetBMCNetworkAction *SetBMCNetworkAction `json:"setBMCNetworkAction,omitempty"`
+
+	// ResetBMCAction represents a cold or warm reset of the BMC itself.
+	ResetBMCAction *ResetBMCAction `json:"resetBMCAction,omitempty"`
+
+	// BootAndPowerAction represents setting the one time boot device and then changing the power state over a
+	// single BMC connection.
+	BootAndPowerAction *BootAndPowerAction `json:"bootAndPowerAction,omitempty"`
+
+	// GetBootProgressAction represents a baseboard management read of the boot progress of the Machine.
+	GetBootProgressAction *GetBootProgressAction `json:"getBootProgressAction,omitempty"`
 }
 
+// FailureReason is the classification of the error that failed a Task.
+type FailureReason string
+
+const (
+	// FailureReasonAuth means the BMC rejected the credentials.
+	FailureReasonAuth FailureReason = "auth"
+	// FailureReasonNetwork means the BMC could not be reached.
+	FailureReasonNetwork FailureReason = "network"
+	// FailureReasonUnsupported means none of the providers support the action. The Task is Unsupported instead of Failed.
+	FailureReasonUnsupported FailureReason = "unsupported"
+	// FailureReasonTimeout means the action or the Task did not finish in time.
+	FailureReasonTimeout FailureReason = "timeout"
+	// FailureReasonProvider means the BMC or a provider returned any other error.
+	FailureReasonProvider FailureReason = "provider"
+)
+
 // TaskStatus defines the observed state of Task.
 type TaskStatus struct {
 	// Conditions represents the latest available observations of an object's current state.
 	// +optional
 	Conditions []TaskCondition `json:"conditions,omitempty"`
 
+	// History represents the last Spec.HistoryLimit transitions of the conditions, oldest first. A condition
+	// is added each time its status changes, with the message it had after the change.
+	// +optional
+	History []TaskCondition `json:"history,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the Task last acted on by the controller.
+	// The conditions only reflect the current spec when it equals metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// StartTime represents time when the Task started processing.
 	// +optional
 	StartTime *metav1.Time `json:"startTime,omitempty"`
@@ -69,6 +301,136 @@ type TaskStatus struct {
 	// The completion time is only set when the task finishes successfully.
 	// +optional
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// FailureTime represents time when the task failed.
+	// The failure time is only set when the task fails or is unsupported.
+	// +optional
+	FailureTime *metav1.Time `json:"failureTime,omitempty"`
+
+	// FailureReason is the machine readable classification of the error that failed the Task: auth, network,
+	// unsupported, timeout or provider. Tasks that failed with an auth error are never retried.
+	// +kubebuilder:validation:Enum=auth;network;unsupported;timeout;provider
+	// +optional
+	FailureReason FailureReason `json:"failureReason,omitempty"`
+
+	// BIOSConfig represents the BIOS attributes read by a GetBIOSConfigAction.
+	// +optional
+	BIOSConfig map[string]string `json:"biosConfig,omitempty"`
+
+	// PowerState represents the power state read by a PowerAction of status, for example "on" or "off".
+	// When the controller verifies power actions, it is also the power state observed after a PowerAction
+	// of on, off, soft or cycle. It is empty for all other actions.
+	// +optional
+	PowerState string `json:"powerState,omitempty"`
+
+	// LastBootSource represents the boot source of the last boot override applied, read by a PowerAction of status,
+	// for example "pxe". Boot sources that aren't a BootDevice are represented by their Redfish name, for example
+	// "UefiHttp". It is empty when no boot override was applied or the BMC doesn't report it, like IPMI-only BMCs.
+	// +optional
+	LastBootSource string `json:"lastBootSource,omitempty"`
+
+	// SELEntries represents the System Event Log entries read by a GetSELAction, newest first.
+	// +optional
+	SELEntries []SELEntry `json:"selEntries,omitempty"`
+
+	// Firmware represents the firmware components read by a GetFirmwareInventoryAction.
+	// +optional
+	Firmware []FirmwareComponent `json:"firmware,omitempty"`
+
+	// Sensors represents the sensor readings read by a GetSensorsAction, critical and warning readings first.
+	// +optional
+	Sensors []SensorReading `json:"sensors,omitempty"`
+
+	// BootOrder represents the persistent boot order read by a GetBootDeviceAction, first device first.
+	// A boot option that boots none of the BootDevice values is represented by its name on the BMC.
+	// When the BMC has no Redfish boot order, it holds the boot override device only.
+	// +optional
+	BootOrder []BootDevice `json:"bootOrder,omitempty"`
+
+	// AppliedBootDevices represents the boot devices set by a OneTimeBootDeviceAction, PersistentBootDeviceAction
+	// or BootAndPowerAction, first device first. When it differs from the requested devices, only part of them
+	// were applied and the condition message starts with "partial".
+	// +optional
+	AppliedBootDevices []BootDevice `json:"appliedBootDevices,omitempty"`
+
+	// OneTimeBootOverride represents the one time boot override device read back from the BMC after a
+	// OneTimeBootDeviceAction set it, for example "pxe". It is empty when the BMC reports no one time boot
+	// override, or when the provider can't read the boot override.
+	// +optional
+	OneTimeBootOverride string `json:"oneTimeBootOverride,omitempty"`
+
+	// RedfishResponse represents the response of the BMC to a RedfishActionPassthroughAction.
+	// +optional
+	RedfishResponse *RedfishResponse `json:"redfishResponse,omitempty"`
+
+	// FirmwareProgress is the percentage of the firmware update of an UpdateFirmwareAction that completed,
+	// as reported by the BMC.
+	// +optional
+	FirmwareProgress int `json:"firmwareProgress,omitempty"`
+
+	// FirmwareTaskMonitor is the path of the Redfish task monitor of the firmware update of an UpdateFirmwareAction.
+	// +optional
+	FirmwareTaskMonitor string `json:"firmwareTaskMonitor,omitempty"`
+
+	// PowerLimit represents the power limit and consumption read by a GetPowerLimitAction, or the power
+	// limit read before a SetPowerLimitAction changed it.
+	// +optional
+	PowerLimit *PowerLimit `json:"powerLimit,omitempty"`
+
+	// BMCNetwork represents the network configuration of the BMC read by a GetBMCNetworkAction, or the
+	// configuration read before a SetBMCNetworkAction changed it.
+	// +optional
+	BMCNetwork *BMCNetwork `json:"bmcNetwork,omitempty"`
+
+	// BootProgress is the last boot progress state of the Machine read by a GetBootProgressAction, for example
+	// "MemoryInitializationStarted" or "OSRunning". For an OEM defined state, it is the state reported by the BMC.
+	// +optional
+	BootProgress string `json:"bootProgress,omitempty"`
+
+	// ProviderErrors represents the error of each BMC provider attempted by the action when the Task failed.
+	// +optional
+	ProviderErrors []ProviderError `json:"providerErrors,omitempty"`
+
+	// Provider is the name of the BMC provider that performed the last action run successfully, for example
+	// "gofish" or "ipmitool". Unlike ProviderErrors, it is set when the action succeeds.
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Capabilities represents the capabilities of the BMC detected by a VerifyConnectionAction,
+	// for example "power", "bootdevice" or "virtualmedia".
+	// +optional
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// HardPowerOffFallback reports whether a SoftPowerOffAction issued a hard power off because
+	// the soft power off did not complete within the grace period.
+	// +optional
+	HardPowerOffFallback bool `json:"hardPowerOffFallback,omitempty"`
+
+	// ActionIndex is the index in Spec.Actions of the action being run.
+	// +optional
+	ActionIndex int `json:"actionIndex,omitempty"`
+
+	// FailedActionIndex is the index in Spec.Actions of the action that failed the Task.
+	// +optional
+	FailedActionIndex *int `json:"failedActionIndex,omitempty"`
+
+	// Attempts is the number of times the action has been run.
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+
+	// FirstAttemptTime represents time when the first attempt of the action was run. The RetryPolicy
+	// MaxElapsedTime is measured from it.
+	// +optional
+	FirstAttemptTime *metav1.Time `json:"firstAttemptTime,omitempty"`
+}
+
+// ProviderError represents the error returned by a single BMC provider.
+type ProviderError struct {
+	// Provider is the name of the provider, for example "gofish" or "ipmitool".
+	Provider string `json:"provider"`
+
+	// Error is the error message returned by the provider.
+	Error string `json:"error"`
 }
 
 type TaskCondition struct {
@@ -79,6 +441,15 @@ type TaskCondition struct {
 	// Can be True or False.
 	Status ConditionStatus `json:"status"`
 
+	// LastTransitionTime is the last time the condition changed from one status to another.
+	// It is nil on conditions set before the field existed.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is the machine readable CamelCase reason of the last transition, for example "ConnectionFailed".
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
 	// Message represents human readable message indicating details about last transition.
 	// +optional
 	Message string `json:"message,omitempty"`
@@ -88,7 +459,8 @@ type TaskCondition struct {
 type TaskSetConditionOption func(*TaskCondition)
 
 // SetCondition applies the cType condition to bmt. If the condition already exists,
-// it is updated.
+// it is updated. LastTransitionTime is only set when the condition status changes, which is also
+// recorded in the History when Spec.HistoryLimit is set.
 func (t *Task) SetCondition(cType TaskConditionType, status ConditionStatus, opts ...TaskSetConditionOption) {
 	var condition *TaskCondition
 
@@ -108,10 +480,31 @@ func (t *Task) SetCondition(cType TaskConditionType, status ConditionStatus, opt
 		condition = &t.Status.Conditions[len(t.Status.Conditions)-1]
 	}
 
+	transition := condition.Status != status
+	if transition {
+		now := metav1.Now()
+		condition.LastTransitionTime = &now
+	}
 	condition.Status = status
 	for _, opt := range opts {
 		opt(condition)
 	}
+	if transition {
+		t.recordTransition(*condition)
+	}
+}
+
+// recordTransition appends c to the History, dropping the oldest transitions over Spec.HistoryLimit.
+func (t *Task) recordTransition(c TaskCondition) {
+	limit := int(t.Spec.HistoryLimit)
+	if limit <= 0 {
+		return
+	}
+
+	t.Status.History = append(t.Status.History, *c.DeepCopy())
+	if over := len(t.Status.History) - limit; over > 0 {
+		t.Status.History = slices.Delete(t.Status.History, 0, over)
+	}
 }
 
 // WithTaskConditionMessage sets message m to the TaskCondition.
@@ -121,6 +514,33 @@ func WithTaskConditionMessage(m string) TaskSetConditionOption {
 	}
 }
 
+// WithTaskConditionReason sets reason r to the TaskCondition.
+func WithTaskConditionReason(r string) TaskSetConditionOption {
+	return func(c *TaskCondition) {
+		c.Reason = r
+	}
+}
+
+// CurrentAction returns the action being run: the action of Spec.Actions at Status.ActionIndex when
+// Spec.Actions is set, Spec.Task otherwise.
+func (t *Task) CurrentAction() Action {
+	if len(t.Spec.Actions) == 0 {
+		return t.Spec.Task
+	}
+	if t.Status.ActionIndex < 0 || t.Status.ActionIndex >= len(t.Spec.Actions) {
+		return Action{}
+	}
+
+	return t.Spec.Actions[t.Status.ActionIndex]
+}
+
+// RemoveCondition removes the cType condition from t, if present.
+func (t *Task) RemoveCondition(cType TaskConditionType) {
+	t.Status.Conditions = slices.DeleteFunc(t.Status.Conditions, func(c TaskCondition) bool {
+		return c.Type == cType
+	})
+}
+
 // HasCondition checks if the cType condition is present with status cStatus on a bmt.
 func (t *Task) HasCondition(cType TaskConditionType, cStatus ConditionStatus) bool {
 	for _, c := range t.Status.Conditions {
@@ -132,8 +552,15 @@ func (t *Task) HasCondition(cType TaskConditionType, cStatus ConditionStatus) bo
 	return false
 }
 
+// Finished reports whether t has Completed, Failed or is Unsupported.
+func (t *Task) Finished() bool {
+	return t.HasCondition(TaskCompleted, ConditionTrue) || t.HasCondition(TaskFailed, ConditionTrue) ||
+		t.HasCondition(TaskUnsupported, ConditionTrue)
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 //+kubebuilder:resource:path=tasks,scope=Namespaced,categories=tinkerbell,singular=task,shortName=t
 
 // Task is the Schema for the Task API.
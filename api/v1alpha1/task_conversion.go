@@ -0,0 +1,5 @@
+package v1alpha1
+
+// Hub marks v1alpha1 as the version the other versions of Task are converted to and from, the version Tasks
+// are stored in.
+func (*Task) Hub() {}
@@ -0,0 +1,82 @@
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+func TestTaskSetConditionLastTransitionTime(t *testing.T) {
+	task := &v1alpha1.Task{}
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse)
+	first := task.Status.Conditions[0].LastTransitionTime
+	if first == nil {
+		t.Fatal("expected last transition time to be set on a new condition")
+	}
+
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage("still running"))
+	if got := task.Status.Conditions[0].LastTransitionTime; got != first {
+		t.Fatalf("expected last transition time to be kept when the status is unchanged, got: %v", got)
+	}
+
+	task.Status.Conditions[0].LastTransitionTime = nil
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse)
+	if got := task.Status.Conditions[0].LastTransitionTime; got != nil {
+		t.Fatalf("expected nil last transition time to be kept when the status is unchanged, got: %v", got)
+	}
+
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)
+	if got := task.Status.Conditions[0].LastTransitionTime; got == nil || got == first {
+		t.Fatalf("expected last transition time to be updated when the status changes, got: %v", got)
+	}
+}
+
+func TestTaskSetConditionReason(t *testing.T) {
+	task := &v1alpha1.Task{}
+	task.SetCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionReason(v1alpha1.TaskReasonAuthFailed), v1alpha1.WithTaskConditionMessage("Authentication failed"))
+	if got := task.Status.Conditions[0].Reason; got != v1alpha1.TaskReasonAuthFailed {
+		t.Fatalf("expected reason %q, got: %q", v1alpha1.TaskReasonAuthFailed, got)
+	}
+
+	// Options that don't set the reason keep it.
+	task.SetCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage("still failed"))
+	if got := task.Status.Conditions[0].Reason; got != v1alpha1.TaskReasonAuthFailed {
+		t.Fatalf("expected reason %q to be kept, got: %q", v1alpha1.TaskReasonAuthFailed, got)
+	}
+}
+
+func TestTaskSetConditionHistory(t *testing.T) {
+	task := &v1alpha1.Task{Spec: v1alpha1.TaskSpec{HistoryLimit: 3}}
+	task.SetCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage("attempt 1"))
+	// Message changes without a status change are not transitions.
+	task.SetCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage("still attempt 1"))
+	task.SetCondition(v1alpha1.TaskRunning, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage("retrying"))
+	task.SetCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage("attempt 2"))
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)
+
+	var got []string
+	for _, c := range task.Status.History {
+		if c.LastTransitionTime == nil {
+			t.Fatalf("expected the transition time of %s to be set", c.Type)
+		}
+		got = append(got, string(c.Type)+"="+string(c.Status)+" "+c.Message)
+	}
+	want := []string{"Running=False retrying", "Running=True attempt 2", "Completed=True "}
+	if len(got) != len(want) {
+		t.Fatalf("expected history %q, got: %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected history %q, got: %q", want, got)
+		}
+	}
+	if len(task.Status.Conditions) != 2 {
+		t.Fatalf("expected the current conditions to be kept, got: %v", task.Status.Conditions)
+	}
+
+	untracked := &v1alpha1.Task{}
+	untracked.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)
+	if untracked.Status.History != nil {
+		t.Fatalf("expected no history without a limit, got: %v", untracked.Status.History)
+	}
+}
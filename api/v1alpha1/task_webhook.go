@@ -0,0 +1,138 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the Task webhooks with the manager. The conversion webhook of the Task versions
+// is registered too, when the scheme of the manager has the other versions.
+// defaultEFIBoot enables defaulting EFIBoot in the mutating webhook, see TaskDefaulter.
+func (t *Task) SetupWebhookWithManager(mgr ctrl.Manager, defaultEFIBoot bool) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(t).
+		WithValidator(&TaskValidator{}).
+		WithDefaulter(NewTaskDefaulter(mgr.GetClient(), defaultEFIBoot)).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-bmc-tinkerbell-org-v1alpha1-task,mutating=true,failurePolicy=fail,sideEffects=None,groups=bmc.tinkerbell.org,resources=tasks,verbs=create,versions=v1alpha1,name=mtask.kb.io,admissionReviewVersions=v1
+
+// TaskDefaulter defaults Task objects on create.
+// It defaults the EFIBoot of the task and actions of Tasks with a MachineRef like JobDefaulter does for Jobs.
+// Tasks with a Connection, like the Tasks created for Jobs, are not changed.
+// +kubebuilder:object:generate=false
+type TaskDefaulter struct {
+	client         client.Reader
+	defaultEFIBoot bool
+}
+
+var _ admission.CustomDefaulter = &TaskDefaulter{}
+
+// NewTaskDefaulter returns a TaskDefaulter that reads Machines with c. defaultEFIBoot enables defaulting EFIBoot,
+// otherwise Tasks are not changed.
+func NewTaskDefaulter(c client.Reader, defaultEFIBoot bool) *TaskDefaulter {
+	return &TaskDefaulter{client: c, defaultEFIBoot: defaultEFIBoot}
+}
+
+// Default implements admission.CustomDefaulter.
+func (d *TaskDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	task, ok := obj.(*Task)
+	if !ok {
+		return fmt.Errorf("expected a Task but got a %T", obj)
+	}
+	if !d.defaultEFIBoot || task.Spec.MachineRef == nil {
+		return nil
+	}
+
+	var raw struct {
+		Spec struct {
+			Task    rawEFIBoot   `json:"task"`
+			Actions []rawEFIBoot `json:"actions"`
+		} `json:"spec"`
+	}
+	if err := decodeRequestObject(ctx, &raw); err != nil {
+		return fmt.Errorf("failed to decode Task: %w", err)
+	}
+	// The task is the first action, followed by the actions.
+	actions := []*Action{&task.Spec.Task}
+	for i := range task.Spec.Actions {
+		actions = append(actions, &task.Spec.Actions[i])
+	}
+	rawActions := append([]rawEFIBoot{raw.Spec.Task}, raw.Spec.Actions...)
+
+	return defaultEFIBoot(ctx, d.client, *task.Spec.MachineRef, actions, rawActions)
+}
+
+//+kubebuilder:webhook:path=/validate-bmc-tinkerbell-org-v1alpha1-task,mutating=false,failurePolicy=fail,sideEffects=None,groups=bmc.tinkerbell.org,resources=tasks,verbs=create;update,versions=v1alpha1,name=vtask.kb.io,admissionReviewVersions=v1
+
+// TaskValidator validates Task objects on create and update.
+// +kubebuilder:object:generate=false
+type TaskValidator struct{}
+
+var _ admission.CustomValidator = &TaskValidator{}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *TaskValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	task, ok := obj.(*Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task but got a %T", obj)
+	}
+
+	return nil, task.validate()
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *TaskValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	task, ok := newObj.(*Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task but got a %T", newObj)
+	}
+
+	return nil, task.validate()
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *TaskValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate returns an Invalid error listing all invalid fields of the Task.
+func (t *Task) validate() error {
+	var allErrs field.ErrorList
+	if len(t.Spec.Actions) > 0 {
+		if !reflect.ValueOf(t.Spec.Task).IsZero() {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "task"), "must be empty when spec.actions is set"))
+		}
+		for i, a := range t.Spec.Actions {
+			allErrs = append(allErrs, validateAction(a, t.Annotations, field.NewPath("spec", "actions").Index(i))...)
+		}
+	} else {
+		allErrs = append(allErrs, validateAction(t.Spec.Task, t.Annotations, field.NewPath("spec", "task"))...)
+	}
+	if t.Spec.MachineRef != nil {
+		// The Connection of the referenced Machine is validated by the Machine webhook.
+		if !reflect.ValueOf(t.Spec.Connection).IsZero() {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "connection"), "must be empty when spec.machineRef is set"))
+		}
+		allErrs = append(allErrs, validateMachineRef(*t.Spec.MachineRef, field.NewPath("spec", "machineRef"))...)
+	} else {
+		if t.Spec.Connection.Host == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec", "connection", "host"), "either spec.connection or spec.machineRef is required"))
+		}
+		allErrs = append(allErrs, validateConnection(t.Spec.Connection, field.NewPath("spec", "connection"))...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Task").GroupKind(), t.Name, allErrs)
+}
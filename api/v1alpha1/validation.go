@@ -0,0 +1,467 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ConfirmClearSELAnnotation must be set to "true" on a Task or Job with a ClearSELAction.
+// It guards against accidentally clearing the System Event Log, for example during bulk operations.
+const ConfirmClearSELAnnotation = "rufio.tinkerbell.org/confirm-clear-sel"
+
+// ConfirmSetBMCCredentialsAnnotation must be set to "true" on a Task or Job with a SetBMCCredentialsAction.
+// It guards against accidentally changing BMC credentials, which may lock out the controller and operators.
+const ConfirmSetBMCCredentialsAnnotation = "rufio.tinkerbell.org/confirm-set-bmc-credentials"
+
+// ConfirmRedfishPassthroughAnnotation must be set to "true" on a Task or Job with a RedfishActionPassthroughAction.
+// Raw Redfish requests are not validated, so they must be confirmed explicitly.
+const ConfirmRedfishPassthroughAnnotation = "rufio.tinkerbell.org/confirm-redfish-passthrough"
+
+// ConfirmSetBMCNetworkAnnotation must be set to "true" on a Task or Job with a SetBMCNetworkAction.
+// Changing the network configuration of the BMC may cut off the controller and operators from it.
+const ConfirmSetBMCNetworkAnnotation = "rufio.tinkerbell.org/confirm-set-bmc-network"
+
+// redfishPassthroughMethods are the HTTP methods of a RedfishActionPassthroughAction.
+var redfishPassthroughMethods = []string{"GET", "POST", "PATCH", "DELETE"}
+
+// firmwareChecksumRegexp matches the Checksum of an UpdateFirmwareAction.
+var firmwareChecksumRegexp = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// ipmiCipherSuites are the IPMI v2.0 cipher suite IDs supported by ipmitool.
+var ipmiCipherSuites = []int{0, 1, 2, 3, 6, 7, 8, 11, 12, 15, 16, 17}
+
+// ipmiCipherSuiteNames returns ipmiCipherSuites as strings, for error messages.
+func ipmiCipherSuiteNames() []string {
+	names := make([]string, 0, len(ipmiCipherSuites))
+	for _, c := range ipmiCipherSuites {
+		names = append(names, strconv.Itoa(c))
+	}
+
+	return names
+}
+
+// supportedResetTypes are the ResetType values of a ResetAction.
+var supportedResetTypes = []string{string(ResetForceRestart), string(ResetGracefulRestart), string(ResetPowerCycle), string(ResetNmi)}
+
+// supportedBootAndPowerActions are the PowerAction values of a BootAndPowerAction.
+var supportedBootAndPowerActions = []string{string(PowerOn), string(PowerCycle), string(PowerReset)}
+
+// supportedBMCResetTypes are the BMCResetType values of a ResetBMCAction.
+var supportedBMCResetTypes = []string{string(BMCResetCold), string(BMCResetWarm)}
+
+// supportedCACertKinds are the kinds of objects a CACertReference can reference.
+var supportedCACertKinds = []string{string(CACertKindSecret), string(CACertKindConfigMap)}
+
+// supportedBootDevices are the BootDevice values that can be set on a Machine, including aliases.
+var supportedBootDevices = []BootDevice{PXE, Network, Disk, BIOS, CDROM, Safe, HTTPBoot, None}
+
+// validateAction validates the fields of a single Action. annotations are the annotations
+// of the object the Action belongs to.
+func validateAction(a Action, annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if a.OneTimeBootDeviceAction != nil {
+		allErrs = append(allErrs, validateBootDevices(a.OneTimeBootDeviceAction.Devices, fldPath.Child("oneTimeBootDeviceAction", "device"))...)
+		allErrs = append(allErrs, validateBootDeviceEntries(a.OneTimeBootDeviceAction.Devices, a.OneTimeBootDeviceAction.Entries, fldPath.Child("oneTimeBootDeviceAction"))...)
+		allErrs = append(allErrs, validateNoneBootDevice(a.OneTimeBootDeviceAction.Devices, a.OneTimeBootDeviceAction.Entries, a.OneTimeBootDeviceAction.Persistent, fldPath.Child("oneTimeBootDeviceAction"))...)
+		var first BootDevice
+		if entries := a.OneTimeBootDeviceAction.BootEntries(); len(entries) > 0 {
+			first = entries[0].Device
+		}
+		allErrs = append(allErrs, validateBootURL(a.OneTimeBootDeviceAction.BootURL, first, fldPath.Child("oneTimeBootDeviceAction", "bootURL"))...)
+	}
+	if a.PersistentBootDeviceAction != nil {
+		allErrs = append(allErrs, validateBootDevices(a.PersistentBootDeviceAction.Devices, fldPath.Child("persistentBootDeviceAction", "device"))...)
+		allErrs = append(allErrs, validateBootDeviceEntries(a.PersistentBootDeviceAction.Devices, a.PersistentBootDeviceAction.Entries, fldPath.Child("persistentBootDeviceAction"))...)
+		allErrs = append(allErrs, validateNoneBootDevice(a.PersistentBootDeviceAction.Devices, a.PersistentBootDeviceAction.Entries, true, fldPath.Child("persistentBootDeviceAction"))...)
+	}
+	if a.VirtualMediaAction != nil {
+		allErrs = append(allErrs, validateVirtualMediaAction(*a.VirtualMediaAction, fldPath.Child("virtualMediaAction"))...)
+	}
+	if a.SoftPowerOffAction != nil && a.SoftPowerOffAction.GracePeriod != nil && a.SoftPowerOffAction.GracePeriod.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("softPowerOffAction", "gracePeriod"), a.SoftPowerOffAction.GracePeriod.Duration.String(), "must be greater than 0"))
+	}
+	if a.PowerCycleAction != nil && a.PowerCycleAction.WaitTimeout != nil && a.PowerCycleAction.WaitTimeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("powerCycleAction", "waitTimeout"), a.PowerCycleAction.WaitTimeout.Duration.String(), "must be greater than 0"))
+	}
+	if a.ResetAction != nil && !slices.Contains(supportedResetTypes, string(a.ResetAction.ResetType)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("resetAction", "resetType"), a.ResetAction.ResetType, supportedResetTypes))
+	}
+	if a.IdentifyAction != nil {
+		allErrs = append(allErrs, validateIdentifyAction(*a.IdentifyAction, fldPath.Child("identifyAction"))...)
+	}
+	if a.ClearSELAction != nil && annotations[ConfirmClearSELAnnotation] != "true" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("clearSELAction"), fmt.Sprintf("requires the annotation %s: \"true\"", ConfirmClearSELAnnotation)))
+	}
+	if a.SetBMCCredentialsAction != nil {
+		allErrs = append(allErrs, validateSetBMCCredentialsAction(*a.SetBMCCredentialsAction, annotations, fldPath.Child("setBMCCredentialsAction"))...)
+	}
+	if a.RedfishActionPassthroughAction != nil {
+		allErrs = append(allErrs, validateRedfishActionPassthroughAction(*a.RedfishActionPassthroughAction, annotations, fldPath.Child("redfishActionPassthroughAction"))...)
+	}
+	if a.UpdateFirmwareAction != nil {
+		allErrs = append(allErrs, validateUpdateFirmwareAction(*a.UpdateFirmwareAction, fldPath.Child("updateFirmwareAction"))...)
+	}
+	if a.SetPowerLimitAction != nil && a.SetPowerLimitAction.LimitWatts < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("setPowerLimitAction", "limitWatts"), a.SetPowerLimitAction.LimitWatts, "must be greater than 0"))
+	}
+	if a.ResetBMCAction != nil && !slices.Contains(supportedBMCResetTypes, string(a.ResetBMCAction.Type)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("resetBMCAction", "type"), a.ResetBMCAction.Type, supportedBMCResetTypes))
+	}
+	if a.BootAndPowerAction != nil {
+		allErrs = append(allErrs, validateBootAndPowerAction(*a.BootAndPowerAction, fldPath.Child("bootAndPowerAction"))...)
+	}
+	if a.SetBMCNetworkAction != nil {
+		allErrs = append(allErrs, validateSetBMCNetworkAction(*a.SetBMCNetworkAction, annotations, fldPath.Child("setBMCNetworkAction"))...)
+	}
+
+	return allErrs
+}
+
+// validateSetBMCCredentialsAction validates the account and password Secret of a SetBMCCredentialsAction
+// and that the change is confirmed by annotation.
+func validateSetBMCCredentialsAction(a SetBMCCredentialsAction, annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if annotations[ConfirmSetBMCCredentialsAnnotation] != "true" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("requires the annotation %s: \"true\"", ConfirmSetBMCCredentialsAnnotation)))
+	}
+	if a.Username == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("username"), "the BMC account to change is required"))
+	}
+	if a.PasswordSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("passwordSecretRef", "name"), "the Secret holding the new password is required"))
+	}
+
+	return allErrs
+}
+
+// validateRedfishActionPassthroughAction validates the request of a RedfishActionPassthroughAction and that
+// it is confirmed by annotation.
+func validateRedfishActionPassthroughAction(a RedfishActionPassthroughAction, annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if annotations[ConfirmRedfishPassthroughAnnotation] != "true" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("requires the annotation %s: \"true\"", ConfirmRedfishPassthroughAnnotation)))
+	}
+	if !slices.Contains(redfishPassthroughMethods, a.Method) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("method"), a.Method, redfishPassthroughMethods))
+	}
+	if u, err := url.Parse(a.Path); err != nil || !strings.HasPrefix(a.Path, "/redfish/") || u.Host != "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), a.Path, "must be a path starting with /redfish/"))
+	}
+	if a.Body != nil && len(a.Body.Raw) > 0 && !json.Valid(a.Body.Raw) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("body"), string(a.Body.Raw), "must be valid JSON"))
+	}
+
+	return allErrs
+}
+
+// validateSetBMCNetworkAction validates the IPv4 configuration of a SetBMCNetworkAction and that the change
+// is confirmed by annotation.
+func validateSetBMCNetworkAction(a SetBMCNetworkAction, annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if annotations[ConfirmSetBMCNetworkAnnotation] != "true" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("requires the annotation %s: \"true\"", ConfirmSetBMCNetworkAnnotation)))
+	}
+	switch {
+	case a.DHCP && a.Address != "":
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("address"), "must be empty when dhcp is true"))
+	case !a.DHCP && a.Address == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("address"), "either dhcp or a static address is required"))
+	}
+	for _, f := range []struct{ name, value string }{{"address", a.Address}, {"subnetMask", a.SubnetMask}, {"gateway", a.Gateway}} {
+		if ip, err := netip.ParseAddr(f.value); f.value != "" && (err != nil || !ip.Is4()) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child(f.name), f.value, "must be an IPv4 address"))
+		}
+	}
+	if a.Address != "" && a.SubnetMask == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("subnetMask"), "the subnet mask of the address is required"))
+	}
+
+	return allErrs
+}
+
+// validateUpdateFirmwareAction validates the component, the image URL and the checksum of an UpdateFirmwareAction.
+func validateUpdateFirmwareAction(a UpdateFirmwareAction, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if a.Component == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("component"), "the firmware component to update is required"))
+	}
+	if u, err := url.Parse(a.ImageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("imageURL"), a.ImageURL, "must be a http or https URL"))
+	}
+	if a.Checksum != "" && !firmwareChecksumRegexp.MatchString(a.Checksum) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("checksum"), a.Checksum, "must be sha256:<hex digest>"))
+	}
+
+	return allErrs
+}
+
+// validateIdentifyAction validates the state and duration of an IdentifyAction.
+func validateIdentifyAction(a IdentifyAction, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	states := []string{string(IdentifyOn), string(IdentifyOff), string(IdentifyBlink)}
+	if !slices.Contains(states, string(a.State)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("state"), a.State, states))
+	}
+	if a.DurationSeconds != nil && *a.DurationSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("durationSeconds"), *a.DurationSeconds, "must be greater than 0"))
+	}
+	if a.DurationSeconds != nil && a.State == IdentifyOff {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("durationSeconds"), "must not be set when state is off"))
+	}
+
+	return allErrs
+}
+
+// validateConnection validates the fields of a Connection.
+func validateConnection(c Connection, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.Port < 0 || c.Port > 65535 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), c.Port, "must be between 1 and 65535, or 0 to use the protocol default"))
+	}
+	if c.Username != "" {
+		switch {
+		case c.AuthProviderRef != nil:
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("username"), "must not be set with authProviderRef, the credential provider supplies the username"))
+		case c.ProviderOptions != nil && c.ProviderOptions.RPC != nil:
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("username"), "must not be set with the RPC provider, which authenticates with HMAC secrets"))
+		case c.AuthSecretRef.Name == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("authSecretRef", "name"), "a Secret with the password is required when username is set"))
+		}
+	}
+	if c.ClientCertSecretRef != nil && c.ClientCertSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clientCertSecretRef", "name"), "the name of a kubernetes.io/tls Secret is required"))
+	}
+	if c.CACertSecretRef != nil {
+		if c.CACertSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("caCertSecretRef", "name"), "the name of a Secret or ConfigMap with a CA bundle is required"))
+		}
+		if k := c.CACertSecretRef.CAKind(); k != CACertKindSecret && k != CACertKindConfigMap {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("caCertSecretRef", "kind"), k, supportedCACertKinds))
+		}
+		if c.InsecureTLS {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("insecureTLS"), "must not be set with caCertSecretRef, which verifies the BMC certificate"))
+		}
+	}
+	if c.ProxyURL != "" {
+		allErrs = append(allErrs, validateProxyURL(c.ProxyURL, fldPath.Child("proxyURL"))...)
+	}
+	if c.IPMIOptions != nil && c.IPMIOptions.CipherSuite != nil && !slices.Contains(ipmiCipherSuites, *c.IPMIOptions.CipherSuite) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("ipmiOptions", "cipherSuite"), *c.IPMIOptions.CipherSuite, ipmiCipherSuiteNames()))
+	}
+	if c.ProviderOptions != nil && c.ProviderOptions.DisablePortProbing && !hasConfiguredPort(c) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("providerOptions", "disablePortProbing"), true, "requires port, or a port or RPC in providerOptions, to be set"))
+	}
+
+	return allErrs
+}
+
+// hasConfiguredPort reports whether c explicitly configures the port of at least one protocol, or the RPC provider.
+func hasConfiguredPort(c Connection) bool {
+	if c.Port != 0 {
+		return true
+	}
+	o := c.ProviderOptions
+
+	return (o.Redfish != nil && o.Redfish.Port != 0) || (o.IPMITOOL != nil && o.IPMITOOL.Port != 0) ||
+		(o.IntelAMT != nil && o.IntelAMT.Port != 0) || o.RPC != nil
+}
+
+// proxySchemes are the URL schemes of the proxies supported by the Go HTTP transport.
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// IsSupportedProxyScheme reports whether scheme is a supported Connection.ProxyURL scheme.
+func IsSupportedProxyScheme(scheme string) bool {
+	return slices.Contains(proxySchemes, scheme)
+}
+
+// validateMachineRef returns the errors of a reference to a Machine.
+func validateMachineRef(ref MachineRef, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "the name of a Machine is required"))
+	}
+	if ref.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), "the namespace of the Machine is required"))
+	}
+
+	return allErrs
+}
+
+// validateProxyURL validates that proxyURL is an absolute URL with a supported scheme and a host.
+func validateProxyURL(proxyURL string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath, proxyURL, fmt.Sprintf("must be a valid URL: %v", err)))
+	}
+	if !IsSupportedProxyScheme(u.Scheme) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("scheme"), u.Scheme, proxySchemes))
+	}
+	if u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath, u.Redacted(), "must contain a host"))
+	}
+
+	return allErrs
+}
+
+// validateBootDevices validates that every device is a supported BootDevice.
+func validateBootDevices(devices []BootDevice, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, d := range devices {
+		if err := validateBootDevice(d, fldPath.Index(i)); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return allErrs
+}
+
+// validateBootDevice validates that d is a supported BootDevice.
+func validateBootDevice(d BootDevice, fldPath *field.Path) *field.Error {
+	if slices.Contains(supportedBootDevices, d) {
+		return nil
+	}
+	if trimmed := BootDevice(strings.TrimSpace(string(d))); slices.Contains(supportedBootDevices, trimmed) {
+		return field.Invalid(fldPath, d, fmt.Sprintf("must not contain whitespace, did you mean %q?", trimmed))
+	}
+
+	valid := make([]string, 0, len(supportedBootDevices))
+	for _, d := range supportedBootDevices {
+		valid = append(valid, string(d))
+	}
+	return field.NotSupported(fldPath, d, valid)
+}
+
+// validateBootDeviceEntries validates that exactly one of devices and entries is set, and that the device
+// of every entry is a supported BootDevice.
+func validateBootDeviceEntries(devices []BootDevice, entries []BootDeviceEntry, fldPath *field.Path) field.ErrorList {
+	if len(entries) == 0 {
+		if len(devices) == 0 {
+			return field.ErrorList{field.Required(fldPath.Child("device"), "exactly one of device and entries must be set")}
+		}
+		return nil
+	}
+	if len(devices) > 0 {
+		return field.ErrorList{field.Forbidden(fldPath.Child("entries"), "exactly one of device and entries must be set")}
+	}
+
+	var allErrs field.ErrorList
+	for i, e := range entries {
+		if err := validateBootDevice(e.Device, fldPath.Child("entries").Index(i).Child("device")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return allErrs
+}
+
+// validateBootAndPowerAction validates that the device of a is a supported BootDevice other than none, and that
+// its PowerAction powers the Machine on.
+func validateBootAndPowerAction(a BootAndPowerAction, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if a.Device == None {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("device"), a.Device, "must be a boot device, use a oneTimeBootDeviceAction to clear the boot override"))
+	} else if err := validateBootDevice(a.Device, fldPath.Child("device")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if !slices.Contains(supportedBootAndPowerActions, string(a.Power())) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("powerAction"), a.PowerAction, supportedBootAndPowerActions))
+	}
+	allErrs = append(allErrs, validateBootURL(a.BootURL, a.Device, fldPath.Child("bootURL"))...)
+
+	return allErrs
+}
+
+// validateBootURL validates that bootURL is only set when device, the boot device it applies to, is httpboot,
+// and that it is a well-formed http(s) URL.
+func validateBootURL(bootURL string, device BootDevice, fldPath *field.Path) field.ErrorList {
+	if bootURL == "" {
+		return nil
+	}
+	if device != HTTPBoot {
+		return field.ErrorList{field.Forbidden(fldPath, "must only be set when the boot device is httpboot")}
+	}
+
+	var allErrs field.ErrorList
+	u, err := url.Parse(bootURL)
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath, bootURL, err.Error()))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		allErrs = append(allErrs, field.Invalid(fldPath, bootURL, "scheme must be http or https"))
+	}
+	if u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath, bootURL, "host must not be empty"))
+	}
+
+	return allErrs
+}
+
+// validateNoneBootDevice validates that the None BootDevice, which clears the boot override, is the single device
+// of a boot device action that is not persistent.
+func validateNoneBootDevice(devices []BootDevice, entries []BootDeviceEntry, persistent bool, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, e := range bootEntries(devices, entries, false) {
+		if e.Device != None {
+			continue
+		}
+		p := fldPath.Child("device").Index(i)
+		if len(entries) > 0 {
+			p = fldPath.Child("entries").Index(i).Child("device")
+		}
+		switch {
+		case persistent:
+			allErrs = append(allErrs, field.Forbidden(p, "none clears the one time boot override and is only supported by a oneTimeBootDeviceAction that is not persistent"))
+		case len(devices)+len(entries) > 1:
+			allErrs = append(allErrs, field.Invalid(p, e.Device, "none must be the only boot device"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateVirtualMediaAction validates that mediaURL is a well-formed http(s) URL, or is empty when ejecting.
+func validateVirtualMediaAction(a VirtualMediaAction, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if a.Eject {
+		if a.MediaURL != "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("mediaURL"), a.MediaURL, "must be empty when eject is true"))
+		}
+		return allErrs
+	}
+
+	if a.MediaURL == "" {
+		return allErrs
+	}
+
+	u, err := url.Parse(a.MediaURL)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mediaURL"), a.MediaURL, err.Error()))
+		return allErrs
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mediaURL"), a.MediaURL, "scheme must be http or https"))
+	}
+	if u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("mediaURL"), a.MediaURL, "host must not be empty"))
+	}
+
+	return allErrs
+}
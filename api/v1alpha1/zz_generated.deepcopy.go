@@ -21,8 +21,9 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"net/http"
 )
 
@@ -39,11 +40,126 @@ func (in *Action) DeepCopyInto(out *Action) {
 		*out = new(OneTimeBootDeviceAction)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PersistentBootDeviceAction != nil {
+		in, out := &in.PersistentBootDeviceAction, &out.PersistentBootDeviceAction
+		*out = new(PersistentBootDeviceAction)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.VirtualMediaAction != nil {
 		in, out := &in.VirtualMediaAction, &out.VirtualMediaAction
 		*out = new(VirtualMediaAction)
 		**out = **in
 	}
+	if in.GetBIOSConfigAction != nil {
+		in, out := &in.GetBIOSConfigAction, &out.GetBIOSConfigAction
+		*out = new(GetBIOSConfigAction)
+		**out = **in
+	}
+	if in.SetBIOSConfigAction != nil {
+		in, out := &in.SetBIOSConfigAction, &out.SetBIOSConfigAction
+		*out = new(SetBIOSConfigAction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GetSELAction != nil {
+		in, out := &in.GetSELAction, &out.GetSELAction
+		*out = new(GetSELAction)
+		**out = **in
+	}
+	if in.ClearSELAction != nil {
+		in, out := &in.ClearSELAction, &out.ClearSELAction
+		*out = new(ClearSELAction)
+		**out = **in
+	}
+	if in.GetFirmwareInventoryAction != nil {
+		in, out := &in.GetFirmwareInventoryAction, &out.GetFirmwareInventoryAction
+		*out = new(GetFirmwareInventoryAction)
+		**out = **in
+	}
+	if in.SoftPowerOffAction != nil {
+		in, out := &in.SoftPowerOffAction, &out.SoftPowerOffAction
+		*out = new(SoftPowerOffAction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PowerCycleAction != nil {
+		in, out := &in.PowerCycleAction, &out.PowerCycleAction
+		*out = new(PowerCycleAction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VerifyConnectionAction != nil {
+		in, out := &in.VerifyConnectionAction, &out.VerifyConnectionAction
+		*out = new(VerifyConnectionAction)
+		**out = **in
+	}
+	if in.ResetAction != nil {
+		in, out := &in.ResetAction, &out.ResetAction
+		*out = new(ResetAction)
+		**out = **in
+	}
+	if in.IdentifyAction != nil {
+		in, out := &in.IdentifyAction, &out.IdentifyAction
+		*out = new(IdentifyAction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GetSensorsAction != nil {
+		in, out := &in.GetSensorsAction, &out.GetSensorsAction
+		*out = new(GetSensorsAction)
+		**out = **in
+	}
+	if in.GetBootDeviceAction != nil {
+		in, out := &in.GetBootDeviceAction, &out.GetBootDeviceAction
+		*out = new(GetBootDeviceAction)
+		**out = **in
+	}
+	if in.SetBMCCredentialsAction != nil {
+		in, out := &in.SetBMCCredentialsAction, &out.SetBMCCredentialsAction
+		*out = new(SetBMCCredentialsAction)
+		**out = **in
+	}
+	if in.RedfishActionPassthroughAction != nil {
+		in, out := &in.RedfishActionPassthroughAction, &out.RedfishActionPassthroughAction
+		*out = new(RedfishActionPassthroughAction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpdateFirmwareAction != nil {
+		in, out := &in.UpdateFirmwareAction, &out.UpdateFirmwareAction
+		*out = new(UpdateFirmwareAction)
+		**out = **in
+	}
+	if in.SetPowerLimitAction != nil {
+		in, out := &in.SetPowerLimitAction, &out.SetPowerLimitAction
+		*out = new(SetPowerLimitAction)
+		**out = **in
+	}
+	if in.GetPowerLimitAction != nil {
+		in, out := &in.GetPowerLimitAction, &out.GetPowerLimitAction
+		*out = new(GetPowerLimitAction)
+		**out = **in
+	}
+	if in.GetBMCNetworkAction != nil {
+		in, out := &in.GetBMCNetworkAction, &out.GetBMCNetworkAction
+		*out = new(GetBMCNetworkAction)
+		**out = **in
+	}
+	if in.SetBMCNetworkAction != nil {
+		in, out := &in.SetBMCNetworkAction, &out.SetBMCNetworkAction
+		*out = new(SetBMCNetworkAction)
+		**out = **in
+	}
+	if in.ResetBMCAction != nil {
+		in, out := &in.ResetBMCAction, &out.ResetBMCAction
+		*out = new(ResetBMCAction)
+		**out = **in
+	}
+	if in.BootAndPowerAction != nil {
+		in, out := &in.BootAndPowerAction, &out.BootAndPowerAction
+		*out = new(BootAndPowerAction)
+		**out = **in
+	}
+	if in.GetBootProgressAction != nil {
+		in, out := &in.GetBootProgressAction, &out.GetBootProgressAction
+		*out = new(GetBootProgressAction)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Action.
@@ -56,10 +172,145 @@ func (in *Action) DeepCopy() *Action {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthProviderRef) DeepCopyInto(out *AuthProviderRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthProviderRef.
+func (in *AuthProviderRef) DeepCopy() *AuthProviderRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthProviderRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMCNetwork) DeepCopyInto(out *BMCNetwork) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]BMCNetworkInterface, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCNetwork.
+func (in *BMCNetwork) DeepCopy() *BMCNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(BMCNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BMCNetworkInterface) DeepCopyInto(out *BMCNetworkInterface) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCNetworkInterface.
+func (in *BMCNetworkInterface) DeepCopy() *BMCNetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(BMCNetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootAndPowerAction) DeepCopyInto(out *BootAndPowerAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootAndPowerAction.
+func (in *BootAndPowerAction) DeepCopy() *BootAndPowerAction {
+	if in == nil {
+		return nil
+	}
+	out := new(BootAndPowerAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootDeviceEntry) DeepCopyInto(out *BootDeviceEntry) {
+	*out = *in
+	if in.EFI != nil {
+		in, out := &in.EFI, &out.EFI
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootDeviceEntry.
+func (in *BootDeviceEntry) DeepCopy() *BootDeviceEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(BootDeviceEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CACertReference) DeepCopyInto(out *CACertReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CACertReference.
+func (in *CACertReference) DeepCopy() *CACertReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CACertReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClearSELAction) DeepCopyInto(out *ClearSELAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClearSELAction.
+func (in *ClearSELAction) DeepCopy() *ClearSELAction {
+	if in == nil {
+		return nil
+	}
+	out := new(ClearSELAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Connection) DeepCopyInto(out *Connection) {
 	*out = *in
 	out.AuthSecretRef = in.AuthSecretRef
+	if in.AuthProviderRef != nil {
+		in, out := &in.AuthProviderRef, &out.AuthProviderRef
+		*out = new(AuthProviderRef)
+		**out = **in
+	}
+	if in.ClientCertSecretRef != nil {
+		in, out := &in.ClientCertSecretRef, &out.ClientCertSecretRef
+		*out = new(corev1.SecretReference)
+		**out = **in
+	}
+	if in.CACertSecretRef != nil {
+		in, out := &in.CACertSecretRef, &out.CACertSecretRef
+		*out = new(CACertReference)
+		**out = **in
+	}
+	if in.IPMIOptions != nil {
+		in, out := &in.IPMIOptions, &out.IPMIOptions
+		*out = new(IPMIOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ProviderOptions != nil {
 		in, out := &in.ProviderOptions, &out.ProviderOptions
 		*out = new(ProviderOptions)
@@ -77,6 +328,21 @@ func (in *Connection) DeepCopy() *Connection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunResult) DeepCopyInto(out *DryRunResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunResult.
+func (in *DryRunResult) DeepCopy() *DryRunResult {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentalOpts) DeepCopyInto(out *ExperimentalOpts) {
 	*out = *in
@@ -92,6 +358,141 @@ func (in *ExperimentalOpts) DeepCopy() *ExperimentalOpts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirmwareComponent) DeepCopyInto(out *FirmwareComponent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareComponent.
+func (in *FirmwareComponent) DeepCopy() *FirmwareComponent {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareComponent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetBIOSConfigAction) DeepCopyInto(out *GetBIOSConfigAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetBIOSConfigAction.
+func (in *GetBIOSConfigAction) DeepCopy() *GetBIOSConfigAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetBIOSConfigAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetBMCNetworkAction) DeepCopyInto(out *GetBMCNetworkAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetBMCNetworkAction.
+func (in *GetBMCNetworkAction) DeepCopy() *GetBMCNetworkAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetBMCNetworkAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetBootDeviceAction) DeepCopyInto(out *GetBootDeviceAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetBootDeviceAction.
+func (in *GetBootDeviceAction) DeepCopy() *GetBootDeviceAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetBootDeviceAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetBootProgressAction) DeepCopyInto(out *GetBootProgressAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetBootProgressAction.
+func (in *GetBootProgressAction) DeepCopy() *GetBootProgressAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetBootProgressAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetFirmwareInventoryAction) DeepCopyInto(out *GetFirmwareInventoryAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetFirmwareInventoryAction.
+func (in *GetFirmwareInventoryAction) DeepCopy() *GetFirmwareInventoryAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetFirmwareInventoryAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetPowerLimitAction) DeepCopyInto(out *GetPowerLimitAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetPowerLimitAction.
+func (in *GetPowerLimitAction) DeepCopy() *GetPowerLimitAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetPowerLimitAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetSELAction) DeepCopyInto(out *GetSELAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetSELAction.
+func (in *GetSELAction) DeepCopy() *GetSELAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetSELAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GetSensorsAction) DeepCopyInto(out *GetSensorsAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GetSensorsAction.
+func (in *GetSensorsAction) DeepCopy() *GetSensorsAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GetSensorsAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HMACOpts) DeepCopyInto(out *HMACOpts) {
 	*out = *in
@@ -99,13 +500,13 @@ func (in *HMACOpts) DeepCopyInto(out *HMACOpts) {
 		in, out := &in.Secrets, &out.Secrets
 		*out = make(HMACSecrets, len(*in))
 		for key, val := range *in {
-			var outVal []v1.SecretReference
+			var outVal []corev1.SecretReference
 			if val == nil {
 				(*out)[key] = nil
 			} else {
 				inVal := (*in)[key]
 				in, out := &inVal, &outVal
-				*out = make([]v1.SecretReference, len(*in))
+				*out = make([]corev1.SecretReference, len(*in))
 				copy(*out, *in)
 			}
 			(*out)[key] = outVal
@@ -129,13 +530,13 @@ func (in HMACSecrets) DeepCopyInto(out *HMACSecrets) {
 		in := &in
 		*out = make(HMACSecrets, len(*in))
 		for key, val := range *in {
-			var outVal []v1.SecretReference
+			var outVal []corev1.SecretReference
 			if val == nil {
 				(*out)[key] = nil
 			} else {
 				inVal := (*in)[key]
 				in, out := &inVal, &outVal
-				*out = make([]v1.SecretReference, len(*in))
+				*out = make([]corev1.SecretReference, len(*in))
 				copy(*out, *in)
 			}
 			(*out)[key] = outVal
@@ -153,6 +554,26 @@ func (in HMACSecrets) DeepCopy() HMACSecrets {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPMIOptions) DeepCopyInto(out *IPMIOptions) {
+	*out = *in
+	if in.CipherSuite != nil {
+		in, out := &in.CipherSuite, &out.CipherSuite
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPMIOptions.
+func (in *IPMIOptions) DeepCopy() *IPMIOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(IPMIOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IPMITOOLOptions) DeepCopyInto(out *IPMITOOLOptions) {
 	*out = *in
@@ -168,6 +589,26 @@ func (in *IPMITOOLOptions) DeepCopy() *IPMITOOLOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentifyAction) DeepCopyInto(out *IdentifyAction) {
+	*out = *in
+	if in.DurationSeconds != nil {
+		in, out := &in.DurationSeconds, &out.DurationSeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentifyAction.
+func (in *IdentifyAction) DeepCopy() *IdentifyAction {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentifyAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntelAMTOptions) DeepCopyInto(out *IntelAMTOptions) {
 	*out = *in
@@ -268,6 +709,11 @@ func (in *JobSpec) DeepCopyInto(out *JobSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PropagateLabels != nil {
+		in, out := &in.PropagateLabels, &out.PropagateLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobSpec.
@@ -296,6 +742,21 @@ func (in *JobStatus) DeepCopyInto(out *JobStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.FinishedTasks != nil {
+		in, out := &in.FinishedTasks, &out.FinishedTasks
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedTasks != nil {
+		in, out := &in.SkippedTasks, &out.SkippedTasks
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.DryRunResults != nil {
+		in, out := &in.DryRunResults, &out.DryRunResults
+		*out = make([]DryRunResult, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobStatus.
@@ -402,56 +863,171 @@ func (in *MachineRef) DeepCopy() *MachineRef {
 func (in *MachineSpec) DeepCopyInto(out *MachineSpec) {
 	*out = *in
 	in.Connection.DeepCopyInto(&out.Connection)
+	if in.PowerCheckInterval != nil {
+		in, out := &in.PowerCheckInterval, &out.PowerCheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineSpec.
+func (in *MachineSpec) DeepCopy() *MachineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineStatus) DeepCopyInto(out *MachineStatus) {
+	*out = *in
+	if in.LastPowerStateUpdate != nil {
+		in, out := &in.LastPowerStateUpdate, &out.LastPowerStateUpdate
+		*out = (*in).DeepCopy()
+	}
+	if in.LastEnforcementTime != nil {
+		in, out := &in.LastEnforcementTime, &out.LastEnforcementTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastInventoryTime != nil {
+		in, out := &in.LastInventoryTime, &out.LastInventoryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastCapabilitiesTime != nil {
+		in, out := &in.LastCapabilitiesTime, &out.LastCapabilitiesTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MachineCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineStatus.
+func (in *MachineStatus) DeepCopy() *MachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OneTimeBootDeviceAction) DeepCopyInto(out *OneTimeBootDeviceAction) {
+	*out = *in
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]BootDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]BootDeviceEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OneTimeBootDeviceAction.
+func (in *OneTimeBootDeviceAction) DeepCopy() *OneTimeBootDeviceAction {
+	if in == nil {
+		return nil
+	}
+	out := new(OneTimeBootDeviceAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentBootDeviceAction) DeepCopyInto(out *PersistentBootDeviceAction) {
+	*out = *in
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]BootDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]BootDeviceEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentBootDeviceAction.
+func (in *PersistentBootDeviceAction) DeepCopy() *PersistentBootDeviceAction {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentBootDeviceAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerCycleAction) DeepCopyInto(out *PowerCycleAction) {
+	*out = *in
+	if in.WaitTimeout != nil {
+		in, out := &in.WaitTimeout, &out.WaitTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineSpec.
-func (in *MachineSpec) DeepCopy() *MachineSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerCycleAction.
+func (in *PowerCycleAction) DeepCopy() *PowerCycleAction {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineSpec)
+	out := new(PowerCycleAction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineStatus) DeepCopyInto(out *MachineStatus) {
+func (in *PowerLimit) DeepCopyInto(out *PowerLimit) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]MachineCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineStatus.
-func (in *MachineStatus) DeepCopy() *MachineStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerLimit.
+func (in *PowerLimit) DeepCopy() *PowerLimit {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineStatus)
+	out := new(PowerLimit)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OneTimeBootDeviceAction) DeepCopyInto(out *OneTimeBootDeviceAction) {
+func (in *ProviderError) DeepCopyInto(out *ProviderError) {
 	*out = *in
-	if in.Devices != nil {
-		in, out := &in.Devices, &out.Devices
-		*out = make([]BootDevice, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OneTimeBootDeviceAction.
-func (in *OneTimeBootDeviceAction) DeepCopy() *OneTimeBootDeviceAction {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderError.
+func (in *ProviderError) DeepCopy() *ProviderError {
 	if in == nil {
 		return nil
 	}
-	out := new(OneTimeBootDeviceAction)
+	out := new(ProviderError)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -464,6 +1040,11 @@ func (in *ProviderOptions) DeepCopyInto(out *ProviderOptions) {
 		*out = make([]ProviderName, len(*in))
 		copy(*out, *in)
 	}
+	if in.PreferredProviders != nil {
+		in, out := &in.PreferredProviders, &out.PreferredProviders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.IntelAMT != nil {
 		in, out := &in.IntelAMT, &out.IntelAMT
 		*out = new(IntelAMTOptions)
@@ -477,7 +1058,7 @@ func (in *ProviderOptions) DeepCopyInto(out *ProviderOptions) {
 	if in.Redfish != nil {
 		in, out := &in.Redfish, &out.Redfish
 		*out = new(RedfishOptions)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.RPC != nil {
 		in, out := &in.RPC, &out.RPC
@@ -531,9 +1112,34 @@ func (in *RPCOptions) DeepCopy() *RPCOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedfishActionPassthroughAction) DeepCopyInto(out *RedfishActionPassthroughAction) {
+	*out = *in
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedfishActionPassthroughAction.
+func (in *RedfishActionPassthroughAction) DeepCopy() *RedfishActionPassthroughAction {
+	if in == nil {
+		return nil
+	}
+	out := new(RedfishActionPassthroughAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedfishOptions) DeepCopyInto(out *RedfishOptions) {
 	*out = *in
+	if in.VersionsNotCompatible != nil {
+		in, out := &in.VersionsNotCompatible, &out.VersionsNotCompatible
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedfishOptions.
@@ -546,6 +1152,21 @@ func (in *RedfishOptions) DeepCopy() *RedfishOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedfishResponse) DeepCopyInto(out *RedfishResponse) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedfishResponse.
+func (in *RedfishResponse) DeepCopy() *RedfishResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(RedfishResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequestOpts) DeepCopyInto(out *RequestOpts) {
 	*out = *in
@@ -577,6 +1198,164 @@ func (in *RequestOpts) DeepCopy() *RequestOpts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResetAction) DeepCopyInto(out *ResetAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResetAction.
+func (in *ResetAction) DeepCopy() *ResetAction {
+	if in == nil {
+		return nil
+	}
+	out := new(ResetAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResetBMCAction) DeepCopyInto(out *ResetBMCAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResetBMCAction.
+func (in *ResetBMCAction) DeepCopy() *ResetBMCAction {
+	if in == nil {
+		return nil
+	}
+	out := new(ResetBMCAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.BackoffBase != nil {
+		in, out := &in.BackoffBase, &out.BackoffBase
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxElapsedTime != nil {
+		in, out := &in.MaxElapsedTime, &out.MaxElapsedTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SELEntry) DeepCopyInto(out *SELEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SELEntry.
+func (in *SELEntry) DeepCopy() *SELEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(SELEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SensorReading) DeepCopyInto(out *SensorReading) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SensorReading.
+func (in *SensorReading) DeepCopy() *SensorReading {
+	if in == nil {
+		return nil
+	}
+	out := new(SensorReading)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetBIOSConfigAction) DeepCopyInto(out *SetBIOSConfigAction) {
+	*out = *in
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetBIOSConfigAction.
+func (in *SetBIOSConfigAction) DeepCopy() *SetBIOSConfigAction {
+	if in == nil {
+		return nil
+	}
+	out := new(SetBIOSConfigAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetBMCCredentialsAction) DeepCopyInto(out *SetBMCCredentialsAction) {
+	*out = *in
+	out.PasswordSecretRef = in.PasswordSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetBMCCredentialsAction.
+func (in *SetBMCCredentialsAction) DeepCopy() *SetBMCCredentialsAction {
+	if in == nil {
+		return nil
+	}
+	out := new(SetBMCCredentialsAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetBMCNetworkAction) DeepCopyInto(out *SetBMCNetworkAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetBMCNetworkAction.
+func (in *SetBMCNetworkAction) DeepCopy() *SetBMCNetworkAction {
+	if in == nil {
+		return nil
+	}
+	out := new(SetBMCNetworkAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetPowerLimitAction) DeepCopyInto(out *SetPowerLimitAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetPowerLimitAction.
+func (in *SetPowerLimitAction) DeepCopy() *SetPowerLimitAction {
+	if in == nil {
+		return nil
+	}
+	out := new(SetPowerLimitAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SignatureOpts) DeepCopyInto(out *SignatureOpts) {
 	*out = *in
@@ -597,6 +1376,26 @@ func (in *SignatureOpts) DeepCopy() *SignatureOpts {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SoftPowerOffAction) DeepCopyInto(out *SoftPowerOffAction) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SoftPowerOffAction.
+func (in *SoftPowerOffAction) DeepCopy() *SoftPowerOffAction {
+	if in == nil {
+		return nil
+	}
+	out := new(SoftPowerOffAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Task) DeepCopyInto(out *Task) {
 	*out = *in
@@ -627,6 +1426,10 @@ func (in *Task) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskCondition) DeepCopyInto(out *TaskCondition) {
 	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskCondition.
@@ -675,7 +1478,34 @@ func (in *TaskList) DeepCopyObject() runtime.Object {
 func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 	*out = *in
 	in.Task.DeepCopyInto(&out.Task)
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]Action, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Connection.DeepCopyInto(&out.Connection)
+	if in.MachineRef != nil {
+		in, out := &in.MachineRef, &out.MachineRef
+		*out = new(MachineRef)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSpec.
@@ -694,7 +1524,16 @@ func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]TaskCondition, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]TaskCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.StartTime != nil {
 		in, out := &in.StartTime, &out.StartTime
@@ -704,6 +1543,76 @@ func (in *TaskStatus) DeepCopyInto(out *TaskStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.FailureTime != nil {
+		in, out := &in.FailureTime, &out.FailureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BIOSConfig != nil {
+		in, out := &in.BIOSConfig, &out.BIOSConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SELEntries != nil {
+		in, out := &in.SELEntries, &out.SELEntries
+		*out = make([]SELEntry, len(*in))
+		copy(*out, *in)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = make([]FirmwareComponent, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sensors != nil {
+		in, out := &in.Sensors, &out.Sensors
+		*out = make([]SensorReading, len(*in))
+		copy(*out, *in)
+	}
+	if in.BootOrder != nil {
+		in, out := &in.BootOrder, &out.BootOrder
+		*out = make([]BootDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedBootDevices != nil {
+		in, out := &in.AppliedBootDevices, &out.AppliedBootDevices
+		*out = make([]BootDevice, len(*in))
+		copy(*out, *in)
+	}
+	if in.RedfishResponse != nil {
+		in, out := &in.RedfishResponse, &out.RedfishResponse
+		*out = new(RedfishResponse)
+		**out = **in
+	}
+	if in.PowerLimit != nil {
+		in, out := &in.PowerLimit, &out.PowerLimit
+		*out = new(PowerLimit)
+		**out = **in
+	}
+	if in.BMCNetwork != nil {
+		in, out := &in.BMCNetwork, &out.BMCNetwork
+		*out = new(BMCNetwork)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProviderErrors != nil {
+		in, out := &in.ProviderErrors, &out.ProviderErrors
+		*out = make([]ProviderError, len(*in))
+		copy(*out, *in)
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedActionIndex != nil {
+		in, out := &in.FailedActionIndex, &out.FailedActionIndex
+		*out = new(int)
+		**out = **in
+	}
+	if in.FirstAttemptTime != nil {
+		in, out := &in.FirstAttemptTime, &out.FirstAttemptTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskStatus.
@@ -716,6 +1625,36 @@ func (in *TaskStatus) DeepCopy() *TaskStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateFirmwareAction) DeepCopyInto(out *UpdateFirmwareAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateFirmwareAction.
+func (in *UpdateFirmwareAction) DeepCopy() *UpdateFirmwareAction {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateFirmwareAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerifyConnectionAction) DeepCopyInto(out *VerifyConnectionAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerifyConnectionAction.
+func (in *VerifyConnectionAction) DeepCopy() *VerifyConnectionAction {
+	if in == nil {
+		return nil
+	}
+	out := new(VerifyConnectionAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualMediaAction) DeepCopyInto(out *VirtualMediaAction) {
 	*out = *in
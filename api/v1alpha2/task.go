@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Tinkerbell.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// TaskSpec defines the desired state of Task. It is the v1alpha1 TaskSpec, with the single action in Action
+// instead of Task.
+type TaskSpec struct {
+	// Action defines the specific action to be performed.
+	// It must be empty when Actions is set.
+	// +optional
+	Action v1alpha1.Action `json:"action"`
+
+	// Actions defines actions performed in order over a single BMC connection, for example setting the
+	// boot device and then power cycling the machine. When set, Action must be empty. The Task completes
+	// once all actions succeeded and fails on the first action that fails.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	Actions []v1alpha1.Action `json:"actions,omitempty"`
+
+	// Connection represents the Machine connectivity information.
+	// It must be empty when MachineRef is set.
+	Connection v1alpha1.Connection `json:"connection,omitempty"`
+
+	// MachineRef references the Machine whose Connection the Task uses, instead of an inline Connection.
+	// The Connection is read from the Machine each time the Task is reconciled, and Secret references without
+	// a namespace are to Secrets in the namespace of the Machine.
+	// +optional
+	MachineRef *v1alpha1.MachineRef `json:"machineRef,omitempty"`
+
+	// Timeout bounds the BMC operations of the Task, including opening the BMC connection.
+	// When unset, a Task fails if it has not completed within 10 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RetryPolicy defines how the action is retried when it fails with a transient BMC error.
+	// When unset, the action is not retried.
+	// +optional
+	RetryPolicy *v1alpha1.RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// TTLSecondsAfterFinished limits the lifetime of a Task that has finished, either Completed, Failed or Unsupported.
+	// The Task is deleted TTLSecondsAfterFinished seconds after its CompletionTime or FailureTime.
+	// When unset, the Task is never deleted automatically. When zero, the Task is deleted as soon as it finishes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// IdempotentPower makes a PowerAction of on, off or soft first read the power state of the Machine and
+	// skip the power operation when the Machine is already in the desired state.
+	// +optional
+	IdempotentPower bool `json:"idempotentPower,omitempty"`
+
+	// DeletePolicy defines what happens to the BMC operation in flight when the Task is deleted.
+	// With wait, the default, the Task is removed once the operation has finished. With abandon,
+	// the operation is cancelled and the Task is removed right away.
+	// +kubebuilder:validation:Enum=wait;abandon
+	// +optional
+	DeletePolicy v1alpha1.DeletePolicy `json:"deletePolicy,omitempty"`
+
+	// Priority orders the Tasks waiting for the controller when the limits of Tasks in flight or of concurrent
+	// operations per BMC host are reached: Tasks with a higher priority run first. Defaults to 0.
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// HistoryLimit is the number of condition transitions kept in Status.History, oldest first.
+	// When unset or zero, no history is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HistoryLimit int32 `json:"historyLimit,omitempty"`
+
+	// DryRun makes the Task only verify that its actions can run: the BMC connection is opened and checked, and
+	// the capability each action requires is checked against the opened providers. No action is run.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Suspend makes the controller skip the Task, which keeps its definition and status, and set its Suspended
+	// condition, for example to freeze it during a maintenance window. An action in flight on the BMC is not
+	// cancelled, only its status is no longer checked. Unsetting it resumes the Task where it left off.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:unservedversion
+//+kubebuilder:resource:path=tasks,scope=Namespaced,categories=tinkerbell,singular=task,shortName=t
+
+// Task is the Schema for the Task API.
+type Task struct {
+	metav1.TypeMeta   `json:""`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TaskSpec            `json:"spec,omitempty"`
+	Status v1alpha1.TaskStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TaskList contains a list of Task.
+type TaskList struct {
+	metav1.TypeMeta `json:""`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Task `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Task{}, &TaskList{})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Tinkerbell.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// ConvertTo converts the Task to the hub version, v1alpha1.
+func (src *Task) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.Task)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of Task to %T", dstRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha1.TaskSpec{
+		Task:                    src.Spec.Action,
+		Actions:                 src.Spec.Actions,
+		Connection:              src.Spec.Connection,
+		MachineRef:              src.Spec.MachineRef,
+		Timeout:                 src.Spec.Timeout,
+		RetryPolicy:             src.Spec.RetryPolicy,
+		TTLSecondsAfterFinished: src.Spec.TTLSecondsAfterFinished,
+		IdempotentPower:         src.Spec.IdempotentPower,
+		DeletePolicy:            src.Spec.DeletePolicy,
+		Priority:                src.Spec.Priority,
+		HistoryLimit:            src.Spec.HistoryLimit,
+		DryRun:                  src.Spec.DryRun,
+		Suspend:                 src.Spec.Suspend,
+	}
+	dst.Status = src.Status
+
+	return nil
+}
+
+// ConvertFrom converts the Task from the hub version, v1alpha1.
+func (dst *Task) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.Task)
+	if !ok {
+		return fmt.Errorf("unsupported conversion of Task from %T", srcRaw)
+	}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = TaskSpec{
+		Action:                  src.Spec.Task,
+		Actions:                 src.Spec.Actions,
+		Connection:              src.Spec.Connection,
+		MachineRef:              src.Spec.MachineRef,
+		Timeout:                 src.Spec.Timeout,
+		RetryPolicy:             src.Spec.RetryPolicy,
+		TTLSecondsAfterFinished: src.Spec.TTLSecondsAfterFinished,
+		IdempotentPower:         src.Spec.IdempotentPower,
+		DeletePolicy:            src.Spec.DeletePolicy,
+		Priority:                src.Spec.Priority,
+		HistoryLimit:            src.Spec.HistoryLimit,
+		DryRun:                  src.Spec.DryRun,
+		Suspend:                 src.Spec.Suspend,
+	}
+	dst.Status = src.Status
+
+	return nil
+}
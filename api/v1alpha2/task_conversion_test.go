@@ -0,0 +1,164 @@
+package v1alpha2_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+	"github.com/tinkerbell/rufio/api/v1alpha2"
+)
+
+func TestTaskConvertTo(t *testing.T) {
+	src := &v1alpha2.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task", Namespace: "default", Labels: map[string]string{"app": "rufio"}},
+		Spec: v1alpha2.TaskSpec{
+			Action:          v1alpha1.Action{PowerAction: v1alpha1.PowerHardOff.Ptr()},
+			Connection:      v1alpha1.Connection{Host: "10.0.0.1", InsecureTLS: true},
+			Timeout:         &metav1.Duration{Duration: time.Minute},
+			RetryPolicy:     &v1alpha1.RetryPolicy{MaxRetries: 3},
+			IdempotentPower: true,
+			Priority:        10,
+			DryRun:          true,
+			Suspend:         true,
+		},
+		Status: v1alpha1.TaskStatus{PowerState: "on"},
+	}
+	want := &v1alpha1.Task{
+		ObjectMeta: src.ObjectMeta,
+		Spec: v1alpha1.TaskSpec{
+			Task:            v1alpha1.Action{PowerAction: v1alpha1.PowerHardOff.Ptr()},
+			Connection:      v1alpha1.Connection{Host: "10.0.0.1", InsecureTLS: true},
+			Timeout:         &metav1.Duration{Duration: time.Minute},
+			RetryPolicy:     &v1alpha1.RetryPolicy{MaxRetries: 3},
+			IdempotentPower: true,
+			Priority:        10,
+			DryRun:          true,
+			Suspend:         true,
+		},
+		Status: v1alpha1.TaskStatus{PowerState: "on"},
+	}
+
+	got := &v1alpha1.Task{}
+	if err := src.ConvertTo(got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestTaskConvertRoundTrip(t *testing.T) {
+	hub := &v1alpha1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task", Namespace: "default"},
+		Spec: v1alpha1.TaskSpec{
+			Actions: []v1alpha1.Action{
+				{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}},
+				{PowerAction: v1alpha1.PowerCycle.Ptr()},
+			},
+			Connection:   v1alpha1.Connection{Host: "10.0.0.1", Port: 623},
+			DeletePolicy: v1alpha1.DeletePolicyAbandon,
+		},
+		Status: v1alpha1.TaskStatus{ActionIndex: 1},
+	}
+
+	spoke := &v1alpha2.Task{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if diff := cmp.Diff(hub.Spec.Actions, spoke.Spec.Actions); diff != "" {
+		t.Fatal(diff)
+	}
+	got := &v1alpha1.Task{}
+	if err := spoke.ConvertTo(got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if diff := cmp.Diff(hub, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// TestTaskConvertRoundTripAllFields converts Tasks with every field set, so that a field added to the TaskSpec of
+// either version but not copied by the conversion fails the test.
+func TestTaskConvertRoundTripAllFields(t *testing.T) {
+	t.Run("hub", func(t *testing.T) {
+		hub := &v1alpha1.Task{}
+		fill(reflect.ValueOf(hub).Elem(), new(int), 0)
+		// The single action of v1alpha1 is in Task, which is Action in v1alpha2.
+		if hub.Spec.Task.PowerAction == nil {
+			t.Fatal("expected the Task to be filled")
+		}
+
+		spoke := &v1alpha2.Task{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		got := &v1alpha1.Task{}
+		if err := spoke.ConvertTo(got); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if diff := cmp.Diff(hub, got); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("spoke", func(t *testing.T) {
+		spoke := &v1alpha2.Task{}
+		fill(reflect.ValueOf(spoke).Elem(), new(int), 0)
+
+		hub := &v1alpha1.Task{}
+		if err := spoke.ConvertTo(hub); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		got := &v1alpha2.Task{}
+		if err := got.ConvertFrom(hub); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if diff := cmp.Diff(spoke, got); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
+
+// fill sets every exported field reachable from v to a distinct non-zero value, numbered by n. Slices and maps
+// get one element. Types nested deeper than a few levels, and interfaces, are left unset.
+func fill(v reflect.Value, n *int, depth int) {
+	if depth > 10 {
+		return
+	}
+	*n++
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), n, depth+1)
+			}
+		}
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), n, depth+1)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0), n, depth+1)
+	case reflect.Map:
+		key, elem := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fill(key, n, depth+1)
+		fill(elem, n, depth+1)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	case reflect.String:
+		v.SetString(fmt.Sprintf("value-%d", *n))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(*n%100 + 1))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(*n%100 + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(*n))
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// maxBIOSConfigAttributes is the maximum number of BIOS attributes stored in the Task status.
+const maxBIOSConfigAttributes = 1000
+
+// getBIOSConfig reads the BIOS attributes of the Machine and stores them in the Task status.
+// When there are more than maxBIOSConfigAttributes attributes, the attributes are truncated
+// and a message is added to the Task Completed condition.
+func getBIOSConfig(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	cfg, err := bmcClient.GetBiosConfiguration(ctx)
+	if err != nil {
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support BIOS configuration retrieval: %w", err)
+		}
+		return err
+	}
+
+	if len(cfg) <= maxBIOSConfigAttributes {
+		task.Status.BIOSConfig = cfg
+		return nil
+	}
+
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	task.Status.BIOSConfig = make(map[string]string, maxBIOSConfigAttributes)
+	for _, k := range keys[:maxBIOSConfigAttributes] {
+		task.Status.BIOSConfig[k] = cfg[k]
+	}
+	msg := fmt.Sprintf("BIOS configuration truncated to %d of %d attributes", maxBIOSConfigAttributes, len(cfg))
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(msg))
+
+	return nil
+}
+
+// setBIOSConfig submits the BIOS attributes to the Machine. When the current BIOS configuration
+// can be read, attributes unknown to the BIOS are rejected before submitting. The BMC accepting
+// the pending configuration is considered success. When the BMC reports a power cycle is needed
+// to apply the configuration, a message is added to the Task Completed condition.
+func setBIOSConfig(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	attrs := task.CurrentAction().SetBIOSConfigAction.Attributes
+
+	current, err := bmcClient.GetBiosConfiguration(ctx)
+	switch {
+	case err == nil:
+		if unknown := unknownBIOSAttributes(attrs, current); len(unknown) > 0 {
+			return fmt.Errorf("unknown BIOS attributes: %s", strings.Join(unknown, ", "))
+		}
+	case isUnsupported(err):
+		// Without the current configuration the attributes can't be checked up front.
+		// The provider is left to reject unknown attributes.
+	default:
+		return fmt.Errorf("failed to read current BIOS configuration: %w", err)
+	}
+
+	if err := bmcClient.SetBiosConfiguration(ctx, attrs); err != nil {
+		if errors.Is(err, bmclibErrs.ErrHostPowercycleRequired) {
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage("BIOS configuration accepted, a power cycle is required to apply it"))
+			return nil
+		}
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support setting the BIOS configuration: %w", err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// unknownBIOSAttributes returns the sorted names of attrs that are not present in current.
+func unknownBIOSAttributes(attrs, current map[string]string) []string {
+	var unknown []string
+	for k := range attrs {
+		if _, ok := current[k]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown
+}
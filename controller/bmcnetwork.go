@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// managerCollectionPath is the path of the Redfish manager collection, the BMCs of the Machine.
+const managerCollectionPath = "/redfish/v1/Managers"
+
+// redfishManager is the part of a Redfish Manager resource used to find its network configuration.
+type redfishManager struct {
+	ManagerType        string       `json:"ManagerType"`
+	EthernetInterfaces *redfishLink `json:"EthernetInterfaces"`
+	NetworkProtocol    *redfishLink `json:"NetworkProtocol"`
+}
+
+// redfishNetworkProtocol is the part of a Redfish ManagerNetworkProtocol resource.
+type redfishNetworkProtocol struct {
+	HostName string `json:"HostName"`
+}
+
+// redfishIPv4Address is an IPv4 address of a Redfish EthernetInterface resource.
+type redfishIPv4Address struct {
+	Address    string `json:"Address,omitempty"`
+	SubnetMask string `json:"SubnetMask,omitempty"`
+	Gateway    string `json:"Gateway,omitempty"`
+}
+
+// redfishEthernetInterface is the part of a Redfish EthernetInterface resource with its IPv4 configuration.
+type redfishEthernetInterface struct {
+	ID         string `json:"Id"`
+	MACAddress string `json:"MACAddress"`
+	DHCPv4     *struct {
+		DHCPEnabled bool `json:"DHCPEnabled"`
+	} `json:"DHCPv4"`
+	IPv4Addresses []redfishIPv4Address `json:"IPv4Addresses"`
+	// IPv4StaticAddresses is only reported by BMCs that configure static addresses separately from the
+	// addresses in use.
+	IPv4StaticAddresses []redfishIPv4Address `json:"IPv4StaticAddresses"`
+}
+
+// bmcNetworkInterface is an Ethernet interface of the BMC read from Redfish.
+type bmcNetworkInterface struct {
+	path  string
+	iface redfishEthernetInterface
+}
+
+// getBMCNetwork reads the network configuration of the BMC and stores it in the Task status.
+func getBMCNetwork(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	network, _, err := readBMCNetwork(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	task.Status.BMCNetwork = network
+
+	return nil
+}
+
+// setBMCNetwork changes the IPv4 configuration of the Ethernet interface of the BMC in action. The configuration
+// read before is stored in the Task status. An interface the BMC does not have is returned as a terminal error.
+func setBMCNetwork(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions, action v1alpha1.SetBMCNetworkAction) error {
+	network, interfaces, err := readBMCNetwork(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	task.Status.BMCNetwork = network
+
+	var target *bmcNetworkInterface
+	for i := range interfaces {
+		if action.Interface == "" || interfaces[i].iface.ID == action.Interface {
+			target = &interfaces[i]
+			break
+		}
+	}
+	if target == nil {
+		if action.Interface == "" {
+			return fmt.Errorf("the BMC has no Ethernet interface: %w", bmclibErrs.ErrProviderImplementation)
+		}
+		return &terminalError{err: fmt.Errorf("the BMC has no Ethernet interface %q", action.Interface)}
+	}
+
+	body := map[string]any{"DHCPv4": map[string]any{"DHCPEnabled": action.DHCP}}
+	if !action.DHCP {
+		address := []redfishIPv4Address{{Address: action.Address, SubnetMask: action.SubnetMask, Gateway: action.Gateway}}
+		if target.iface.IPv4StaticAddresses != nil {
+			body["IPv4StaticAddresses"] = address
+		} else {
+			body["IPv4Addresses"] = address
+		}
+	}
+
+	return redfishSend(ctx, bmcClient, opts, http.MethodPatch, target.path, body)
+}
+
+// readBMCNetwork returns the network configuration of the BMC, and its Ethernet interfaces, from the first
+// manager of type BMC, or the first manager when none has that type. IPMI-only providers don't support it,
+// which is returned as an unsupported error.
+func readBMCNetwork(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) (*v1alpha1.BMCNetwork, []bmcNetworkInterface, error) {
+	if err := requireRedfish(bmcClient, "BMC network configurations"); err != nil {
+		return nil, nil, err
+	}
+
+	var managers redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, managerCollectionPath, &managers); err != nil {
+		return nil, nil, err
+	}
+	var manager *redfishManager
+	for _, member := range managers.Members {
+		var m redfishManager
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &m); err != nil {
+			return nil, nil, err
+		}
+		if manager == nil || (m.ManagerType == "BMC" && manager.ManagerType != "BMC") {
+			manager = &m
+		}
+		if m.ManagerType == "BMC" {
+			break
+		}
+	}
+	if manager == nil || manager.EthernetInterfaces == nil {
+		return nil, nil, fmt.Errorf("the BMC has no manager with Ethernet interfaces: %w", bmclibErrs.ErrProviderImplementation)
+	}
+
+	network := &v1alpha1.BMCNetwork{}
+	if manager.NetworkProtocol != nil {
+		var protocol redfishNetworkProtocol
+		if err := redfishGet(ctx, bmcClient, opts, manager.NetworkProtocol.ID, &protocol); err != nil {
+			return nil, nil, err
+		}
+		network.HostName = protocol.HostName
+	}
+
+	var collection redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, manager.EthernetInterfaces.ID, &collection); err != nil {
+		return nil, nil, err
+	}
+	var interfaces []bmcNetworkInterface
+	for _, member := range collection.Members {
+		var iface redfishEthernetInterface
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &iface); err != nil {
+			return nil, nil, err
+		}
+		interfaces = append(interfaces, bmcNetworkInterface{path: member.ID, iface: iface})
+
+		status := v1alpha1.BMCNetworkInterface{ID: iface.ID, MACAddress: iface.MACAddress}
+		if iface.DHCPv4 != nil {
+			status.DHCP = iface.DHCPv4.DHCPEnabled
+		}
+		if len(iface.IPv4Addresses) > 0 {
+			status.Address = iface.IPv4Addresses[0].Address
+			status.SubnetMask = iface.IPv4Addresses[0].SubnetMask
+			status.Gateway = iface.IPv4Addresses[0].Gateway
+		}
+		network.Interfaces = append(network.Interfaces, status)
+	}
+
+	return network, interfaces, nil
+}
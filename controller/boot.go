@@ -0,0 +1,384 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/bmc"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// getBootOrder reads the persistent boot order of the Machine into the Task status from the Redfish computer
+// system. When no Redfish provider is opened or the computer system has no boot order, the boot override device
+// is read instead and partial is true.
+func getBootOrder(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) (partial bool, err error) {
+	if requireRedfish(bmcClient, "boot orders") == nil {
+		devices, err := getRedfishBootOrder(ctx, bmcClient, opts)
+		if err != nil && !isUnsupported(err) {
+			return false, err
+		}
+		if err == nil {
+			task.Status.BootOrder = devices
+			return false, nil
+		}
+	}
+
+	override, err := bmcClient.GetBootDeviceOverride(ctx)
+	if err != nil {
+		if isUnsupported(err) {
+			return false, fmt.Errorf("provider does not support reading the boot order or the boot override: %w", err)
+		}
+		return false, err
+	}
+	task.Status.BootOrder = nil
+	if override.Device != "" && override.Device != bmc.BootDeviceTypeNone {
+		task.Status.BootOrder = []v1alpha1.BootDevice{v1alpha1.BootDevice(override.Device)}
+	}
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(bootOrderPartialMessage(override)))
+
+	return true, nil
+}
+
+// getRedfishBootOrder reads the boot order of the Redfish computer system of the Machine. Each boot option of the
+// boot order is returned as the BootDevice it boots, or as its display name, or its reference, when it boots
+// none of them.
+func getRedfishBootOrder(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) ([]v1alpha1.BootDevice, error) {
+	_, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(system.Boot.BootOrder) == 0 {
+		return nil, fmt.Errorf("the computer system has no boot order: %w", bmclibErrs.ErrProviderImplementation)
+	}
+	var options []redfishBootOption
+	if system.Boot.BootOptions != nil {
+		if options, err = getRedfishBootOptions(ctx, bmcClient, opts, system); err != nil {
+			return nil, err
+		}
+	}
+
+	devices := make([]v1alpha1.BootDevice, 0, len(system.Boot.BootOrder))
+	for _, ref := range system.Boot.BootOrder {
+		devices = append(devices, bootOptionDevice(options, ref))
+	}
+
+	return devices, nil
+}
+
+// bootOptionDevice returns the BootDevice that the boot option with reference ref boots. A boot option
+// that boots none of them is returned as its display name, or as ref when it has none or is not in options.
+func bootOptionDevice(options []redfishBootOption, ref string) v1alpha1.BootDevice {
+	for _, o := range options {
+		if o.BootOptionReference != ref {
+			continue
+		}
+		for device, source := range redfishBootSources {
+			if strings.EqualFold(o.Alias, source) {
+				return device
+			}
+		}
+		if o.DisplayName != "" {
+			return v1alpha1.BootDevice(o.DisplayName)
+		}
+		break
+	}
+
+	return v1alpha1.BootDevice(ref)
+}
+
+// getOneTimeBootOverride reads back the boot override of the Machine and returns its device when it only
+// applies to the next boot, or an empty string when no one time boot override is set.
+func getOneTimeBootOverride(ctx context.Context, bmcClient *bmclib.Client) (string, error) {
+	override, err := bmcClient.GetBootDeviceOverride(ctx)
+	if err != nil {
+		return "", err
+	}
+	if override.IsPersistent || override.Device == bmc.BootDeviceTypeNone {
+		return "", nil
+	}
+
+	return string(override.Device), nil
+}
+
+// getLastBootSource returns the boot source of the last boot override applied to the Redfish computer system of
+// the Machine, as its BootDevice when it has one, for example pxe, and as the Redfish boot source otherwise. It is
+// empty when no boot override was applied. IPMI-only providers don't report it, which is returned as an
+// unsupported error.
+func getLastBootSource(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) (string, error) {
+	if err := requireRedfish(bmcClient, "last boot sources"); err != nil {
+		return "", err
+	}
+	_, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return "", err
+	}
+	target := system.Boot.BootSourceOverrideTarget
+	if target == "" || strings.EqualFold(target, "None") {
+		return "", nil
+	}
+	for device, source := range redfishBootSources {
+		if strings.EqualFold(target, source) {
+			return string(device), nil
+		}
+	}
+
+	return target, nil
+}
+
+// getBootProgress reads the last boot progress state of the Redfish computer system of the Machine into the Task
+// status. IPMI-only providers, and BMCs that don't report the boot progress, don't support it, which is returned
+// as an unsupported error.
+func getBootProgress(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	if err := requireRedfish(bmcClient, "boot progress states"); err != nil {
+		return err
+	}
+	_, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	progress := system.BootProgress
+	if progress == nil || progress.LastState == "" {
+		return fmt.Errorf("the BMC does not report the boot progress of the computer system: %w", bmclibErrs.ErrProviderImplementation)
+	}
+	task.Status.BootProgress = progress.LastState
+	if strings.EqualFold(progress.LastState, "OEM") && progress.OemLastState != "" {
+		task.Status.BootProgress = progress.OemLastState
+	}
+
+	return nil
+}
+
+// lastBootSourceUnavailableMessage is the Task condition message when the last boot source couldn't be read.
+func lastBootSourceUnavailableMessage(err error) string {
+	if isUnsupported(err) {
+		return "last boot source is unavailable, it is only reported by Redfish BMCs"
+	}
+
+	return fmt.Sprintf("last boot source is unavailable: %v", err)
+}
+
+// bootOrderPartialMessage describes that only the boot override, and not the boot order, was read.
+func bootOrderPartialMessage(override bmc.BootDeviceOverride) string {
+	if override.Device == "" || override.Device == bmc.BootDeviceTypeNone {
+		return "provider does not support reading the boot order, no boot override is set"
+	}
+
+	return fmt.Sprintf("provider does not support reading the boot order, only the boot override device was read (persistent: %t, efiBoot: %t)", override.IsPersistent, override.IsEFIBoot)
+}
+
+// redfishBootSources maps the BootDevice values to the Redfish boot source of the boot options that boot them.
+var redfishBootSources = map[v1alpha1.BootDevice]string{
+	v1alpha1.PXE:      "Pxe",
+	v1alpha1.Disk:     "Hdd",
+	v1alpha1.CDROM:    "Cd",
+	v1alpha1.BIOS:     "BiosSetup",
+	v1alpha1.HTTPBoot: redfishHTTPBootSource,
+}
+
+// redfishHTTPBootSource is the Redfish boot source of UEFI HTTP boot.
+const redfishHTTPBootSource = "UefiHttp"
+
+// setBootDevices sets the boot devices of the Machine, each with its EFI boot flag, and returns the devices that
+// were applied. When a Redfish provider is opened, a persistent ordered list of devices is set as the boot order of
+// the Redfish computer system, leaving out the devices the BMC has no boot option for. Otherwise, and for one time
+// boot, which takes a single device, only the first device is set. A first device of httpboot is set as a UEFI HTTP
+// boot override from bootURL, which also takes a single device. note describes when devices were left out, and is
+// empty otherwise.
+func setBootDevices(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, entries []v1alpha1.BootDeviceEntry, setPersistent bool, bootURL string) (applied []v1alpha1.BootDevice, note string, err error) {
+	if len(entries) == 0 {
+		return nil, "", fmt.Errorf("no boot devices specified")
+	}
+
+	if setPersistent && len(entries) > 1 && bootURL == "" {
+		if err := requireRedfish(bmcClient, "ordered boot devices"); err == nil {
+			applied, reason, err := setRedfishBootOrder(ctx, bmcClient, opts, entries)
+			if err != nil || reason == "" {
+				return applied, "", err
+			}
+			return applied, partialBootDevicesMessage(reason, entries, applied), nil
+		}
+	}
+
+	first := entries[0]
+	if first.Device == v1alpha1.HTTPBoot {
+		if err := setHTTPBoot(ctx, bmcClient, opts, bootURL, setPersistent); err != nil {
+			return nil, "", err
+		}
+	} else if _, err := bmcClient.SetBootDevice(ctx, string(first.Device.Canonical()), setPersistent, first.EFI != nil && *first.EFI); err != nil {
+		return nil, "", err
+	}
+	applied = []v1alpha1.BootDevice{first.Device}
+	if len(entries) == 1 {
+		return applied, "", nil
+	}
+	if !setPersistent {
+		return applied, partialBootDevicesMessage("a one time boot override takes a single device", entries, applied), nil
+	}
+	if bootURL != "" {
+		return applied, partialBootDevicesMessage("an HTTP boot override with a boot URL takes a single device", entries, applied), nil
+	}
+
+	return applied, partialBootDevicesMessage("provider does not support ordered boot devices", entries, applied), nil
+}
+
+// setHTTPBoot sets the boot override of the Redfish computer system of the Machine to UEFI HTTP boot, for the next
+// boot only unless persistent. The Machine boots from bootURL, or from the URL offered by its DHCP server when
+// bootURL is empty. IPMI-only providers, and BMCs that don't accept the UefiHttp boot source, don't support it,
+// which is returned as an unsupported error.
+func setHTTPBoot(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, bootURL string, persistent bool) error {
+	if err := requireRedfish(bmcClient, "HTTP boot overrides"); err != nil {
+		return err
+	}
+	path, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	if targets := system.Boot.BootSourceOverrideTargets; len(targets) > 0 && !slices.Contains(targets, redfishHTTPBootSource) {
+		return fmt.Errorf("%s is not one of the boot override targets [%s] of the computer system: %w", redfishHTTPBootSource, strings.Join(targets, ", "), bmclibErrs.ErrProviderImplementation)
+	}
+
+	enabled := "Once"
+	if persistent {
+		enabled = "Continuous"
+	}
+	boot := map[string]any{"BootSourceOverrideTarget": redfishHTTPBootSource, "BootSourceOverrideEnabled": enabled}
+	// UEFI HTTP boot is only available in UEFI mode.
+	if modes := system.Boot.BootSourceOverrideModes; len(modes) == 0 || slices.Contains(modes, "UEFI") {
+		boot["BootSourceOverrideMode"] = "UEFI"
+	}
+	if bootURL != "" {
+		boot["HttpBootUri"] = bootURL
+	}
+
+	return redfishSend(ctx, bmcClient, opts, http.MethodPatch, path, map[string]any{"Boot": boot})
+}
+
+// isClearBootOverride reports whether action clears the boot override with the none boot device, instead of
+// setting a boot device.
+func isClearBootOverride(action v1alpha1.OneTimeBootDeviceAction) bool {
+	entries := action.BootEntries()
+
+	return len(entries) == 1 && entries[0].Device == v1alpha1.None
+}
+
+// clearBootOverride clears the boot override of the Machine, so that it boots from its boot order again. On Redfish
+// BMCs the boot override of the computer system is disabled, unless it already is. Other providers set the none
+// boot device, which clears the override whether one was set or not.
+func clearBootOverride(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	if requireRedfish(bmcClient, "boot overrides") != nil {
+		_, err := bmcClient.SetBootDevice(ctx, string(v1alpha1.None), false, false)
+		return err
+	}
+	path, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(system.Boot.BootSourceOverrideEnabled, "Disabled") {
+		return nil
+	}
+
+	patch := map[string]any{"Boot": map[string]any{"BootSourceOverrideEnabled": "Disabled"}}
+	return redfishSend(ctx, bmcClient, opts, http.MethodPatch, path, patch)
+}
+
+// setRedfishBootOrder sets the boot order of the Redfish computer system of the Machine to the boot options of
+// entries, in order, followed by the other boot options of the current boot order, and returns the devices that
+// were applied. The boot option of an entry is its UEFI boot option when its EFI boot flag is set, its legacy boot
+// option otherwise. Entries the BMC has no boot option for are left out, which reason describes, and an error is
+// returned when it has none for any entry. The boot override is disabled, so that the boot order applies.
+func setRedfishBootOrder(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, entries []v1alpha1.BootDeviceEntry) (applied []v1alpha1.BootDevice, reason string, err error) {
+	path, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	options, err := getRedfishBootOptions(ctx, bmcClient, opts, system)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var order, missing []string
+	for _, e := range entries {
+		efiBoot := e.EFI != nil && *e.EFI
+		ref, ok := bootOptionReference(options, e.Device, efiBoot)
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s (efiBoot: %t)", e.Device, efiBoot))
+			continue
+		}
+		applied = append(applied, e.Device)
+		if !slices.Contains(order, ref) {
+			order = append(order, ref)
+		}
+	}
+	if len(applied) == 0 {
+		return nil, "", fmt.Errorf("no boot option of the BMC boots devices %s", strings.Join(missing, ", "))
+	}
+	for _, ref := range system.Boot.BootOrder {
+		if !slices.Contains(order, ref) {
+			order = append(order, ref)
+		}
+	}
+
+	patch := map[string]any{"Boot": map[string]any{"BootOrder": order, "BootSourceOverrideEnabled": "Disabled"}}
+	if err := redfishSend(ctx, bmcClient, opts, http.MethodPatch, path, patch); err != nil {
+		return nil, "", err
+	}
+	if len(missing) > 0 {
+		reason = fmt.Sprintf("no boot option of the BMC boots devices %s", strings.Join(missing, ", "))
+	}
+
+	return applied, reason, nil
+}
+
+// getRedfishBootOptions reads the boot options of system.
+func getRedfishBootOptions(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, system *redfishSystem) ([]redfishBootOption, error) {
+	if system.Boot.BootOptions == nil {
+		return nil, fmt.Errorf("the computer system has no boot options: %w", bmclibErrs.ErrProviderImplementation)
+	}
+
+	var collection redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, system.Boot.BootOptions.ID, &collection); err != nil {
+		return nil, err
+	}
+	options := make([]redfishBootOption, 0, len(collection.Members))
+	for _, member := range collection.Members {
+		var o redfishBootOption
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &o); err != nil {
+			return nil, err
+		}
+		options = append(options, o)
+	}
+
+	return options, nil
+}
+
+// bootOptionReference returns the reference of the first of options that boots device, with UEFI boot when
+// efiBoot is true and legacy boot otherwise. HTTP boot options always boot with UEFI.
+func bootOptionReference(options []redfishBootOption, device v1alpha1.BootDevice, efiBoot bool) (string, bool) {
+	source, ok := redfishBootSources[device.Canonical()]
+	if !ok {
+		return "", false
+	}
+	for _, o := range options {
+		if strings.EqualFold(o.Alias, source) && ((o.UefiDevicePath != "") == efiBoot || device == v1alpha1.HTTPBoot) {
+			return o.BootOptionReference, true
+		}
+	}
+
+	return "", false
+}
+
+// partialBootDevicesMessage describes the boot devices of entries that were applied when only part of them were,
+// for reason.
+func partialBootDevicesMessage(reason string, entries []v1alpha1.BootDeviceEntry, applied []v1alpha1.BootDevice) string {
+	devices := make([]string, 0, len(applied))
+	for _, d := range applied {
+		devices = append(devices, string(d))
+	}
+
+	return fmt.Sprintf("partial: %s, only %d of %d devices (%s) were applied", reason, len(applied), len(entries), strings.Join(devices, ", "))
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HostBreaker is a circuit breaker of connections to BMC hosts. After threshold consecutive connection
+// failures to a host, the circuit of the host opens and Tasks against it fail fast for the cooldown,
+// instead of occupying a worker with a connection attempt that is likely to fail. Once the cooldown passed,
+// connections are attempted again: the first success closes the circuit, a failure opens it for another cooldown.
+// A nil HostBreaker never opens.
+type HostBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostCircuit
+}
+
+// hostCircuit is the circuit of a single BMC host.
+type hostCircuit struct {
+	// failures is the number of consecutive connection failures.
+	failures int
+	// openedAt is when the circuit last opened, zero while it is closed.
+	openedAt time.Time
+}
+
+// NewHostBreaker returns a HostBreaker that opens the circuit of a host after threshold consecutive connection
+// failures, for cooldown.
+func NewHostBreaker(threshold int, cooldown time.Duration) *HostBreaker {
+	return &HostBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     map[string]*hostCircuit{},
+	}
+}
+
+// Allow returns an error when the circuit of host is open and its cooldown has not passed yet.
+func (b *HostBreaker) Allow(host string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok || c.openedAt.IsZero() {
+		return nil
+	}
+	if remaining := b.cooldown - time.Since(c.openedAt); remaining > 0 {
+		circuitRejections.WithLabelValues(host).Inc()
+		return fmt.Errorf("circuit open for host %s after %d consecutive connection failures, retrying connections in %s", host, c.failures, remaining.Round(time.Second))
+	}
+
+	return nil
+}
+
+// Success closes the circuit of host.
+func (b *HostBreaker) Success(host string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.hosts[host]; ok {
+		delete(b.hosts, host)
+		circuitOpen.DeleteLabelValues(host)
+	}
+}
+
+// Failure records a connection failure to host, opening its circuit once there were threshold consecutive failures.
+func (b *HostBreaker) Failure(host string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+	c.failures++
+	if c.failures >= b.threshold {
+		c.openedAt = time.Now()
+		circuitOpen.WithLabelValues(host).Set(1)
+	}
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/go-logr/logr"
+)
+
+// closeTimeout bounds closing a cached BMC connection.
+const closeTimeout = 10 * time.Second
+
+// ClientCache reuses open BMC connections across reconciles, so consecutive actions against the same BMC
+// don't each log in again. Connections are keyed by host, credentials and options.
+// A connection is used by a single reconcile at a time: Get checks it out of the cache and Put returns it.
+type ClientCache struct {
+	open ClientFunc
+	size int
+	ttl  time.Duration
+	log  logr.Logger
+
+	mu      sync.Mutex
+	idle    map[string]*cachedClient
+	clients map[*bmclib.Client]string
+}
+
+// cachedClient is an open BMC connection that is not in use.
+type cachedClient struct {
+	client   *bmclib.Client
+	lastUsed time.Time
+}
+
+// NewClientCache returns a ClientCache that opens connections with open. At most size idle connections
+// are kept, each for at most ttl after it was last used.
+func NewClientCache(open ClientFunc, size int, ttl time.Duration, log logr.Logger) *ClientCache {
+	return &ClientCache{
+		open:    open,
+		size:    size,
+		ttl:     ttl,
+		log:     log,
+		idle:    map[string]*cachedClient{},
+		clients: map[*bmclib.Client]string{},
+	}
+}
+
+// Get returns an open connection to the BMC at host. An idle cached connection is reused when available,
+// otherwise a new connection is opened. The connection must be returned with Put.
+func (c *ClientCache) Get(ctx context.Context, log logr.Logger, host, username, password string, opts *BMCOptions) (*bmclib.Client, error) {
+	key := cacheKey(host, username, password, opts)
+
+	c.mu.Lock()
+	if cc, ok := c.idle[key]; ok {
+		delete(c.idle, key)
+		if time.Since(cc.lastUsed) < c.ttl {
+			c.mu.Unlock()
+			log.Info("reusing cached BMC connection", "host", host)
+
+			return cc.client, nil
+		}
+		// Expired, but not closed by Start yet.
+		delete(c.clients, cc.client)
+		c.mu.Unlock()
+		c.close(ctx, []*bmclib.Client{cc.client})
+	} else {
+		c.mu.Unlock()
+	}
+
+	client, err := c.open(ctx, log, host, username, password, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.clients[client] = key
+	c.mu.Unlock()
+
+	return client, nil
+}
+
+// Put returns a connection obtained with Get to the cache. err is the error, if any, of using the connection.
+// Connections that failed to authenticate or whose session expired are closed instead of cached.
+// When the cache is full, the least recently used idle connection is closed.
+func (c *ClientCache) Put(ctx context.Context, client *bmclib.Client, err error) {
+	if client == nil {
+		return
+	}
+
+	c.mu.Lock()
+	key, ok := c.clients[client]
+	var toClose []*bmclib.Client
+	switch {
+	case !ok:
+		// Not opened through the cache, or evicted while in use.
+		toClose = append(toClose, client)
+	case isSessionError(err):
+		delete(c.clients, client)
+		toClose = append(toClose, client)
+	case c.idle[key] != nil:
+		// A concurrent reconcile already returned a connection for the same key.
+		delete(c.clients, client)
+		toClose = append(toClose, client)
+	default:
+		c.idle[key] = &cachedClient{client: client, lastUsed: time.Now()}
+		for len(c.idle) > c.size {
+			toClose = append(toClose, c.evictOldest())
+		}
+	}
+	c.mu.Unlock()
+
+	c.close(ctx, toClose)
+}
+
+// Evict closes the idle connections to the BMC at host. Connections to host in use by a reconcile are closed
+// when they are returned with Put, instead of being cached.
+func (c *ClientCache) Evict(ctx context.Context, host string) {
+	// The connections are opened with the host as passed to bmclib, with IPv6 literals enclosed in brackets.
+	host = bmclibHost(host)
+	c.mu.Lock()
+	var toClose []*bmclib.Client
+	for key, cc := range c.idle {
+		if cc.client.Auth.Host == host {
+			delete(c.idle, key)
+			delete(c.clients, cc.client)
+			toClose = append(toClose, cc.client)
+		}
+	}
+	for client := range c.clients {
+		if client.Auth.Host == host {
+			delete(c.clients, client)
+		}
+	}
+	c.mu.Unlock()
+
+	c.close(ctx, toClose)
+}
+
+// Start closes idle connections once their TTL expires, until ctx is done. Then all idle connections are closed.
+// It implements manager.Runnable.
+func (c *ClientCache) Start(ctx context.Context) error {
+	interval := max(c.ttl/2, time.Second)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			var toClose []*bmclib.Client
+			for len(c.idle) > 0 {
+				toClose = append(toClose, c.evictOldest())
+			}
+			c.mu.Unlock()
+			// ctx is done, so closing uses a fresh context.
+			c.close(context.Background(), toClose)
+
+			return nil
+		case <-ticker.C:
+			c.mu.Lock()
+			var toClose []*bmclib.Client
+			for key, cc := range c.idle {
+				if time.Since(cc.lastUsed) >= c.ttl {
+					delete(c.idle, key)
+					delete(c.clients, cc.client)
+					toClose = append(toClose, cc.client)
+				}
+			}
+			c.mu.Unlock()
+			c.close(ctx, toClose)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Idle connections are closed on every replica.
+func (c *ClientCache) NeedLeaderElection() bool {
+	return false
+}
+
+// evictOldest removes the least recently used idle connection from the cache and returns it.
+// c.mu must be held and c.idle must not be empty.
+func (c *ClientCache) evictOldest() *bmclib.Client {
+	var oldestKey string
+	var oldest *cachedClient
+	for key, cc := range c.idle {
+		if oldest == nil || cc.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, cc
+		}
+	}
+	delete(c.idle, oldestKey)
+	delete(c.clients, oldest.client)
+
+	return oldest.client
+}
+
+// close closes clients, logging any errors.
+func (c *ClientCache) close(ctx context.Context, clients []*bmclib.Client) {
+	for _, client := range clients {
+		ctx, cancel := context.WithTimeout(ctx, closeTimeout)
+		if err := client.Close(ctx); err != nil {
+			c.log.Error(err, "BMC close connection failed", "host", client.Auth.Host)
+		}
+		cancel()
+	}
+}
+
+// isSessionError reports whether err indicates that the BMC session of a connection can't be used anymore.
+func isSessionError(err error) bool {
+	return isAuthError(err) || errors.Is(err, bmclibErrs.ErrSessionExpired)
+}
+
+// cacheKey returns the key of the connection to host with the given credentials and options.
+// The key is a hash, so credentials are not kept in memory in plain text.
+func cacheKey(host, username, password string, opts *BMCOptions) string {
+	h := sha256.New()
+	for _, s := range []string{host, username, password} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	if opts != nil {
+		// Errors are ignored, the options only contain types that can be marshaled.
+		b, _ := json.Marshal(struct {
+			ProviderOptions any
+			RPCSecrets      any
+			InsecureTLS     bool
+			Port            int
+			IPMICipherSuite *int
+			ClientCert      [][]byte
+			CACerts         [][]byte
+			ProxyURL        string
+		}{opts.ProviderOptions, opts.rpcSecrets, opts.insecureTLS, opts.port, opts.ipmiCipherSuite, clientCertDER(opts.clientCert), caCertsDER(opts.caCerts), proxyURLString(opts.proxyURL)})
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// openClient returns an open connection to the BMC at host. It is taken from cache when cache is set,
+// otherwise it is opened with open.
+func openClient(ctx context.Context, log logr.Logger, cache *ClientCache, open ClientFunc, host, username, password string, opts *BMCOptions) (*bmclib.Client, error) {
+	if cache != nil {
+		return cache.Get(ctx, log, host, username, password, opts)
+	}
+
+	return open(ctx, log, host, username, password, opts)
+}
+
+// releaseClient returns bmcClient to cache when cache is set, otherwise the connection is closed.
+// err is the error, if any, of using the connection.
+func releaseClient(ctx context.Context, log logr.Logger, cache *ClientCache, bmcClient *bmclib.Client, err error) {
+	if cache != nil {
+		cache.Put(ctx, bmcClient, err)
+		return
+	}
+
+	if err := bmcClient.Close(ctx); err != nil {
+		md := bmcClient.GetMetadata()
+		log.Error(err, "BMC close connection failed", "providersAttempted", md.ProvidersAttempted)
+
+		return
+	}
+	md := bmcClient.GetMetadata()
+	log.Info("BMC connection closed", "successfulCloseConns", md.SuccessfulCloseConns, "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+}
+
+// proxyURLString returns the proxy URL u as a string, empty when u is nil.
+func proxyURLString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+// clientCertDER returns the DER encoded certificate chain of cert, nil when cert is nil.
+func clientCertDER(cert *tls.Certificate) [][]byte {
+	if cert == nil {
+		return nil
+	}
+
+	return cert.Certificate
+}
+
+// caCertsDER returns the DER encoded certificates of certs, nil when certs is empty.
+func caCertsDER(certs []*x509.Certificate) [][]byte {
+	var der [][]byte
+	for _, cert := range certs {
+		der = append(der, cert.Raw)
+	}
+
+	return der
+}
@@ -0,0 +1,148 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/go-logr/logr"
+	"github.com/tinkerbell/rufio/controller"
+)
+
+// countingClient returns a ClientFunc that counts the connections opened through it.
+func countingClient(provider *testProvider, opened *int) controller.ClientFunc {
+	open := newTestClient(provider)
+	return func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+		*opened++
+		return open(ctx, log, hostIP, username, password, opts)
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	tests := map[string]struct {
+		size       int
+		ttl        time.Duration
+		firstHost  string
+		secondHost string
+		putErr     error
+		wantOpened int
+	}{
+		"reused for the same host": {
+			size: 1, ttl: time.Minute, firstHost: "127.0.0.1", secondHost: "127.0.0.1", wantOpened: 1,
+		},
+		"not reused for another host": {
+			size: 1, ttl: time.Minute, firstHost: "127.0.0.1", secondHost: "127.0.0.2", wantOpened: 2,
+		},
+		"evicted on authentication error": {
+			size: 1, ttl: time.Minute, firstHost: "127.0.0.1", secondHost: "127.0.0.1",
+			putErr: fmt.Errorf("failed to perform PowerAction: %w", bmclibErrs.ErrLoginFailed), wantOpened: 2,
+		},
+		"evicted on expired session": {
+			size: 1, ttl: time.Minute, firstHost: "127.0.0.1", secondHost: "127.0.0.1",
+			putErr: bmclibErrs.ErrSessionExpired, wantOpened: 2,
+		},
+		"reused after non session error": {
+			size: 1, ttl: time.Minute, firstHost: "127.0.0.1", secondHost: "127.0.0.1",
+			putErr: bmclibErrs.ErrPowerStatusRead, wantOpened: 1,
+		},
+		"not reused after ttl": {
+			size: 1, ttl: time.Nanosecond, firstHost: "127.0.0.1", secondHost: "127.0.0.1", wantOpened: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			opened := 0
+			cache := controller.NewClientCache(countingClient(&testProvider{}, &opened), tt.size, tt.ttl, logr.Discard())
+
+			first, err := cache.Get(ctx, logr.Discard(), tt.firstHost, "user", "pass", &controller.BMCOptions{})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			cache.Put(ctx, first, tt.putErr)
+			time.Sleep(time.Millisecond)
+
+			second, err := cache.Get(ctx, logr.Discard(), tt.secondHost, "user", "pass", &controller.BMCOptions{})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			cache.Put(ctx, second, nil)
+
+			if opened != tt.wantOpened {
+				t.Fatalf("expected %d connections opened, got: %d", tt.wantOpened, opened)
+			}
+			if reused := first == second; reused != (tt.wantOpened == 1) {
+				t.Fatalf("expected connection reused to be %v", tt.wantOpened == 1)
+			}
+		})
+	}
+}
+
+func TestClientCacheEvict(t *testing.T) {
+	tests := map[string]struct {
+		// inUse evicts the connection while it is checked out of the cache.
+		inUse bool
+		// host is the host of the connection, 127.0.0.1 when empty.
+		host       string
+		evictHost  string
+		wantOpened int
+	}{
+		"idle connection evicted":      {evictHost: "127.0.0.1", wantOpened: 2},
+		"connection in use not cached": {inUse: true, evictHost: "127.0.0.1", wantOpened: 2},
+		"other host kept":              {evictHost: "127.0.0.2", wantOpened: 1},
+		"ipv6 connection evicted":      {host: "fd00::1", evictHost: "fd00::1", wantOpened: 2},
+		"bracketed ipv6 evicted":       {host: "fd00::1", evictHost: "[fd00::1]", wantOpened: 2},
+		"other ipv6 host kept":         {host: "fd00::1", evictHost: "fd00::2", wantOpened: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			opened := 0
+			provider := &testProvider{}
+			open := countingClient(provider, &opened)
+			// Like NewClientFunc, open the connection with an IPv6 literal enclosed in brackets.
+			bracketed := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				if addr, err := netip.ParseAddr(hostIP); err == nil && addr.Is6() {
+					hostIP = "[" + hostIP + "]"
+				}
+				return open(ctx, log, hostIP, username, password, opts)
+			}
+			cache := controller.NewClientCache(bracketed, 1, time.Minute, logr.Discard())
+			host := tt.host
+			if host == "" {
+				host = "127.0.0.1"
+			}
+
+			first, err := cache.Get(ctx, logr.Discard(), host, "user", "pass", &controller.BMCOptions{})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if tt.inUse {
+				cache.Evict(ctx, tt.evictHost)
+				cache.Put(ctx, first, nil)
+			} else {
+				cache.Put(ctx, first, nil)
+				cache.Evict(ctx, tt.evictHost)
+			}
+
+			second, err := cache.Get(ctx, logr.Discard(), host, "user", "pass", &controller.BMCOptions{})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			cache.Put(ctx, second, nil)
+
+			if opened != tt.wantOpened {
+				t.Fatalf("expected %d connections opened, got: %d", tt.wantOpened, opened)
+			}
+			if closes := int(provider.Closes.Load()); closes != tt.wantOpened-1 {
+				t.Fatalf("expected %d connections closed, got: %d", tt.wantOpened-1, closes)
+			}
+		})
+	}
+}
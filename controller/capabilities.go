@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// capabilitiesFresh reports whether the capabilities of bm were detected less than interval ago.
+// A zero interval disables detecting capabilities, so they are never fresh.
+func capabilitiesFresh(bm *v1alpha1.Machine, interval time.Duration) bool {
+	if interval <= 0 {
+		return false
+	}
+
+	return bm.Status.LastCapabilitiesTime != nil && time.Since(bm.Status.LastCapabilitiesTime.Time) < interval
+}
+
+// updateCapabilities stores the providers opened by bmcClient and their capabilities in the Machine status.
+func updateCapabilities(ctx context.Context, logger logr.Logger, bm *v1alpha1.Machine, bmcClient *bmclib.Client, opts *BMCOptions) {
+	now := metav1.Now()
+	bm.Status.LastCapabilitiesTime = &now
+	providers := make([]string, 0, len(bmcClient.Registry.Drivers))
+	for _, d := range bmcClient.Registry.Drivers {
+		providers = append(providers, d.Name)
+	}
+	bm.Status.Providers = providers
+	bm.Status.Capabilities = detectCapabilities(bmcClient.Registry.Drivers)
+	if uefiOnly, err := detectUEFIOnly(ctx, bmcClient, opts); err != nil {
+		logger.V(1).Info("failed to detect the boot override modes of the Machine", "error", err.Error())
+	} else if uefiOnly {
+		bm.Status.Capabilities = append(bm.Status.Capabilities, v1alpha1.UEFIOnlyCapability)
+	}
+	logger.Info("Machine capabilities detected", "capabilities", bm.Status.Capabilities)
+}
+
+// detectUEFIOnly reports whether the Redfish computer system of the Machine only allows UEFI boot overrides.
+// BMCs that don't report the allowed boot override modes, and IPMI-only providers, are not UEFI only.
+func detectUEFIOnly(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) (bool, error) {
+	if requireRedfish(bmcClient, "boot override modes") != nil {
+		return false, nil
+	}
+	_, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return false, err
+	}
+	modes := system.Boot.BootSourceOverrideModes
+
+	return len(modes) > 0 && !slices.ContainsFunc(modes, func(m string) bool { return !strings.EqualFold(m, "UEFI") }), nil
+}
+
+// actionCapability returns the capability, as detected by detectCapabilities, that action requires.
+// It is empty for actions that are not checked against the capabilities of a Machine.
+func actionCapability(a v1alpha1.Action) string {
+	switch {
+	case a.PowerAction != nil && *a.PowerAction != v1alpha1.PowerStatus && *a.PowerAction != v1alpha1.PowerNMI,
+		a.PowerCycleAction != nil, a.SoftPowerOffAction != nil:
+		return "power"
+	case a.OneTimeBootDeviceAction != nil, a.PersistentBootDeviceAction != nil, a.BootAndPowerAction != nil:
+		return "bootdevice"
+	case a.VirtualMediaAction != nil:
+		return "virtualmedia"
+	case a.GetBIOSConfigAction != nil, a.SetBIOSConfigAction != nil:
+		return "biosconfig"
+	case a.GetSELAction != nil, a.ClearSELAction != nil:
+		return "sel"
+	case a.GetFirmwareInventoryAction != nil:
+		return "inventory"
+	case a.ResetBMCAction != nil:
+		return "bmcreset"
+	default:
+		return ""
+	}
+}
+
+// checkMachineCapabilities returns a terminal error when task references, or is owned by a Job that references,
+// a Machine with fresh capabilities that lack the capability of the current action of task, so that the Task fails
+// without connecting to the BMC. Other Tasks, and Machines that can't be read, are not checked.
+func (r *TaskReconciler) checkMachineCapabilities(ctx context.Context, logger logr.Logger, task *v1alpha1.Task) error {
+	required := actionCapability(task.CurrentAction())
+	if required == "" || r.capabilitiesInterval <= 0 {
+		return nil
+	}
+	ref := task.Spec.MachineRef
+	if ref == nil {
+		owner := metav1.GetControllerOf(task)
+		if owner == nil || owner.Kind != "Job" {
+			return nil
+		}
+		job := &v1alpha1.Job{}
+		if err := r.client.Get(ctx, types.NamespacedName{Namespace: task.Namespace, Name: owner.Name}, job); err != nil {
+			logger.V(1).Info("not checking Machine capabilities, failed to get the Job of the Task", "error", err.Error())
+			return nil
+		}
+		ref = &job.Spec.MachineRef
+	}
+
+	machine := &v1alpha1.Machine{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, machine); err != nil {
+		logger.V(1).Info("not checking Machine capabilities, failed to get the Machine of the Task", "error", err.Error())
+		return nil
+	}
+	if !capabilitiesFresh(machine, r.capabilitiesInterval) || slices.Contains(machine.Status.Capabilities, required) {
+		return nil
+	}
+
+	return &terminalError{err: fmt.Errorf("%s requires the %s capability, which the providers [%s] of Machine %s/%s don't support", actionType(task.CurrentAction()), required, strings.Join(machine.Status.Providers, ", "), machine.Namespace, machine.Name)}
+}
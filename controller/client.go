@@ -2,24 +2,39 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/netip"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"dario.cat/mergo"
 	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
 	"github.com/bmc-toolbox/bmclib/v2/providers/rpc"
 	"github.com/ccoveille/go-safecast"
 	"github.com/go-logr/logr"
+	"github.com/jacobweinstock/registrar"
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 )
 
 // ClientFunc defines a func that returns a bmclib.Client.
 type ClientFunc func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *BMCOptions) (*bmclib.Client, error)
 
+// errProvidersDisabled is the error of opening a connection that only providers disabled by the controller could
+// open. It is unsupported, so the Task fails without being retried.
+var errProvidersDisabled = fmt.Errorf("providers disabled by the controller: %w", bmclibErrs.ErrProviderImplementation)
+
 // NewClientFunc returns a new BMCClientFactoryFunc. The timeout parameter determines the
-// maximum time to probe for compatible interfaces.
-func NewClientFunc(timeout time.Duration) ClientFunc {
+// maximum time to probe for compatible interfaces. When enabled is not empty, only the providers it names,
+// by name or protocol, are used, whatever the ProviderOptions of the Connection prefer.
+func NewClientFunc(timeout time.Duration, enabled []string) ClientFunc {
 	// Initializes a bmclib client based on input host and credentials
 	// Establishes a connection with the bmc with client.Open
 	// Returns a bmclib.Client.
@@ -28,9 +43,9 @@ func NewClientFunc(timeout time.Duration) ClientFunc {
 		if opts != nil {
 			o = append(o, opts.Translate(hostIP)...)
 		}
-		log = log.WithValues("host", hostIP, "username", username)
+		log = log.WithValues("host", hostIP)
 		o = append(o, bmclib.WithLogger(log))
-		client := bmclib.NewClient(hostIP, username, password, o...)
+		client := bmclib.NewClient(bmclibHost(hostIP), username, password, o...)
 
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
@@ -38,11 +53,45 @@ func NewClientFunc(timeout time.Duration) ClientFunc {
 		if opts != nil && opts.ProviderOptions != nil && len(opts.PreferredOrder) > 0 {
 			client.Registry.Drivers = client.Registry.PreferProtocol(toStringSlice(opts.PreferredOrder)...)
 		}
+		// bmclib only checks the Redfish versions that are not compatible when filtering for compatible drivers.
+		if opts != nil && opts.ProviderOptions != nil && opts.Redfish != nil && len(opts.Redfish.VersionsNotCompatible) > 0 {
+			client.FilterForCompatible(ctx)
+		}
+		all := client.Registry.Drivers
+		if len(enabled) > 0 {
+			client.Registry.Drivers = enabledProviders(client.Registry.Drivers, enabled)
+		}
+		if opts != nil && opts.ProviderOptions != nil && opts.DisablePortProbing {
+			configured := opts.configuredProtocols()
+			client.Registry.Drivers = enabledProviders(client.Registry.Drivers, configured)
+			if len(client.Registry.Drivers) == 0 {
+				return nil, fmt.Errorf("failed to open connection to BMC: port probing is disabled and none of the providers of the protocols with a configured port %v are available", configured)
+			}
+		}
+		var preferred []string
+		if opts != nil && opts.ProviderOptions != nil && len(opts.PreferredProviders) > 0 {
+			preferred = opts.PreferredProviders
+			client.Registry.Drivers = filterProviders(client.Registry.Drivers, preferred)
+			if len(client.Registry.Drivers) == 0 {
+				if len(filterProviders(all, preferred)) > 0 {
+					return nil, fmt.Errorf("failed to open connection to BMC: none of the preferred providers %v are enabled, the controller only enables %v: %w", preferred, enabled, errProvidersDisabled)
+				}
+				return nil, fmt.Errorf("failed to open connection to BMC: none of the preferred providers %v are available", preferred)
+			}
+		}
+		if len(client.Registry.Drivers) == 0 && len(all) > 0 {
+			return nil, fmt.Errorf("failed to open connection to BMC: none of the providers enabled by the controller %v are available: %w", enabled, errProvidersDisabled)
+		}
 		if err := client.Open(ctx); err != nil {
 			md := client.GetMetadata()
 			log.Info("Failed to open connection to BMC", "error", err, "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulOpenConns)
+			if len(preferred) > 0 {
+				err = fmt.Errorf("failed to open connection to BMC with preferred providers: %s: %w", providerErrors(md.ProvidersAttempted, md.FailedProviderDetail), err)
+			} else {
+				err = fmt.Errorf("failed to open connection to BMC: %w", err)
+			}
 
-			return nil, fmt.Errorf("failed to open connection to BMC: %w", err)
+			return nil, withProviderErrors(err, md.ProvidersAttempted, md.FailedProviderDetail)
 		}
 		md := client.GetMetadata()
 		log.Info("Connected to BMC", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulOpenConns)
@@ -51,14 +100,71 @@ func NewClientFunc(timeout time.Duration) ClientFunc {
 	}
 }
 
+// defaultHTTPTimeout is the timeout of each HTTP request to a BMC when HTTPClientConfig doesn't set one, the same as
+// the bmclib HTTP client.
+const defaultHTTPTimeout = 120 * time.Second
+
+// HTTPClientConfig configures the HTTP requests to BMCs for all Connections. The zero value keeps the defaults.
+type HTTPClientConfig struct {
+	// UserAgent, when set, is the User-Agent header of the Redfish requests the controller sends itself, for example
+	// those of a RedfishActionPassthroughAction. The bmclib providers send their own User-Agent.
+	UserAgent string
+	// Timeout, when set, bounds each HTTP request of the HTTP based providers and of the Redfish requests the
+	// controller sends itself, instead of defaultHTTPTimeout.
+	Timeout time.Duration
+}
+
 type BMCOptions struct {
 	*v1alpha1.ProviderOptions
-	rpcSecrets map[rpc.Algorithm][]string
+	rpcSecrets  map[rpc.Algorithm][]string
+	insecureTLS bool
+	port        int
+	// ipmiCipherSuite is the IPMI cipher suite ID, nil to negotiate it with the BMC.
+	ipmiCipherSuite *int
+	// clientCert, when set, is presented to the BMC for TLS client certificate authentication.
+	clientCert *tls.Certificate
+	// caCerts, when set, are the CA certificates the BMC TLS certificate is verified against instead of the system
+	// root CAs.
+	caCerts []*x509.Certificate
+	// proxyURL, when set, is the HTTP, HTTPS or SOCKS5 proxy that HTTP based providers connect through.
+	proxyURL *url.URL
+	// httpConfig is the HTTP client configuration of the controller.
+	httpConfig HTTPClientConfig
 }
 
+// ipmiDefaultPort is the IPMI port. It used to be the default of Connection.Port.
+const ipmiDefaultPort = 623
+
 func (b BMCOptions) Translate(host string) []bmclib.Option {
 	o := []bmclib.Option{}
 
+	// The HTTP client must be set before WithSecureTLS, which configures the TLS verification of the HTTP client in use.
+	if b.clientCert != nil || b.proxyURL != nil || b.httpConfig.Timeout != 0 {
+		o = append(o, bmclib.WithHTTPClient(newHTTPClient(b.clientCert, b.proxyURL, b.httpConfig.Timeout)))
+	}
+
+	// bmclib skips TLS verification unless told otherwise, so verify against the CA bundle of the Connection,
+	// or the system root CAs by default.
+	if !b.insecureTLS {
+		o = append(o, bmclib.WithSecureTLS(b.rootCAs()))
+	}
+
+	// The Connection port applies to both IPMI and Redfish. Provider specific ports take precedence
+	// as they are appended later.
+	if b.port != 0 {
+		o = append(o, bmclib.WithIpmitoolPort(strconv.Itoa(b.port)))
+		// Objects created before the Connection port was honored were defaulted to the IPMI port.
+		// Don't send Redfish requests there.
+		if b.port != ipmiDefaultPort {
+			o = append(o, bmclib.WithRedfishPort(strconv.Itoa(b.port)))
+		}
+	}
+
+	// Like the port, the provider specific cipher suite takes precedence.
+	if b.ipmiCipherSuite != nil {
+		o = append(o, bmclib.WithIpmitoolCipherSuite(strconv.Itoa(*b.ipmiCipherSuite)))
+	}
+
 	if b.ProviderOptions == nil {
 		return o
 	}
@@ -69,11 +175,14 @@ func (b BMCOptions) Translate(host string) []bmclib.Option {
 			o = append(o, bmclib.WithRedfishPort(strconv.Itoa(b.Redfish.Port)))
 		}
 		if b.Redfish.UseBasicAuth {
-			o = append(o, bmclib.WithRedfishUseBasicAuth(true))
+			o = append(o, bmclib.WithRedfishUseBasicAuth(true), bmclib.WithDellRedfishUseBasicAuth(true))
 		}
 		if b.Redfish.SystemName != "" {
 			o = append(o, bmclib.WithRedfishSystemName(b.Redfish.SystemName))
 		}
+		if len(b.Redfish.VersionsNotCompatible) > 0 {
+			o = append(o, bmclib.WithRedfishVersionsNotCompatible(b.Redfish.VersionsNotCompatible), bmclib.WithDellRedfishVersionsNotCompatible(b.Redfish.VersionsNotCompatible))
+		}
 	}
 
 	// ipmitool options
@@ -107,6 +216,107 @@ func (b BMCOptions) Translate(host string) []bmclib.Option {
 	return o
 }
 
+// configuredProtocols returns the protocols, or provider names, of the providers whose port is explicitly configured
+// in the options, in the order of the bmclib defaults. A Connection port of 623 only configures IPMI, like in
+// Translate.
+func (b BMCOptions) configuredProtocols() []string {
+	var protocols []string
+	if b.IPMITOOL != nil && b.IPMITOOL.Port != 0 || b.port != 0 {
+		protocols = append(protocols, "ipmi")
+	}
+	if b.Redfish != nil && b.Redfish.Port != 0 || b.port != 0 && b.port != ipmiDefaultPort {
+		protocols = append(protocols, "redfish")
+	}
+	if b.IntelAMT != nil && b.IntelAMT.Port != 0 {
+		protocols = append(protocols, "AMT")
+	}
+	if b.RPC != nil {
+		protocols = append(protocols, "rpc")
+	}
+
+	return protocols
+}
+
+// rootCAs returns the pool of the CA certificates of the options, nil to use the system root CAs.
+func (b BMCOptions) rootCAs() *x509.CertPool {
+	if len(b.caCerts) == 0 {
+		return nil
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range b.caCerts {
+		pool.AddCert(cert)
+	}
+
+	return pool
+}
+
+// bmclibHost returns host with an IPv6 literal enclosed in brackets. bmclib appends the port to the host with a
+// colon, both for the Redfish URL and for ipmitool, which is ambiguous with a bare IPv6 literal. Hostnames, IPv4
+// addresses and IPv6 literals already enclosed in brackets are returned unchanged.
+func bmclibHost(host string) string {
+	h := unbracketHost(host)
+	if addr, err := netip.ParseAddr(h); err == nil && addr.Is6() {
+		return "[" + h + "]"
+	}
+
+	return host
+}
+
+// unbracketHost returns host without the brackets enclosing an IPv6 literal, for example for net.JoinHostPort,
+// which adds them.
+func unbracketHost(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+
+	return host
+}
+
+// newHTTPClient returns an HTTP client, with the same defaults as the bmclib HTTP client, that presents cert
+// to the BMC when it is not nil and connects through proxy when it is not nil. Each request is bounded by timeout,
+// or by defaultHTTPTimeout when it is zero. Like the bmclib HTTP client, it skips TLS verification unless
+// WithSecureTLS is used.
+func newHTTPClient(cert *tls.Certificate, proxy *url.URL, timeout time.Duration) *http.Client {
+	// cookiejar.New never returns an error without options.
+	jar, _ := cookiejar.New(nil)
+	tp := http.DefaultTransport.(*http.Transport).Clone()
+	tp.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // verification is enabled by WithSecureTLS unless Connection.InsecureTLS is set.
+	}
+	if cert != nil {
+		tp.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+	}
+	if proxy != nil {
+		tp.Proxy = http.ProxyURL(proxy)
+	}
+	tp.DisableKeepAlives = true
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: tp,
+		Jar:       jar,
+	}
+}
+
+// parseProxyURL parses the proxy URL of a Connection. It returns nil when proxyURL is empty.
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if !v1alpha1.IsSupportedProxyScheme(u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %s: must be an http, https or socks5 URL with a host", u.Redacted())
+	}
+
+	return u, nil
+}
+
 func (b BMCOptions) translateRPC(host string) rpc.Provider {
 	s := map[rpc.Algorithm][]string{}
 	if b.rpcSecrets != nil {
@@ -224,6 +434,49 @@ func toExperimentalOpts(e *v1alpha1.ExperimentalOpts) rpc.Experimental {
 	return opt
 }
 
+// filterProviders returns the drivers that match names, ordered by names.
+// A name matches a driver by its name or its protocol, case insensitively.
+func filterProviders(drivers registrar.Drivers, names []string) registrar.Drivers {
+	var filtered registrar.Drivers
+	added := map[*registrar.Driver]bool{}
+	for _, name := range names {
+		for _, d := range drivers {
+			if added[d] || (!strings.EqualFold(d.Name, name) && !strings.EqualFold(d.Protocol, name)) {
+				continue
+			}
+			filtered = append(filtered, d)
+			added[d] = true
+		}
+	}
+
+	return filtered
+}
+
+// enabledProviders returns the drivers that match enabled, in their order.
+// A name matches a driver by its name or its protocol, case insensitively.
+func enabledProviders(drivers registrar.Drivers, enabled []string) registrar.Drivers {
+	var filtered registrar.Drivers
+	for _, d := range drivers {
+		if slices.ContainsFunc(enabled, func(name string) bool {
+			return strings.EqualFold(d.Name, name) || strings.EqualFold(d.Protocol, name)
+		}) {
+			filtered = append(filtered, d)
+		}
+	}
+
+	return filtered
+}
+
+// providerErrors formats the error of each attempted provider, in the order they were attempted.
+func providerErrors(attempted []string, details map[string]string) string {
+	var errs []string
+	for _, pe := range toProviderErrors(attempted, details) {
+		errs = append(errs, fmt.Sprintf("%s: %s", pe.Provider, pe.Error))
+	}
+
+	return strings.Join(errs, "; ")
+}
+
 // convert a slice of ProviderName to a slice of string.
 func toStringSlice(p []v1alpha1.ProviderName) []string {
 	var s []string
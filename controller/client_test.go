@@ -0,0 +1,162 @@
+package controller_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+	"github.com/tinkerbell/rufio/controller"
+)
+
+func TestNewClientFuncPreferredProviders(t *testing.T) {
+	tests := map[string]struct {
+		preferred []string
+		// versionsNotCompatible, when set, removes the drivers that fail the compatibility check before opening.
+		versionsNotCompatible []string
+		wantErr               string
+	}{
+		"no matching provider": {
+			preferred: []string{"notaprovider"},
+			wantErr:   "none of the preferred providers [notaprovider] are available",
+		},
+		"provider errors are listed": {
+			preferred: []string{"Redfish"},
+			wantErr:   "failed to open connection to BMC with preferred providers: gofish: ",
+		},
+		"incompatible providers are filtered out": {
+			preferred:             []string{"Redfish"},
+			versionsNotCompatible: []string{"1.6.0"},
+			wantErr:               "none of the preferred providers [Redfish] are available",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &controller.BMCOptions{ProviderOptions: &v1alpha1.ProviderOptions{
+				PreferredProviders: tt.preferred,
+				// Nothing listens on port 1, so opening the connection fails fast.
+				Redfish: &v1alpha1.RedfishOptions{Port: 1, VersionsNotCompatible: tt.versionsNotCompatible},
+			}}
+			_, err := controller.NewClientFunc(5*time.Second, nil)(context.Background(), logr.Discard(), "127.0.0.1", "user", "pass", opts)
+			if err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected err to contain %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewClientFuncEnabledProviders(t *testing.T) {
+	tests := map[string]struct {
+		enabled   []string
+		preferred []string
+		wantErr   string
+	}{
+		"preferred providers are disabled": {
+			enabled:   []string{"redfish"},
+			preferred: []string{"ipmitool"},
+			wantErr:   "none of the preferred providers [ipmitool] are enabled, the controller only enables [redfish]",
+		},
+		"no enabled provider is available": {
+			enabled: []string{"notaprovider"},
+			wantErr: "none of the providers enabled by the controller [notaprovider] are available",
+		},
+		"enabled preferred providers are opened": {
+			enabled:   []string{"Redfish"},
+			preferred: []string{"gofish", "ipmitool"},
+			wantErr:   "failed to open connection to BMC with preferred providers: gofish: ",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &controller.BMCOptions{ProviderOptions: &v1alpha1.ProviderOptions{
+				PreferredProviders: tt.preferred,
+				// Nothing listens on port 1, so opening the connection fails fast.
+				Redfish: &v1alpha1.RedfishOptions{Port: 1},
+			}}
+			_, err := controller.NewClientFunc(5*time.Second, tt.enabled)(context.Background(), logr.Discard(), "127.0.0.1", "user", "pass", opts)
+			if err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected err to contain %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewClientFuncDisablePortProbing(t *testing.T) {
+	tests := map[string]struct {
+		opts    *v1alpha1.ProviderOptions
+		wantErr string
+		// notAttempted is a provider that must not be attempted, as its port is not configured.
+		notAttempted string
+	}{
+		"no configured port": {
+			opts:    &v1alpha1.ProviderOptions{DisablePortProbing: true},
+			wantErr: "port probing is disabled and none of the providers of the protocols with a configured port [] are available",
+		},
+		"no preferred provider with a configured port": {
+			// Nothing listens on port 1, so opening the connection fails fast.
+			opts:    &v1alpha1.ProviderOptions{DisablePortProbing: true, PreferredProviders: []string{"ipmitool"}, Redfish: &v1alpha1.RedfishOptions{Port: 1}},
+			wantErr: "none of the preferred providers [ipmitool] are available",
+		},
+		"only the providers with a configured port are opened": {
+			opts:         &v1alpha1.ProviderOptions{DisablePortProbing: true, Redfish: &v1alpha1.RedfishOptions{Port: 1}},
+			wantErr:      "failed to open connection to BMC",
+			notAttempted: "ipmitool",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &controller.BMCOptions{ProviderOptions: tt.opts}
+			_, err := controller.NewClientFunc(5*time.Second, nil)(context.Background(), logr.Discard(), "127.0.0.1", "user", "pass", opts)
+			if err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected err to contain %q, got: %v", tt.wantErr, err)
+			}
+			if tt.notAttempted != "" && strings.Contains(err.Error(), tt.notAttempted) {
+				t.Fatalf("expected %s not to be attempted, got: %v", tt.notAttempted, err)
+			}
+		})
+	}
+}
+
+func TestNewClientFuncIPv6Host(t *testing.T) {
+	tests := map[string]struct {
+		host string
+		port int
+		// wantAddr is the address Redfish requests are sent to. Nothing listens on it, so it is in the error.
+		wantAddr string
+	}{
+		"ipv6 with port":              {host: "::1", port: 1, wantAddr: "[::1]:1"},
+		"ipv6 without port":           {host: "::1", wantAddr: "[::1]:443"},
+		"bracketed ipv6 with port":    {host: "[::1]", port: 1, wantAddr: "[::1]:1"},
+		"bracketed ipv6 without port": {host: "[::1]", wantAddr: "[::1]:443"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &controller.BMCOptions{ProviderOptions: &v1alpha1.ProviderOptions{
+				PreferredProviders: []string{"gofish"},
+				Redfish:            &v1alpha1.RedfishOptions{Port: tt.port},
+			}}
+			_, err := controller.NewClientFunc(5*time.Second, nil)(context.Background(), logr.Discard(), tt.host, "user", "pass", opts)
+			if err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantAddr) {
+				t.Fatalf("expected err to contain %q, got: %v", tt.wantAddr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// CredentialProvider resolves BMC credentials held outside of Kubernetes Secrets, for example in Vault.
+// Providers are registered on the reconcilers by name and referenced by Connection.AuthProviderRef.
+type CredentialProvider interface {
+	// Credentials returns the username and password referenced by ref.
+	Credentials(ctx context.Context, ref v1alpha1.AuthProviderRef) (username, password string, err error)
+}
+
+// resolveCredentials returns the username and password of the BMC connection c. They are read from
+// the provider named in c.AuthProviderRef when set, otherwise from the Secret c.AuthSecretRef using reader,
+// with the username of c.Username when set. A connection that authenticates with only a client certificate
+// has no username and password.
+func resolveCredentials(ctx context.Context, reader client.Reader, providers map[string]CredentialProvider, c v1alpha1.Connection, namespace string) (string, string, error) {
+	if c.AuthProviderRef == nil && c.ClientCertSecretRef != nil && c.AuthSecretRef.Name == "" {
+		return "", "", nil
+	}
+	if c.AuthProviderRef == nil {
+		return resolveAuthSecretRef(ctx, reader, c.AuthSecretRef, c.Username, namespace)
+	}
+
+	provider, ok := providers[c.AuthProviderRef.Name]
+	if !ok {
+		return "", "", fmt.Errorf("credential provider %q is not configured", c.AuthProviderRef.Name)
+	}
+	username, password, err := provider.Credentials(ctx, *c.AuthProviderRef)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get credentials %s from provider %q: %w", c.AuthProviderRef.Path, c.AuthProviderRef.Name, err)
+	}
+
+	return username, password, nil
+}
+
+// usesAuthSecret reports whether the credentials of c are read from its AuthSecretRef or AuthProviderRef.
+// The RPC provider authenticates with HMAC secrets instead.
+func usesAuthSecret(c v1alpha1.Connection) bool {
+	return c.ProviderOptions == nil || c.ProviderOptions.RPC == nil
+}
+
+// refreshCredentials re-reads the credentials of task, bypassing the informer cache, after the BMC rejected
+// username and password. It returns the current credentials and whether they differ from the ones used,
+// so the caller can retry with rotated credentials instead of failing the Task.
+func (r *TaskReconciler) refreshCredentials(ctx context.Context, logger logr.Logger, task *v1alpha1.Task, username, password string) (string, string, bool) {
+	var reader client.Reader = r.client
+	if r.apiReader != nil {
+		reader = r.apiReader
+	}
+
+	newUsername, newPassword, err := resolveCredentials(ctx, reader, r.credentialProviders, task.Spec.Connection, task.Namespace)
+	if err != nil {
+		logger.Error(err, "failed to re-read connection credentials after authentication error")
+		return username, password, false
+	}
+	if newUsername == username && newPassword == password {
+		return username, password, false
+	}
+	logger.Info("connection credentials changed, retrying with the new credentials")
+
+	return newUsername, newPassword, true
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// defaultPowerCycleWaitTimeout is how long a PowerCycleAction without a WaitTimeout waits for the Machine
+// to power back on.
+const defaultPowerCycleWaitTimeout = 5 * time.Minute
+
+// powerCycleWaitTimeout returns the wait timeout of a.
+func powerCycleWaitTimeout(a *v1alpha1.PowerCycleAction) time.Duration {
+	if a.WaitTimeout == nil || a.WaitTimeout.Duration <= 0 {
+		return defaultPowerCycleWaitTimeout
+	}
+
+	return a.WaitTimeout.Duration
+}
+
+// checkPowerCycle polls the power state of a Machine after a power cycle until it reports on.
+// A non-zero Result means the Machine is not powered on yet. When the wait timeout since the Task started
+// has passed, a terminal error is returned so the Task fails.
+func checkPowerCycle(ctx context.Context, log logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client) (ctrl.Result, error) {
+	rawState, err := bmcClient.GetPowerState(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get power state: %w", err)
+	}
+	if toPowerState(rawState) == v1alpha1.On {
+		task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage("machine powered back on after power cycle"))
+		return ctrl.Result{}, nil
+	}
+
+	wait := powerCycleWaitTimeout(task.CurrentAction().PowerCycleAction)
+	if time.Since(task.Status.StartTime.Time) >= wait {
+		return ctrl.Result{}, &terminalError{err: fmt.Errorf("machine did not return to power on within %s after power cycle, power state is %s", wait, rawState)}
+	}
+	log.Info("requeuing task", "currentPowerState", rawState, "requeueAfter", powerActionRequeueAfter)
+
+	return ctrl.Result{RequeueAfter: powerActionRequeueAfter}, nil
+}
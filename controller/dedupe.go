@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// taskHostActionKey is the field index of Tasks by the BMC host of their Connection and a digest of their actions.
+const taskHostActionKey = ".spec.hostAction"
+
+// duplicateTaskRequeueAfter is the wait before a Task deferred for an identical running Task is reconciled again.
+const duplicateTaskRequeueAfter = 10 * time.Second
+
+// DuplicateTaskPolicy is what the Task reconciler does with a Task identical to a running Task, one with the
+// same actions against the same BMC host.
+type DuplicateTaskPolicy string
+
+const (
+	// DuplicateTaskPolicyNone runs identical Tasks independently.
+	DuplicateTaskPolicyNone DuplicateTaskPolicy = "none"
+	// DuplicateTaskPolicyDefer sets the Task Pending until the identical running Task finished, then runs it.
+	DuplicateTaskPolicyDefer DuplicateTaskPolicy = "defer"
+	// DuplicateTaskPolicySkip completes the Task without running its actions.
+	DuplicateTaskPolicySkip DuplicateTaskPolicy = "skip"
+)
+
+// TaskHostActionIndexFunc is Indexer func which returns the BMC host and the digest of the actions of a Task.
+func TaskHostActionIndexFunc(obj client.Object) []string {
+	task, ok := obj.(*v1alpha1.Task)
+	if !ok {
+		return nil
+	}
+
+	return []string{taskHostAction(task)}
+}
+
+// taskHostAction returns the BMC host of task, or the Machine it references, and a digest of its actions, equal
+// for Tasks running the same actions against the same BMC.
+func taskHostAction(task *v1alpha1.Task) string {
+	b, _ := json.Marshal(struct {
+		Task    v1alpha1.Action   `json:"task"`
+		Actions []v1alpha1.Action `json:"actions"`
+	}{task.Spec.Task, task.Spec.Actions})
+	sum := sha256.Sum256(b)
+
+	host := task.Spec.Connection.Host
+	if ref := task.Spec.MachineRef; ref != nil {
+		// The Connection of a Task referencing a Machine is only resolved while it is reconciled.
+		host = "machine:" + ref.Namespace + "/" + ref.Name
+	}
+
+	return host + "/" + hex.EncodeToString(sum[:8])
+}
+
+// runningDuplicate returns an unfinished Task, other than task, that runs the same actions against the same
+// BMC host and has started, or nil when there is none.
+func (r *TaskReconciler) runningDuplicate(ctx context.Context, task *v1alpha1.Task) (*v1alpha1.Task, error) {
+	tasks := &v1alpha1.TaskList{}
+	if err := r.client.List(ctx, tasks, client.MatchingFields{taskHostActionKey: taskHostAction(task)}); err != nil {
+		return nil, fmt.Errorf("failed to list identical tasks: %w", err)
+	}
+
+	for i := range tasks.Items {
+		t := &tasks.Items[i]
+		if client.ObjectKeyFromObject(t) == client.ObjectKeyFromObject(task) || t.Status.StartTime.IsZero() {
+			continue
+		}
+		if t.Finished() {
+			continue
+		}
+		return t, nil
+	}
+
+	return nil, nil
+}
+
+// duplicateTaskMessage is the Task condition message when the Task was skipped for the identical running Task.
+func duplicateTaskMessage(running *v1alpha1.Task) string {
+	return fmt.Sprintf("identical Task %s is already running; no action taken", client.ObjectKeyFromObject(running))
+}
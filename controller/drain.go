@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errShutdownDrainExpired is the cause of the cancellation of a reconcile that did not finish within the
+// shutdown drain period.
+var errShutdownDrainExpired = errors.New("controller shut down before the reconcile finished")
+
+// drainContext returns a context that, unlike ctx, is not cancelled as soon as the manager stops but period
+// later, so a reconcile in flight can finish its BMC operations and record the result. A period of 0 returns ctx.
+func drainContext(ctx context.Context, period time.Duration) (context.Context, context.CancelFunc) {
+	if period <= 0 {
+		return ctx, func() {}
+	}
+
+	drained, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		t := time.NewTimer(period)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			cancel(errShutdownDrainExpired)
+		case <-drained.Done():
+		}
+	})
+
+	return drained, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// shutdownDrainExpired reports whether ctx was cancelled because the controller shut down and the drain
+// period passed. A Task cut off this way is left as is, to be reconciled again once the controller is back.
+func shutdownDrainExpired(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errShutdownDrainExpired)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// dryRunTask checks that the actions of a Task with DryRun set can run over the opened BMC connection, without
+// running them. The connection is confirmed by reading the power state, and the capability each action requires
+// is checked against the capabilities of the opened providers, which are stored in the Task status. An action
+// whose capability is missing is returned as an unsupported error. Nothing on the Machine is changed.
+func dryRunTask(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	if _, err := bmcClient.GetPowerState(ctx); err != nil {
+		return fmt.Errorf("dry run: failed to read power state with the opened connection: %w", err)
+	}
+	task.Status.Capabilities = detectCapabilities(bmcClient.Registry.Drivers)
+
+	names := make([]string, 0, len(bmcClient.Registry.Drivers))
+	for _, d := range bmcClient.Registry.Drivers {
+		names = append(names, d.Name)
+	}
+	actions := task.Spec.Actions
+	if len(actions) == 0 {
+		actions = []v1alpha1.Action{task.Spec.Task}
+	}
+	var missing []string
+	for _, a := range actions {
+		if required := actionCapability(a); required != "" && !slices.Contains(task.Status.Capabilities, required) {
+			missing = append(missing, fmt.Sprintf("%s requires the %s capability", actionType(a), required))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("dry run: %s, which the providers [%s] don't support: %w", strings.Join(missing, ", "), strings.Join(names, ", "), bmclibErrs.ErrProviderImplementation)
+	}
+
+	msg := fmt.Sprintf("dry run: connection verified with providers: %s, no action was run", strings.Join(names, ", "))
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(msg))
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// transientErrorMessages are substrings of BMC error messages that indicate a temporary condition.
+var transientErrorMessages = []string{
+	"connection reset",
+	"connection refused",
+	"503",
+	"service unavailable",
+	"i/o timeout",
+	"tls handshake timeout",
+	"eof",
+}
+
+// isUnsupported reports whether err indicates that none of the opened providers implement the
+// requested feature.
+func isUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return errors.Is(err, bmclibErrs.ErrProviderImplementation) || strings.Contains(err.Error(), "implementations found")
+}
+
+// authErrorMessages are substrings of BMC error messages that indicate the credentials were rejected,
+// for example a Redfish 401 response.
+var authErrorMessages = []string{
+	"401",
+	"unauthorized",
+}
+
+// isAuthError reports whether err indicates that the BMC rejected the credentials.
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, bmclibErrs.ErrLoginFailed) || errors.Is(err, bmclibErrs.ErrNotAuthenticated) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, m := range authErrorMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTransient reports whether err is likely temporary, so retrying the action may succeed.
+// Authentication and unsupported action errors are never transient.
+func isTransient(err error) bool {
+	if err == nil || isUnsupported(err) || isAuthError(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, m := range transientErrorMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeoutErrorMessages are substrings of error messages that indicate an operation did not finish in time.
+var timeoutErrorMessages = []string{
+	"exceeded timeout",
+	"exceeded default bmc timeout",
+	"deadline exceeded",
+	"i/o timeout",
+	"tls handshake timeout",
+}
+
+// networkErrorMessages are substrings of error messages that indicate the BMC could not be reached.
+var networkErrorMessages = []string{
+	"connection reset",
+	"connection refused",
+	"no route to host",
+	"no such host",
+	"network is unreachable",
+	"circuit open for host",
+	"eof",
+}
+
+// failureReason classifies err, the error that failed a Task, for TaskStatus.FailureReason.
+func failureReason(err error) v1alpha1.FailureReason {
+	switch {
+	case isAuthError(err):
+		return v1alpha1.FailureReasonAuth
+	case isUnsupported(err):
+		return v1alpha1.FailureReasonUnsupported
+	case errors.Is(err, context.DeadlineExceeded) || containsAny(err, timeoutErrorMessages):
+		return v1alpha1.FailureReasonTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return v1alpha1.FailureReasonTimeout
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, io.ErrUnexpectedEOF) || containsAny(err, networkErrorMessages) {
+		return v1alpha1.FailureReasonNetwork
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return v1alpha1.FailureReasonNetwork
+	}
+
+	return v1alpha1.FailureReasonProvider
+}
+
+// conditionReason returns the Reason of the Task condition of a failure classified as reason.
+func conditionReason(reason v1alpha1.FailureReason) string {
+	switch reason {
+	case v1alpha1.FailureReasonAuth:
+		return v1alpha1.TaskReasonAuthFailed
+	case v1alpha1.FailureReasonNetwork:
+		return v1alpha1.TaskReasonConnectionFailed
+	case v1alpha1.FailureReasonUnsupported:
+		return v1alpha1.TaskReasonUnsupported
+	case v1alpha1.FailureReasonTimeout:
+		return v1alpha1.TaskReasonTimeout
+	default:
+		return v1alpha1.TaskReasonActionFailed
+	}
+}
+
+// containsAny reports whether the lower case message of err contains one of messages.
+func containsAny(err error, messages []string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, m := range messages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// providerErrorsError is an error of a BMC call annotated with the error of each attempted provider.
+type providerErrorsError struct {
+	providerErrors []v1alpha1.ProviderError
+	err            error
+}
+
+func (e *providerErrorsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *providerErrorsError) Unwrap() error {
+	return e.err
+}
+
+// withProviderErrors annotates err with the error of each provider in attempted that failed, from details.
+// err is returned unchanged when no provider failed.
+func withProviderErrors(err error, attempted []string, details map[string]string) error {
+	pe := toProviderErrors(attempted, details)
+	if err == nil || len(pe) == 0 {
+		return err
+	}
+
+	return &providerErrorsError{providerErrors: pe, err: err}
+}
+
+// providerErrorsOf returns the provider errors err was annotated with by withProviderErrors, if any.
+func providerErrorsOf(err error) []v1alpha1.ProviderError {
+	var pe *providerErrorsError
+	if errors.As(err, &pe) {
+		return pe.providerErrors
+	}
+
+	return nil
+}
+
+// toProviderErrors returns the error of each provider in attempted that failed, in the order they were attempted.
+func toProviderErrors(attempted []string, details map[string]string) []v1alpha1.ProviderError {
+	var pe []v1alpha1.ProviderError
+	for _, p := range attempted {
+		if d, ok := details[p]; ok {
+			pe = append(pe, v1alpha1.ProviderError{Provider: p, Error: d})
+		}
+	}
+
+	return pe
+}
+
+// terminalError is an error of a Task status check that fails the Task instead of being retried.
+type terminalError struct {
+	err error
+}
+
+func (e *terminalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *terminalError) Unwrap() error {
+	return e.err
+}
+
+// isTerminal reports whether err, or an error it wraps, is a terminalError.
+func isTerminal(err error) bool {
+	var t *terminalError
+	return errors.As(err, &t)
+}
+
+// cipherSuiteErrorMessages are substrings of ipmitool error messages that indicate the BMC rejected the cipher suite.
+var cipherSuiteErrorMessages = []string{
+	"no matching cipher suite",
+	"invalid cipher suite",
+	"unsupported cipher suite",
+}
+
+// isCipherSuiteError reports whether err indicates that the BMC does not support the IPMI cipher suite.
+func isCipherSuiteError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, m := range cipherSuiteErrorMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cipherSuiteHint returns a hint to append to the message of a connection error err when it indicates
+// an IPMI cipher suite mismatch, otherwise an empty string.
+func cipherSuiteHint(err error, c v1alpha1.Connection) string {
+	if !isCipherSuiteError(err) {
+		return ""
+	}
+	if c.IPMIOptions != nil && c.IPMIOptions.CipherSuite != nil {
+		return fmt.Sprintf(" (IPMI cipher suite mismatch: the BMC does not accept cipher suite %d, try another ipmiOptions.cipherSuite)", *c.IPMIOptions.CipherSuite)
+	}
+
+	return " (IPMI cipher suite mismatch: the BMC may require a specific cipher suite, set ipmiOptions.cipherSuite)"
+}
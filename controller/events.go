@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// Reasons of the Events recorded on Task state transitions.
+const (
+	taskStartedEventReason     = "Started"
+	taskCompletedEventReason   = "Completed"
+	taskFailedEventReason      = "Failed"
+	taskUnsupportedEventReason = "Unsupported"
+)
+
+// recordTaskEvent records an Event of eventType and reason on task. The message is prefixed with the
+// action type and the BMC host the Task targets.
+func recordTaskEvent(recorder record.EventRecorder, task *v1alpha1.Task, eventType, reason, message string) {
+	recorder.Eventf(task, eventType, reason, "%s on %s: %s", actionType(task.CurrentAction()), task.Spec.Connection.Host, message)
+}
+
+// recordTaskFailedEvent records a Warning Event describing why task failed, with the Unsupported reason when
+// no provider supports its action.
+func recordTaskFailedEvent(recorder record.EventRecorder, task *v1alpha1.Task, message string) {
+	reason := taskFailedEventReason
+	if task.Status.FailureReason == v1alpha1.FailureReasonUnsupported {
+		reason = taskUnsupportedEventReason
+	}
+	recordTaskEvent(recorder, task, corev1.EventTypeWarning, reason, message)
+}
+
+// actionType returns a short description of the type of action a, for example "PowerAction(on)".
+func actionType(a v1alpha1.Action) string {
+	switch {
+	case a.PowerAction != nil:
+		return fmt.Sprintf("PowerAction(%s)", *a.PowerAction)
+	case a.OneTimeBootDeviceAction != nil:
+		return "OneTimeBootDeviceAction"
+	case a.PersistentBootDeviceAction != nil:
+		return "PersistentBootDeviceAction"
+	case a.VirtualMediaAction != nil:
+		return "VirtualMediaAction"
+	case a.GetBIOSConfigAction != nil:
+		return "GetBIOSConfigAction"
+	case a.SetBIOSConfigAction != nil:
+		return "SetBIOSConfigAction"
+	case a.GetSELAction != nil:
+		return "GetSELAction"
+	case a.ClearSELAction != nil:
+		return "ClearSELAction"
+	case a.GetFirmwareInventoryAction != nil:
+		return "GetFirmwareInventoryAction"
+	case a.SoftPowerOffAction != nil:
+		return "SoftPowerOffAction"
+	case a.PowerCycleAction != nil:
+		return "PowerCycleAction"
+	case a.VerifyConnectionAction != nil:
+		return "VerifyConnectionAction"
+	case a.IdentifyAction != nil:
+		return fmt.Sprintf("IdentifyAction(%s)", a.IdentifyAction.State)
+	case a.GetSensorsAction != nil:
+		return "GetSensorsAction"
+	case a.GetBootDeviceAction != nil:
+		return "GetBootDeviceAction"
+	case a.SetBMCCredentialsAction != nil:
+		return "SetBMCCredentialsAction"
+	case a.RedfishActionPassthroughAction != nil:
+		return "RedfishActionPassthroughAction"
+	case a.UpdateFirmwareAction != nil:
+		return "UpdateFirmwareAction"
+	case a.SetPowerLimitAction != nil:
+		return "SetPowerLimitAction"
+	case a.GetPowerLimitAction != nil:
+		return "GetPowerLimitAction"
+	case a.GetBMCNetworkAction != nil:
+		return "GetBMCNetworkAction"
+	case a.SetBMCNetworkAction != nil:
+		return "SetBMCNetworkAction"
+	case a.GetBootProgressAction != nil:
+		return "GetBootProgressAction"
+	case a.ResetBMCAction != nil:
+		return fmt.Sprintf("ResetBMCAction(%s)", a.ResetBMCAction.Type)
+	case a.ResetAction != nil:
+		return fmt.Sprintf("ResetAction(%s)", a.ResetAction.ResetType)
+	case a.BootAndPowerAction != nil:
+		return fmt.Sprintf("BootAndPowerAction(%s, %s)", a.BootAndPowerAction.Device, a.BootAndPowerAction.Power())
+	default:
+		return "UnknownAction"
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// taskOperations tracks the cancel funcs of the BMC operations in flight, by Task.
+// A Task is never reconciled concurrently, so there is at most one operation per Task.
+type taskOperations struct {
+	mu      sync.Mutex
+	cancels map[types.NamespacedName]context.CancelFunc
+}
+
+// start returns a context for the BMC operations of the Task key that is cancelled by cancel(key).
+// The returned func must be called once the operations have finished.
+func (o *taskOperations) start(ctx context.Context, key types.NamespacedName) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancels == nil {
+		o.cancels = map[types.NamespacedName]context.CancelFunc{}
+	}
+	o.cancels[key] = cancel
+
+	return ctx, func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		delete(o.cancels, key)
+		cancel()
+	}
+}
+
+// cancel cancels the BMC operations in flight of the Task key, if any.
+func (o *taskOperations) cancel(key types.NamespacedName) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if cancel, ok := o.cancels[key]; ok {
+		cancel()
+	}
+}
+
+// cancelOnDelete returns a predicate that cancels the BMC operation in flight of a Task with the abandon
+// DeletePolicy as soon as it is deleted. It runs in the informer, so it doesn't wait for the reconcile
+// running the operation to finish. It filters no events.
+func (r *TaskReconciler) cancelOnDelete() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			task, ok := e.ObjectNew.(*v1alpha1.Task)
+			if ok && !task.DeletionTimestamp.IsZero() && task.Spec.DeletePolicy == v1alpha1.DeletePolicyAbandon {
+				r.operations.cancel(client.ObjectKeyFromObject(task))
+			}
+			return true
+		},
+	}
+}
+
+// actionInFlight returns true when the current action of task has started and the Task has not finished yet.
+func actionInFlight(task *v1alpha1.Task) bool {
+	return !task.Status.StartTime.IsZero() && !task.Finished()
+}
+
+// removeFinalizer removes the TaskFinalizer from task, if set.
+func (r *TaskReconciler) removeFinalizer(ctx context.Context, task *v1alpha1.Task) error {
+	if !controllerutil.RemoveFinalizer(task, v1alpha1.TaskFinalizer) {
+		return nil
+	}
+	if err := r.client.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to remove finalizer from Task %s/%s: %w", task.Namespace, task.Name, err)
+	}
+
+	return nil
+}
+
+// addFinalizer adds the TaskFinalizer to task, if not set.
+func (r *TaskReconciler) addFinalizer(ctx context.Context, task *v1alpha1.Task) error {
+	if !controllerutil.AddFinalizer(task, v1alpha1.TaskFinalizer) {
+		return nil
+	}
+	if err := r.client.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to add finalizer to Task %s/%s: %w", task.Namespace, task.Name, err)
+	}
+
+	return nil
+}
+
+// removeFinalizer removes the MachineFinalizer from machine, if set.
+func (r *MachineReconciler) removeFinalizer(ctx context.Context, machine *v1alpha1.Machine) error {
+	if !controllerutil.RemoveFinalizer(machine, v1alpha1.MachineFinalizer) {
+		return nil
+	}
+	if err := r.client.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to remove finalizer from Machine %s/%s: %w", machine.Namespace, machine.Name, err)
+	}
+
+	return nil
+}
+
+// addFinalizer adds the MachineFinalizer to machine, if not set.
+func (r *MachineReconciler) addFinalizer(ctx context.Context, machine *v1alpha1.Machine) error {
+	if !controllerutil.AddFinalizer(machine, v1alpha1.MachineFinalizer) {
+		return nil
+	}
+	if err := r.client.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to add finalizer to Machine %s/%s: %w", machine.Namespace, machine.Name, err)
+	}
+
+	return nil
+}
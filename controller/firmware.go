@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/providers"
+	"github.com/bmc-toolbox/common"
+	"github.com/jacobweinstock/registrar"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// maxFirmwareComponents is the maximum number of firmware components stored in the Task status.
+const maxFirmwareComponents = 100
+
+// firmwareInstallFeatures are the provider features that allow rufio to install firmware.
+var firmwareInstallFeatures = []registrar.Feature{
+	providers.FeatureFirmwareInstall,
+	providers.FeatureFirmwareInstallUploaded,
+	providers.FeatureFirmwareUploadInitiateInstall,
+}
+
+// getFirmwareInventory reads the installed firmware versions of the Machine and stores them in the Task status.
+// When there are more than maxFirmwareComponents components, the components are truncated
+// and a message is added to the Task Completed condition.
+func getFirmwareInventory(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	device, err := bmcClient.Inventory(ctx)
+	if err != nil {
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support firmware inventory, a Redfish capable provider is required: %w", err)
+		}
+		return err
+	}
+
+	updateable := supportsAny(bmcClient.Registry.Drivers, firmwareInstallFeatures)
+	components := toFirmwareComponents(device, updateable)
+	if len(components) > maxFirmwareComponents {
+		msg := fmt.Sprintf("firmware inventory truncated to %d of %d components", maxFirmwareComponents, len(components))
+		task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(msg))
+		components = components[:maxFirmwareComponents]
+	}
+	task.Status.Firmware = components
+
+	return nil
+}
+
+// toFirmwareComponents returns the components of device that report an installed firmware version.
+func toFirmwareComponents(device *common.Device, updateable bool) []v1alpha1.FirmwareComponent {
+	if device == nil {
+		return nil
+	}
+
+	var components []v1alpha1.FirmwareComponent
+	add := func(kind string, c common.Common) {
+		if c.Firmware == nil || c.Firmware.Installed == "" {
+			return
+		}
+		name := kind
+		if id := strings.TrimSpace(firstNonEmpty(c.ProductName, c.Model, c.Description)); id != "" {
+			name = fmt.Sprintf("%s %s", kind, id)
+		}
+		components = append(components, v1alpha1.FirmwareComponent{Name: name, Version: c.Firmware.Installed, Updateable: updateable})
+	}
+
+	if device.BIOS != nil {
+		add("BIOS", device.BIOS.Common)
+	}
+	if device.BMC != nil {
+		add("BMC", device.BMC.Common)
+	}
+	if device.Mainboard != nil {
+		add("Mainboard", device.Mainboard.Common)
+	}
+	for _, c := range device.CPLDs {
+		add("CPLD", c.Common)
+	}
+	for _, n := range device.NICs {
+		add("NIC", n.Common)
+	}
+	for _, s := range device.StorageControllers {
+		add("StorageController", s.Common)
+	}
+	for _, d := range device.Drives {
+		add("Drive", d.Common)
+	}
+	for _, p := range device.PSUs {
+		add("PSU", p.Common)
+	}
+
+	return components
+}
+
+// supportsAny reports whether any of drivers supports one of features.
+func supportsAny(drivers registrar.Drivers, features []registrar.Feature) bool {
+	for _, d := range drivers {
+		for _, f := range features {
+			for _, df := range d.Features {
+				if df == f {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// firstNonEmpty returns the first of s that is not empty.
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
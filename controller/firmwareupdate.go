@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+const (
+	// firmwareUpdateRequeueAfter is the wait between polls of the Redfish task of a firmware update.
+	firmwareUpdateRequeueAfter = 30 * time.Second
+	// simpleUpdatePath is the path of the Redfish SimpleUpdate action.
+	simpleUpdatePath = "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate"
+	// firmwareInventoryPath is the path of the Redfish firmware inventory, the parent of update targets.
+	firmwareInventoryPath = "/redfish/v1/UpdateService/FirmwareInventory/"
+)
+
+// redfishTask is the part of a Redfish Task resource used to track a firmware update.
+type redfishTask struct {
+	TaskState       string `json:"TaskState"`
+	TaskStatus      string `json:"TaskStatus"`
+	PercentComplete *int   `json:"PercentComplete"`
+	Messages        []struct {
+		Message string `json:"Message"`
+	} `json:"Messages"`
+}
+
+// updateFirmware starts the firmware update of action with the Redfish SimpleUpdate action and stores the
+// path of the task monitor of the update in the Task status. When action has a Checksum, the image is
+// downloaded and verified first. bmclib only supports uploading images, so the request is sent like the
+// request of a RedfishActionPassthroughAction.
+func updateFirmware(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions, action v1alpha1.UpdateFirmwareAction) error {
+	if action.Checksum != "" {
+		if err := verifyImageChecksum(ctx, action.ImageURL, action.Checksum); err != nil {
+			return &terminalError{err: err}
+		}
+	}
+
+	target := action.Component
+	if !strings.HasPrefix(target, "/redfish/") {
+		target = firmwareInventoryPath + target
+	}
+	body, err := json.Marshal(map[string]any{"ImageURI": action.ImageURL, "Targets": []string{target}})
+	if err != nil {
+		return fmt.Errorf("failed to encode SimpleUpdate request: %w", err)
+	}
+	resp, err := redfishRequest(ctx, bmcClient, opts, http.MethodPost, simpleUpdatePath, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRedfishResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read SimpleUpdate response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SimpleUpdate of %s returned %s: %s", action.Component, resp.Status, b)
+	}
+
+	monitor, err := taskMonitorPath(resp.Header.Get("Location"), b)
+	if err != nil {
+		return fmt.Errorf("SimpleUpdate of %s: %w", action.Component, err)
+	}
+	task.Status.FirmwareTaskMonitor = monitor
+	task.Status.FirmwareProgress = 0
+
+	return nil
+}
+
+// taskMonitorPath returns the path of the Redfish task that tracks an update, from the Location header of the
+// SimpleUpdate response or, when it is not set, from the @odata.id of the Task in the response body.
+func taskMonitorPath(location string, body []byte) (string, error) {
+	if location == "" {
+		var t struct {
+			ID string `json:"@odata.id"`
+		}
+		if err := json.Unmarshal(body, &t); err == nil {
+			location = t.ID
+		}
+	}
+	if location == "" {
+		return "", fmt.Errorf("the BMC returned no task to track the update")
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid task location %q: %w", location, err)
+	}
+
+	return u.RequestURI(), nil
+}
+
+// verifyImageChecksum downloads the image at imageURL and compares its digest to checksum, "sha256:<hex digest>".
+func verifyImageChecksum(ctx context.Context, imageURL, checksum string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create firmware image request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download firmware image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download firmware image: %s", resp.Status)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return fmt.Errorf("failed to download firmware image: %w", err)
+	}
+	if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != checksum {
+		return fmt.Errorf("firmware image checksum %s does not match %s", got, checksum)
+	}
+
+	return nil
+}
+
+// checkFirmwareUpdate polls the Redfish task of the firmware update of the Task and stores its progress.
+// A non-zero Result means the update is still running. A failed update is returned as a terminal error
+// with the messages of the BMC.
+func checkFirmwareUpdate(ctx context.Context, log logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) (ctrl.Result, error) {
+	monitor := task.Status.FirmwareTaskMonitor
+	resp, err := redfishRequest(ctx, bmcClient, opts, http.MethodGet, monitor, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRedfishResponseBytes))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to read firmware update task: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ctrl.Result{}, fmt.Errorf("firmware update task %s returned %s", monitor, resp.Status)
+	}
+
+	var t redfishTask
+	_ = json.Unmarshal(b, &t)
+	if t.PercentComplete != nil {
+		task.Status.FirmwareProgress = *t.PercentComplete
+	}
+
+	switch t.TaskState {
+	case "":
+		// A task monitor returns 202 Accepted while the task runs, and the response of the action once done.
+		if resp.StatusCode != http.StatusAccepted {
+			task.Status.FirmwareProgress = 100
+			return ctrl.Result{}, nil
+		}
+	case "Completed":
+		if t.TaskStatus != "Critical" {
+			task.Status.FirmwareProgress = 100
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, &terminalError{err: fmt.Errorf("firmware update failed: %s", redfishTaskMessages(t))}
+	case "Exception", "Killed", "Cancelled", "Interrupted":
+		return ctrl.Result{}, &terminalError{err: fmt.Errorf("firmware update %s: %s", strings.ToLower(t.TaskState), redfishTaskMessages(t))}
+	}
+	log.Info("firmware update in progress", "taskState", t.TaskState, "firmwareProgress", task.Status.FirmwareProgress, "requeueAfter", firmwareUpdateRequeueAfter)
+
+	return ctrl.Result{RequeueAfter: firmwareUpdateRequeueAfter}, nil
+}
+
+// redfishTaskMessages joins the messages of t, or reports that the BMC returned none.
+func redfishTaskMessages(t redfishTask) string {
+	msgs := make([]string, 0, len(t.Messages))
+	for _, m := range t.Messages {
+		if m.Message != "" {
+			msgs = append(msgs, m.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return "the BMC returned no message"
+	}
+
+	return strings.Join(msgs, "; ")
+}
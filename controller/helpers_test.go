@@ -2,16 +2,40 @@ package controller_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 
 	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/bmc"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
 	"github.com/bmc-toolbox/bmclib/v2/providers"
+	"github.com/bmc-toolbox/common"
 	"github.com/go-logr/logr"
 	"github.com/jacobweinstock/registrar"
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 	"github.com/tinkerbell/rufio/controller"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // This source file is currently a bucket of stuff. If it grows too big, consider breaking it
@@ -39,12 +63,59 @@ type testProvider struct {
 	PowerSetOK            bool
 	BootdeviceOK          bool
 	VirtualMediaOK        bool
+	BIOSConfig            map[string]string
+	SEL                   [][]string
+	Device                *common.Device
 	ErrOpen               error
 	ErrClose              error
 	ErrPowerStateGet      error
 	ErrPowerStateSet      error
 	ErrBootDeviceSet      error
 	ErrVirtualMediaInsert error
+	ErrBIOSConfigGet      error
+	ErrBIOSConfigSet      error
+	ErrSELGet             error
+	ErrSELClear           error
+	ErrInventory          error
+
+	// BootOverride is returned by BootDeviceOverrideGet.
+	BootOverride       bmc.BootDeviceOverride
+	ErrBootOverrideGet error
+
+	// PowerSetState records the state argument of the last PowerSet call.
+	PowerSetState string
+	// PowerSetDelay delays PowerSet until it elapses or the context is done.
+	PowerSetDelay time.Duration
+	// PowerSetPanic makes PowerSet panic with it when it is not empty.
+	PowerSetPanic string
+
+	// SetPersistent records the setPersistent argument of the last BootDeviceSet call.
+	SetPersistent bool
+	// SetBootDevice records the bootDevice argument of the last BootDeviceSet call.
+	SetBootDevice string
+	// SetEFIBoot records the efiBoot argument of the last BootDeviceSet call.
+	SetEFIBoot bool
+
+	// UserUpdateOK is returned by UserUpdate, which records its arguments when it succeeds.
+	UserUpdateOK    bool
+	ErrUserUpdate   error
+	UpdatedUser     string
+	UpdatedPassword string
+	UpdatedRole     string
+
+	// BMCResetOK is returned by BmcReset, which records the reset type in BMCResetType.
+	BMCResetOK   bool
+	ErrBMCReset  error
+	BMCResetType string
+
+	// VirtualMediaDelay delays SetVirtualMedia until it elapses or the context is done.
+	VirtualMediaDelay time.Duration
+	// PowerStateGets counts the PowerStateGet calls.
+	PowerStateGets atomic.Int32
+	// Closes counts the Close calls.
+	Closes atomic.Int32
+	// PFeatures replaces the features of the provider when set.
+	PFeatures registrar.Features
 }
 
 func (t *testProvider) Name() string {
@@ -62,11 +133,20 @@ func (t *testProvider) Protocol() string {
 }
 
 func (t *testProvider) Features() registrar.Features {
+	if t.PFeatures != nil {
+		return t.PFeatures
+	}
 	return registrar.Features{
 		providers.FeaturePowerState,
 		providers.FeaturePowerSet,
 		providers.FeatureBootDeviceSet,
 		providers.FeatureVirtualMedia,
+		providers.FeatureGetBiosConfiguration,
+		providers.FeatureSetBiosConfiguration,
+		providers.FeatureGetSystemEventLog,
+		providers.FeatureClearSystemEventLog,
+		providers.FeatureInventoryRead,
+		providers.FeatureBmcReset,
 	}
 }
 
@@ -75,25 +155,89 @@ func (t *testProvider) Open(_ context.Context) error {
 }
 
 func (t *testProvider) Close(_ context.Context) error {
+	t.Closes.Add(1)
 	return t.ErrClose
 }
 
 func (t *testProvider) PowerStateGet(_ context.Context) (string, error) {
+	t.PowerStateGets.Add(1)
 	return t.Powerstate, t.ErrPowerStateGet
 }
 
-func (t *testProvider) PowerSet(_ context.Context, _ string) (ok bool, err error) {
+func (t *testProvider) PowerSet(ctx context.Context, state string) (ok bool, err error) {
+	if t.PowerSetPanic != "" {
+		panic(t.PowerSetPanic)
+	}
+	select {
+	case <-time.After(t.PowerSetDelay):
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	t.PowerSetState = state
 	return t.PowerSetOK, t.ErrPowerStateSet
 }
 
-func (t *testProvider) BootDeviceSet(_ context.Context, _ string, _, _ bool) (ok bool, err error) {
+func (t *testProvider) BootDeviceSet(_ context.Context, bootDevice string, setPersistent, efiBoot bool) (ok bool, err error) {
+	t.SetBootDevice = bootDevice
+	t.SetPersistent = setPersistent
+	t.SetEFIBoot = efiBoot
 	return t.BootdeviceOK, t.ErrBootDeviceSet
 }
 
-func (t *testProvider) SetVirtualMedia(_ context.Context, _ string, _ string) (ok bool, err error) {
+func (t *testProvider) BootDeviceOverrideGet(_ context.Context) (bmc.BootDeviceOverride, error) {
+	return t.BootOverride, t.ErrBootOverrideGet
+}
+
+func (t *testProvider) UserUpdate(_ context.Context, user, pass, role string) (bool, error) {
+	if t.ErrUserUpdate != nil || !t.UserUpdateOK {
+		return t.UserUpdateOK, t.ErrUserUpdate
+	}
+	t.UpdatedUser, t.UpdatedPassword, t.UpdatedRole = user, pass, role
+	return true, nil
+}
+
+func (t *testProvider) BmcReset(_ context.Context, resetType string) (bool, error) {
+	t.BMCResetType = resetType
+	return t.BMCResetOK, t.ErrBMCReset
+}
+
+func (t *testProvider) SetVirtualMedia(ctx context.Context, _ string, _ string) (ok bool, err error) {
+	select {
+	case <-time.After(t.VirtualMediaDelay):
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
 	return t.VirtualMediaOK, t.ErrVirtualMediaInsert
 }
 
+func (t *testProvider) GetBiosConfiguration(_ context.Context) (map[string]string, error) {
+	return t.BIOSConfig, t.ErrBIOSConfigGet
+}
+
+func (t *testProvider) SetBiosConfiguration(_ context.Context, _ map[string]string) error {
+	return t.ErrBIOSConfigSet
+}
+
+func (t *testProvider) SetBiosConfigurationFromFile(_ context.Context, _ string) error {
+	return t.ErrBIOSConfigSet
+}
+
+func (t *testProvider) GetSystemEventLog(_ context.Context) ([][]string, error) {
+	return t.SEL, t.ErrSELGet
+}
+
+func (t *testProvider) GetSystemEventLogRaw(_ context.Context) (string, error) {
+	return "", t.ErrSELGet
+}
+
+func (t *testProvider) ClearSystemEventLog(_ context.Context) error {
+	return t.ErrSELClear
+}
+
+func (t *testProvider) Inventory(_ context.Context) (*common.Device, error) {
+	return t.Device, t.ErrInventory
+}
+
 // newMockBMCClientFactoryFunc returns a new BMCClientFactoryFunc.
 func newTestClient(provider *testProvider) controller.ClientFunc {
 	return func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
@@ -105,3 +249,143 @@ func newTestClient(provider *testProvider) controller.ClientFunc {
 		return cl, cl.Open(ctx)
 	}
 }
+
+// createClientCertSecret returns a kubernetes.io/tls Secret with a self-signed client certificate.
+func createClientCertSecret(t *testing.T) *corev1.Secret {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rufio"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      "test-bm-client-cert",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+// fakeRedfish is a Redfish service that serves resources by path and records the requests that change them.
+type fakeRedfish struct {
+	server *httptest.Server
+	// resources are the bodies of the Redfish resources read with GET, by path.
+	resources map[string]string
+
+	mu sync.Mutex
+	// requests are the requests other than GET, formatted as "METHOD path body".
+	requests []string
+}
+
+// newFakeRedfish starts a fakeRedfish serving resources, which is closed when the test ends.
+func newFakeRedfish(t *testing.T, resources map[string]string) *fakeRedfish {
+	t.Helper()
+	f := &fakeRedfish{resources: resources}
+	f.server = httptest.NewTLSServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeRedfish) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodGet {
+		b, _ := io.ReadAll(r.Body)
+		f.requests = append(f.requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, b))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	body, ok := f.resources[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte(body))
+}
+
+// Requests returns the requests other than GET received so far.
+func (f *fakeRedfish) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.requests...)
+}
+
+// connect points the Connection of task to the Redfish service.
+func (f *fakeRedfish) connect(task *v1alpha1.Task) {
+	f.connectTo(&task.Spec.Connection)
+}
+
+// connectMachine points the Connection of machine to the Redfish service.
+func (f *fakeRedfish) connectMachine(machine *v1alpha1.Machine) {
+	f.connectTo(&machine.Spec.Connection)
+}
+
+func (f *fakeRedfish) connectTo(c *v1alpha1.Connection) {
+	host, port, _ := net.SplitHostPort(f.server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	c.Host = host
+	c.ProviderOptions.Redfish.Port = p
+	c.InsecureTLS = true
+}
+
+// redfishBootResources are the Redfish resources of a computer system with UEFI boot options for pxe, disk
+// and bios, and a legacy boot option for pxe.
+func redfishBootResources() map[string]string {
+	return map[string]string{
+		"/redfish/v1/Systems":                 `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1":               `{"Name":"System","Boot":{"BootOrder":["Boot0002","Boot0004","Boot0001","Boot0003"],"BootOptions":{"@odata.id":"/redfish/v1/Systems/1/BootOptions"}}}`,
+		"/redfish/v1/Systems/1/BootOptions":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1/BootOptions/1"},{"@odata.id":"/redfish/v1/Systems/1/BootOptions/2"},{"@odata.id":"/redfish/v1/Systems/1/BootOptions/3"},{"@odata.id":"/redfish/v1/Systems/1/BootOptions/4"}]}`,
+		"/redfish/v1/Systems/1/BootOptions/1": `{"BootOptionReference":"Boot0001","DisplayName":"UEFI PXE","Alias":"Pxe","UefiDevicePath":"PciRoot(0x0)/Pci(0x1C,0x0)/MAC(0025905B1234,0x1)"}`,
+		"/redfish/v1/Systems/1/BootOptions/2": `{"BootOptionReference":"Boot0002","DisplayName":"UEFI Disk","Alias":"Hdd","UefiDevicePath":"PciRoot(0x0)/Pci(0x17,0x0)/Sata(0x0,0xFFFF,0x0)"}`,
+		"/redfish/v1/Systems/1/BootOptions/3": `{"BootOptionReference":"Boot0003","DisplayName":"Setup","Alias":"BiosSetup","UefiDevicePath":"Fv(5C60F367-A505-419A-859E-2A4FF6CA6FE5)"}`,
+		"/redfish/v1/Systems/1/BootOptions/4": `{"BootOptionReference":"Boot0004","DisplayName":"Legacy PXE","Alias":"Pxe"}`,
+	}
+}
+
+// reconcileTask reconciles task, with its BMC secret, using provider. The first reconcile runs the action and the
+// second one checks its status and completes the Task; the second reconcile is skipped when the first one fails.
+// It returns the Task after the reconciles and the error of the last reconcile.
+func reconcileTask(t *testing.T, task *v1alpha1.Task, secret *corev1.Secret, provider *testProvider) (*v1alpha1.Task, error) {
+	t.Helper()
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	_, err := reconciler.Reconcile(context.Background(), request)
+	if err == nil {
+		_, err = reconciler.Reconcile(context.Background(), request)
+	}
+
+	retrieved := &v1alpha1.Task{}
+	if getErr := cluster.Get(context.Background(), request.NamespacedName, retrieved); getErr != nil {
+		t.Fatalf("expected nil err, got: %v", getErr)
+	}
+
+	return retrieved, err
+}
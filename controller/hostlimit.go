@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// hostBusyRequeueAfter is how long a reconcile waits before retrying when its BMC host is at the concurrency limit.
+const hostBusyRequeueAfter = 2 * time.Second
+
+// HostLimiter limits the number of concurrent operations against a single BMC host.
+// It is shared between reconcilers so that Machine and Task reconciles against the same host are limited together.
+// When a host is at the limit, its freed slots go to the waiting Tasks with the highest priority first.
+// A nil HostLimiter does not limit.
+type HostLimiter struct {
+	limit int
+
+	mu       sync.Mutex
+	inflight map[string]int
+	waiters  map[string]*priorityWaiters
+}
+
+// NewHostLimiter returns a HostLimiter that allows at most limit concurrent operations per host.
+func NewHostLimiter(limit int) *HostLimiter {
+	return &HostLimiter{
+		limit:    limit,
+		inflight: map[string]int{},
+		waiters:  map[string]*priorityWaiters{},
+	}
+}
+
+// TryAcquire reserves an operation slot for host. It returns false, without blocking,
+// when host is already at the limit. A successful TryAcquire must be followed by Release.
+func (l *HostLimiter) TryAcquire(host string) bool {
+	return l.TryAcquirePriority(host, "", 0)
+}
+
+// TryAcquirePriority reserves an operation slot for host for the Task key, namespace/name, with priority.
+// It returns false, without blocking, when host is already at the limit or a Task with a higher priority
+// is waiting for a slot of host. A successful TryAcquirePriority must be followed by Release.
+func (l *HostLimiter) TryAcquirePriority(host, key string, priority int32) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	waiters, ok := l.waiters[host]
+	if !ok {
+		waiters = newPriorityWaiters(3 * hostBusyRequeueAfter)
+	}
+	if l.inflight[host] >= l.limit || waiters.outranked(key, priority) {
+		waiters.wait(key, priority)
+		if !waiters.empty() {
+			l.waiters[host] = waiters
+		}
+		return false
+	}
+	waiters.admit(key)
+	if waiters.empty() {
+		delete(l.waiters, host)
+	}
+	l.inflight[host]++
+
+	return true
+}
+
+// Release frees an operation slot for host reserved by TryAcquire.
+func (l *HostLimiter) Release(host string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight[host]--
+	if l.inflight[host] <= 0 {
+		delete(l.inflight, host)
+	}
+}
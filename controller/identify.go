@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/go-logr/logr"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// identifyBlinkFallbackMessage is the Task condition message when the LED was turned on instead of blinking.
+const identifyBlinkFallbackMessage = "provider does not support blinking the identify LED, it was turned on instead"
+
+// redfishIndicatorLEDs maps the IdentifyState values to the values of the deprecated IndicatorLED property.
+var redfishIndicatorLEDs = map[v1alpha1.IdentifyState]string{
+	v1alpha1.IdentifyOn:    "Lit",
+	v1alpha1.IdentifyOff:   "Off",
+	v1alpha1.IdentifyBlink: "Blinking",
+}
+
+// setChassisIdentify sets the identify LED of the first Redfish chassis of the Machine that has one to state.
+// The LocationIndicatorActive property is preferred to turn the LED on and off, the deprecated IndicatorLED property
+// is used on older BMCs and to blink the LED. When the chassis can't blink the LED, it is turned on instead and
+// fellBack is true. IPMI-only providers don't support the identify LED, which is returned as an unsupported error.
+func setChassisIdentify(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, state v1alpha1.IdentifyState) (fellBack bool, err error) {
+	if err := requireRedfish(bmcClient, "identify LEDs"); err != nil {
+		return false, err
+	}
+
+	var chassis redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, chassisCollectionPath, &chassis); err != nil {
+		return false, err
+	}
+	for _, member := range chassis.Members {
+		var c redfishChassis
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &c); err != nil {
+			return false, err
+		}
+		if c.LocationIndicatorActive == nil && c.IndicatorLED == "" {
+			continue
+		}
+
+		if state == v1alpha1.IdentifyBlink && c.IndicatorLED == "" {
+			fellBack, state = true, v1alpha1.IdentifyOn
+		}
+		patch := map[string]any{"IndicatorLED": redfishIndicatorLEDs[state]}
+		if state != v1alpha1.IdentifyBlink && c.LocationIndicatorActive != nil {
+			patch = map[string]any{"LocationIndicatorActive": state == v1alpha1.IdentifyOn}
+		}
+
+		return fellBack, redfishSend(ctx, bmcClient, opts, http.MethodPatch, member.ID, patch)
+	}
+
+	return false, fmt.Errorf("no chassis of the BMC has an identify LED: %w", bmclibErrs.ErrProviderImplementation)
+}
+
+// checkIdentify waits for the DurationSeconds of the IdentifyAction of task to pass since the action started and then
+// turns the identify LED off, as Redfish has no duration for the identify LED. Without a duration, or when the action
+// turned the LED off, the Task is completed right away.
+func checkIdentify(ctx context.Context, log logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) (ctrl.Result, error) {
+	action := task.CurrentAction().IdentifyAction
+	if action.DurationSeconds == nil || action.State == v1alpha1.IdentifyOff {
+		return ctrl.Result{}, nil
+	}
+
+	duration := time.Duration(*action.DurationSeconds) * time.Second
+	if remaining := duration - time.Since(task.Status.StartTime.Time); remaining > 0 {
+		log.Info("requeuing task until the identify duration has passed", "requeueAfter", remaining)
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	if _, err := setChassisIdentify(ctx, bmcClient, opts, v1alpha1.IdentifyOff); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to turn the identify LED off after %s: %w", duration, err)
+	}
+	log.Info("identify LED turned off", "duration", duration)
+
+	return ctrl.Result{}, nil
+}
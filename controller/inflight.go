@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// inflightFullRequeueAfter is how long a Task waits before retrying when the controller is at the in-flight limit.
+const inflightFullRequeueAfter = 5 * time.Second
+
+// InflightLimiter limits the total number of Tasks executing BMC operations at a time, across all hosts.
+// It is coarser than the HostLimiter and protects the controller and network during large rollouts.
+// When the limit is reached, freed slots go to the waiting Tasks with the highest priority first.
+// A nil InflightLimiter does not limit.
+type InflightLimiter struct {
+	limit int
+
+	mu       sync.Mutex
+	inflight int
+	waiters  *priorityWaiters
+}
+
+// NewInflightLimiter returns an InflightLimiter that allows at most limit Tasks in flight.
+func NewInflightLimiter(limit int) *InflightLimiter {
+	return &InflightLimiter{limit: limit, waiters: newPriorityWaiters(3 * inflightFullRequeueAfter)}
+}
+
+// TryAcquire reserves an in-flight slot. It returns false, without blocking, when the limit is reached.
+// A successful TryAcquire must be followed by Release.
+func (l *InflightLimiter) TryAcquire() bool {
+	return l.TryAcquirePriority("", 0)
+}
+
+// TryAcquirePriority reserves an in-flight slot for the Task key, namespace/name, with priority. It returns
+// false, without blocking, when the limit is reached or a Task with a higher priority is waiting for a slot.
+// A successful TryAcquirePriority must be followed by Release.
+func (l *InflightLimiter) TryAcquirePriority(key string, priority int32) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= l.limit || l.waiters.outranked(key, priority) {
+		l.waiters.wait(key, priority)
+		return false
+	}
+	l.waiters.admit(key)
+	l.inflight++
+
+	return true
+}
+
+// Release frees an in-flight slot reserved by TryAcquire.
+func (l *InflightLimiter) Release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight > 0 {
+		l.inflight--
+	}
+}
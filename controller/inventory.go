@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// inventoryDue reports whether the hardware inventory of bm should be read, because it was never read
+// or the last read is older than interval. A zero interval disables reading the inventory.
+func inventoryDue(bm *v1alpha1.Machine, interval time.Duration) bool {
+	if interval <= 0 {
+		return false
+	}
+
+	return bm.Status.LastInventoryTime == nil || time.Since(bm.Status.LastInventoryTime.Time) >= interval
+}
+
+// updateInventory reads the manufacturer, model and serial number of the Machine and stores them in the
+// Machine status. Failing to read the inventory, for example because only IPMI is available, is not an
+// error of the Machine: the previous values are kept and the read is retried after the inventory interval.
+func (r *MachineReconciler) updateInventory(ctx context.Context, logger logr.Logger, bm *v1alpha1.Machine, bmcClient *bmclib.Client) {
+	now := metav1.Now()
+	bm.Status.LastInventoryTime = &now
+
+	device, err := bmcClient.Inventory(ctx)
+	if err != nil {
+		logger.Info("failed to read Machine inventory", "error", err.Error(), "unsupported", isUnsupported(err))
+		if !isUnsupported(err) {
+			r.recorder.Eventf(bm, corev1.EventTypeWarning, "GetInventoryFailed", "get inventory: %v", err)
+		}
+		return
+	}
+	if device == nil {
+		return
+	}
+
+	bm.Status.Manufacturer = device.Vendor
+	bm.Status.Model = device.Model
+	bm.Status.SerialNumber = device.Serial
+}
@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// jitter returns d extended by a random duration of up to fraction of d, so that reconciles requeued with
+// the same interval spread out instead of hitting the BMCs at the same time. A fraction of 0 disables it.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 || fraction <= 0 {
+		return d
+	}
+
+	return wait.Jitter(d, fraction)
+}
@@ -19,6 +19,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -53,13 +54,14 @@ func NewJobReconciler(c client.Client) *JobReconciler {
 // Reconcile runs a Job.
 // Creates the individual Tasks on the cluster.
 // Watches for Task and creates next Job Task based on conditions.
-func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	logger := ctrl.LoggerFrom(ctx).WithName("controllers/Job").WithValues("job", req.NamespacedName)
 	logger.Info("Reconciling Job")
+	defer recoverPanic(logger, &err)
 
 	// Fetch the job object
 	job := &v1alpha1.Job{}
-	err := r.client.Get(ctx, req.NamespacedName, job)
+	err = r.client.Get(ctx, req.NamespacedName, job)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return ctrl.Result{}, nil
@@ -80,9 +82,21 @@ func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, nil
 	}
 
+	// A suspended Job creates no Tasks until its Suspend is unset, which changes the Job and reconciles it again.
+	suspended, err := r.reconcileJobSuspend(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if suspended {
+		logger.Info("Job is suspended, skipping")
+		return ctrl.Result{}, nil
+	}
+
 	// Create a patch from the initial Job object
 	// Patch is used to update Status after reconciliation
 	jobPatch := client.MergeFrom(job.DeepCopy())
+	logger = logger.WithValues("machine", types.NamespacedName{Namespace: job.Spec.MachineRef.Namespace, Name: job.Spec.MachineRef.Name})
+	ctx = ctrl.LoggerInto(ctx, logger)
 
 	return r.doReconcile(ctx, job, jobPatch)
 }
@@ -91,7 +105,8 @@ func (r *JobReconciler) doReconcile(ctx context.Context, job *v1alpha1.Job, jobP
 	// Check if Job is not currently Running
 	// Initialize the StartTime for the Job
 	// Set the Job to Running condition True
-	if !job.HasCondition(v1alpha1.JobRunning, v1alpha1.ConditionTrue) {
+	wasRunning := job.HasCondition(v1alpha1.JobRunning, v1alpha1.ConditionTrue)
+	if !wasRunning {
 		now := metav1.Now()
 		job.Status.StartTime = &now
 		job.SetCondition(v1alpha1.JobRunning, v1alpha1.ConditionTrue)
@@ -112,20 +127,77 @@ func (r *JobReconciler) doReconcile(ctx context.Context, job *v1alpha1.Job, jobP
 		return ctrl.Result{}, fmt.Errorf("failed to list owned Tasks for Job %s/%s: %w", job.Namespace, job.Name, err)
 	}
 
-	completedTasksCount := 0
-	// Iterate Task Items.
-	// Count the number of completed tasks.
-	// Set the Job condition Failed True if Task has failed.
-	// If the Task has neither Completed or Failed is noop.
+	owned := make(map[string]v1alpha1.Task, len(tasks.Items))
 	for _, task := range tasks.Items {
+		owned[task.Name] = task
+	}
+
+	// Walk the Tasks in order, so a Task is only created once all previous Tasks have finished.
+	// Set the Job condition Failed True and skip the remaining Tasks if a Task has failed, unless ContinueOnError is set.
+	// If the current Task has neither Completed or Failed is noop.
+	// Finished Tasks are recorded in the status together with the counts, which are only recounted for Jobs
+	// started before the finished Tasks were recorded.
+	succeeded, failed := job.Status.SucceededTasks, job.Status.FailedTasks
+	if len(job.Status.FinishedTasks) == 0 {
+		succeeded, failed = 0, 0
+	}
+	recorded := false
+	for i := range job.Spec.Tasks {
+		// A finished Task is never created again, even when it no longer exists, for example because it was
+		// deleted after its TTL or because the cache is not yet synced after a controller restart.
+		if slices.Contains(job.Status.FinishedTasks, i) {
+			continue
+		}
+
+		task, ok := owned[v1alpha1.FormatTaskName(*job, i)]
+		if !ok {
+			job.Status.CurrentTaskIndex = i
+			job.Status.SucceededTasks, job.Status.FailedTasks = succeeded, failed
+			// Create the next Task for the Job. Secret references without a namespace are to Secrets in the
+			// namespace of the Machine, which may differ from the namespace of the Job.
+			if err := r.createTaskWithOwner(ctx, *job, i, withSecretNamespace(machine.Spec.Connection, machine.Namespace)); err != nil {
+				// Set the Job condition Failed True
+				job.SetCondition(v1alpha1.JobFailed, v1alpha1.ConditionTrue, v1alpha1.WithJobConditionMessage(err.Error()))
+				job.Status.SkippedTasks = remainingTasks(job, i)
+				patchErr := r.patchStatus(ctx, job, jobPatch)
+				if patchErr != nil {
+					return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
+				}
+
+				return ctrl.Result{}, err
+			}
+
+			// Patch the status at the end of reconcile loop
+			err = r.patchStatus(ctx, job, jobPatch)
+			return ctrl.Result{}, err
+		}
+
 		if task.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
-			completedTasksCount++
+			succeeded++
+			job.Status.FinishedTasks = append(job.Status.FinishedTasks, i)
+			recordDryRunResult(job, i, &task, true)
+			recorded = true
 			continue
 		}
 
-		if task.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+		// A Task whose action no provider supports fails the Job like a Task that failed.
+		if task.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) || task.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue) {
+			failed++
+			job.Status.FinishedTasks = append(job.Status.FinishedTasks, i)
+			recordDryRunResult(job, i, &task, false)
+			recorded = true
+			// A dry run checks all the tasks, so that every infeasible task is reported.
+			if job.Spec.ContinueOnError || job.Spec.DryRun {
+				continue
+			}
+			job.Status.CurrentTaskIndex = i
+			job.Status.SucceededTasks, job.Status.FailedTasks = succeeded, failed
 			err := fmt.Errorf("task %s/%s failed", task.Namespace, task.Name)
+			if task.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue) {
+				err = fmt.Errorf("task %s/%s is unsupported", task.Namespace, task.Name)
+			}
 			job.SetCondition(v1alpha1.JobFailed, v1alpha1.ConditionTrue, v1alpha1.WithJobConditionMessage(err.Error()))
+			job.Status.SkippedTasks = remainingTasks(job, i)
 			patchErr := r.patchStatus(ctx, job, jobPatch)
 			if patchErr != nil {
 				return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
@@ -134,35 +206,60 @@ func (r *JobReconciler) doReconcile(ctx context.Context, job *v1alpha1.Job, jobP
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, nil
+		// The Task is still running, the status only changes when the Job was not seen running it before.
+		if wasRunning && !recorded && job.Status.CurrentTaskIndex == i {
+			return ctrl.Result{}, nil
+		}
+		job.Status.CurrentTaskIndex = i
+		job.Status.SucceededTasks, job.Status.FailedTasks = succeeded, failed
+		err = r.patchStatus(ctx, job, jobPatch)
+		return ctrl.Result{}, err
 	}
 
-	// Check if all Job tasks have Completed
+	// All Job tasks have finished
 	// Set the Task CompletionTime
 	// Set Task Condition Completed True
-	if completedTasksCount == len(job.Spec.Tasks) {
-		job.SetCondition(v1alpha1.JobCompleted, v1alpha1.ConditionTrue)
-		now := metav1.Now()
-		job.Status.CompletionTime = &now
-		err = r.patchStatus(ctx, job, jobPatch)
-		return ctrl.Result{}, err
+	job.Status.SucceededTasks, job.Status.FailedTasks = succeeded, failed
+	var opts []v1alpha1.JobSetConditionOption
+	switch {
+	case job.Spec.DryRun:
+		opts = append(opts, v1alpha1.WithJobConditionMessage(fmt.Sprintf("dry run: %d of %d tasks feasible, %d infeasible", succeeded, len(job.Spec.Tasks), failed)))
+	case failed > 0:
+		opts = append(opts, v1alpha1.WithJobConditionMessage(fmt.Sprintf("%d of %d tasks succeeded, %d failed", succeeded, len(job.Spec.Tasks), failed)))
 	}
+	job.SetCondition(v1alpha1.JobCompleted, v1alpha1.ConditionTrue, opts...)
+	now := metav1.Now()
+	job.Status.CompletionTime = &now
+	err = r.patchStatus(ctx, job, jobPatch)
+	return ctrl.Result{}, err
+}
 
-	// Create the first Task for the Job
-	if err := r.createTaskWithOwner(ctx, *job, completedTasksCount, machine.Spec.Connection); err != nil {
-		// Set the Job condition Failed True
-		job.SetCondition(v1alpha1.JobFailed, v1alpha1.ConditionTrue, v1alpha1.WithJobConditionMessage(err.Error()))
-		patchErr := r.patchStatus(ctx, job, jobPatch)
-		if patchErr != nil {
-			return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
+// recordDryRunResult records in the status of a Job with DryRun set whether its finished Task at index i, which
+// completed when feasible is true, is feasible, with the message of the condition the Task finished with.
+func recordDryRunResult(job *v1alpha1.Job, i int, task *v1alpha1.Task, feasible bool) {
+	if !job.Spec.DryRun {
+		return
+	}
+	result := v1alpha1.DryRunResult{TaskIndex: i, Feasible: feasible}
+	for _, c := range task.Status.Conditions {
+		if c.Status != v1alpha1.ConditionTrue {
+			continue
 		}
+		if feasible && c.Type == v1alpha1.TaskCompleted || !feasible && (c.Type == v1alpha1.TaskFailed || c.Type == v1alpha1.TaskUnsupported) {
+			result.Message = c.Message
+		}
+	}
+	job.Status.DryRunResults = append(job.Status.DryRunResults, result)
+}
 
-		return ctrl.Result{}, err
+// remainingTasks returns the indexes of the Tasks of job after the Task at index i.
+func remainingTasks(job *v1alpha1.Job, i int) []int {
+	var remaining []int
+	for j := i + 1; j < len(job.Spec.Tasks); j++ {
+		remaining = append(remaining, j)
 	}
 
-	// Patch the status at the end of reconcile loop
-	err = r.patchStatus(ctx, job, jobPatch)
-	return ctrl.Result{}, err
+	return remaining
 }
 
 // getMachine Gets the Machine from MachineRef.
@@ -182,10 +279,38 @@ func (r *JobReconciler) getMachine(ctx context.Context, reference v1alpha1.Machi
 // createTaskWithOwner creates a Task object with an OwnerReference set to the Job.
 func (r *JobReconciler) createTaskWithOwner(ctx context.Context, job v1alpha1.Job, taskIndex int, conn v1alpha1.Connection) error {
 	isController := true
+	// The Task webhook requires the same confirmation annotations as the Job for a ClearSELAction,
+	// SetBMCCredentialsAction, RedfishActionPassthroughAction or SetBMCNetworkAction.
+	var annotations map[string]string
+	for _, key := range []string{v1alpha1.ConfirmClearSELAnnotation, v1alpha1.ConfirmSetBMCCredentialsAnnotation, v1alpha1.ConfirmRedfishPassthroughAnnotation, v1alpha1.ConfirmSetBMCNetworkAnnotation} {
+		if v, ok := job.Annotations[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = v
+		}
+	}
+	var labels map[string]string
+	for _, key := range job.Spec.PropagateLabels {
+		if v, ok := job.Labels[key]; ok {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[key] = v
+		}
+		if v, ok := job.Annotations[key]; ok {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = v
+		}
+	}
 	task := &v1alpha1.Task{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      v1alpha1.FormatTaskName(job, taskIndex),
-			Namespace: job.Namespace,
+			Name:        v1alpha1.FormatTaskName(job, taskIndex),
+			Namespace:   job.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: job.APIVersion,
@@ -197,8 +322,11 @@ func (r *JobReconciler) createTaskWithOwner(ctx context.Context, job v1alpha1.Jo
 			},
 		},
 		Spec: v1alpha1.TaskSpec{
-			Task:       job.Spec.Tasks[taskIndex],
-			Connection: conn,
+			Task:            job.Spec.Tasks[taskIndex],
+			Connection:      conn,
+			IdempotentPower: job.Spec.IdempotentPower,
+			Priority:        job.Spec.Priority,
+			DryRun:          job.Spec.DryRun,
 		},
 	}
 
@@ -2,14 +2,18 @@ package controller_test
 
 import (
 	"context"
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 	"github.com/tinkerbell/rufio/controller"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -20,6 +24,9 @@ func TestJobReconcile(t *testing.T) {
 		job       *v1alpha1.Job
 		shouldErr bool
 		testAll   bool
+		// wantLabels and wantAnnotations are the labels and annotations expected on the created Task.
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
 	}{
 		"success taskless job": {
 			machine: createMachine(),
@@ -37,6 +44,40 @@ func TestJobReconcile(t *testing.T) {
 			job:     createJob("test", createMachine(), getAction("PowerOn")),
 			testAll: true,
 		},
+		"success idempotent power on job": {
+			machine: createMachine(),
+			secret:  createSecret(),
+			job: func() *v1alpha1.Job {
+				job := createJob("test", createMachine(), getAction("PowerOn"))
+				job.Spec.IdempotentPower = true
+				return job
+			}(),
+			testAll: true,
+		},
+		"success machine secret without namespace": {
+			machine: func() *v1alpha1.Machine {
+				machine := createMachine()
+				machine.Spec.Connection.AuthSecretRef.Namespace = ""
+				return machine
+			}(),
+			secret:  createSecret(),
+			job:     createJob("test", createMachine(), getAction("PowerOn")),
+			testAll: true,
+		},
+		"success propagated labels job": {
+			machine: createMachine(),
+			secret:  createSecret(),
+			job: func() *v1alpha1.Job {
+				job := createJob("test", createMachine(), getAction("PowerOn"))
+				job.Labels = map[string]string{"workflow": "reimage-batch-42", "team": "infra"}
+				job.Annotations = map[string]string{"example.com/ticket": "OPS-1", "example.com/owner": "infra"}
+				job.Spec.PropagateLabels = []string{"workflow", "example.com/ticket", "missing"}
+				return job
+			}(),
+			testAll:         true,
+			wantLabels:      map[string]string{"workflow": "reimage-batch-42"},
+			wantAnnotations: map[string]string{"example.com/ticket": "OPS-1"},
+		},
 	}
 
 	for name, tt := range tests {
@@ -95,6 +136,19 @@ func TestJobReconcile(t *testing.T) {
 			if diff := cmp.Diff(task.Spec.Task, tt.job.Spec.Tasks[0]); diff != "" {
 				t.Fatalf("expected task %v, got %v", tt.job.Spec.Tasks[0], task.Spec.Task)
 			}
+			if task.Spec.IdempotentPower != tt.job.Spec.IdempotentPower {
+				t.Fatalf("expected IdempotentPower %v, got %v", tt.job.Spec.IdempotentPower, task.Spec.IdempotentPower)
+			}
+			// Secret references without a namespace are to the namespace of the Machine, not of the Job.
+			if diff := cmp.Diff("test-namespace", task.Spec.Connection.AuthSecretRef.Namespace); diff != "" {
+				t.Fatalf("unexpected auth secret namespace: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantLabels, task.Labels); diff != "" {
+				t.Fatalf("unexpected task labels: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantAnnotations, task.Annotations); diff != "" {
+				t.Fatalf("unexpected task annotations: %v", diff)
+			}
 			if len(task.OwnerReferences) != 1 {
 				t.Fatalf("expected 1 owner reference, got %v", len(task.OwnerReferences))
 			}
@@ -145,3 +199,294 @@ func createJob(name string, machine *v1alpha1.Machine, t ...v1alpha1.Action) *v1
 		},
 	}
 }
+
+func TestJobReconcileSequential(t *testing.T) {
+	completed := []v1alpha1.TaskCondition{{Type: v1alpha1.TaskCompleted, Status: v1alpha1.ConditionTrue}}
+	failed := []v1alpha1.TaskCondition{{Type: v1alpha1.TaskFailed, Status: v1alpha1.ConditionTrue}}
+	unsupported := []v1alpha1.TaskCondition{{Type: v1alpha1.TaskUnsupported, Status: v1alpha1.ConditionTrue}}
+	tests := map[string]struct {
+		// existing are the conditions of the Tasks that already exist, by index.
+		existing [][]v1alpha1.TaskCondition
+		// deleted are the indexes of the existing Tasks that no longer exist.
+		deleted []int
+		// status is the status of the Job before the reconcile.
+		status           v1alpha1.JobStatus
+		continueOnError  bool
+		shouldErr        bool
+		wantCreated      []int
+		wantNotCreated   []int
+		wantCurrentIndex int
+		wantSkipped      []int
+		wantFailed       bool
+		wantCompleted    bool
+		wantSucceeded    int
+		wantFailedTasks  int
+		wantFinished     []int
+	}{
+		"next task is created after previous completed": {
+			existing:         [][]v1alpha1.TaskCondition{completed},
+			wantCreated:      []int{1},
+			wantNotCreated:   []int{2},
+			wantCurrentIndex: 1,
+			wantSucceeded:    1,
+			wantFinished:     []int{0},
+		},
+		"next task waits for running task": {
+			existing:         [][]v1alpha1.TaskCondition{completed, nil},
+			wantNotCreated:   []int{2},
+			wantCurrentIndex: 1,
+			wantSucceeded:    1,
+			wantFinished:     []int{0},
+		},
+		"finished task that was deleted is not created again": {
+			existing:         [][]v1alpha1.TaskCondition{completed, completed},
+			deleted:          []int{0},
+			status:           v1alpha1.JobStatus{CurrentTaskIndex: 1, SucceededTasks: 1, FinishedTasks: []int{0}},
+			wantCreated:      []int{2},
+			wantNotCreated:   []int{0},
+			wantCurrentIndex: 2,
+			wantSucceeded:    2,
+			wantFinished:     []int{0, 1},
+		},
+		"finished tasks are not run again after a restart": {
+			status: v1alpha1.JobStatus{
+				Conditions:       []v1alpha1.JobCondition{{Type: v1alpha1.JobRunning, Status: v1alpha1.ConditionTrue}},
+				CurrentTaskIndex: 1,
+				SucceededTasks:   1,
+				FailedTasks:      1,
+				FinishedTasks:    []int{0, 1},
+			},
+			continueOnError:  true,
+			wantCreated:      []int{2},
+			wantNotCreated:   []int{0, 1},
+			wantCurrentIndex: 2,
+			wantSucceeded:    1,
+			wantFailedTasks:  1,
+			wantFinished:     []int{0, 1},
+		},
+		"failed task skips remaining tasks": {
+			existing:         [][]v1alpha1.TaskCondition{completed, failed},
+			shouldErr:        true,
+			wantNotCreated:   []int{2},
+			wantCurrentIndex: 1,
+			wantSkipped:      []int{2},
+			wantFailed:       true,
+			wantSucceeded:    1,
+			wantFailedTasks:  1,
+			wantFinished:     []int{0, 1},
+		},
+		"unsupported task fails the job": {
+			existing:         [][]v1alpha1.TaskCondition{completed, unsupported},
+			shouldErr:        true,
+			wantNotCreated:   []int{2},
+			wantCurrentIndex: 1,
+			wantSkipped:      []int{2},
+			wantFailed:       true,
+			wantSucceeded:    1,
+			wantFailedTasks:  1,
+			wantFinished:     []int{0, 1},
+		},
+		"failed task continues with continue on error": {
+			existing:         [][]v1alpha1.TaskCondition{completed, failed},
+			continueOnError:  true,
+			wantCreated:      []int{2},
+			wantCurrentIndex: 2,
+			wantSucceeded:    1,
+			wantFailedTasks:  1,
+			wantFinished:     []int{0, 1},
+		},
+		"job completes after failed task with continue on error": {
+			existing:        [][]v1alpha1.TaskCondition{failed, completed, completed},
+			continueOnError: true,
+			wantCompleted:   true,
+			wantSucceeded:   2,
+			wantFailedTasks: 1,
+			wantFinished:    []int{0, 1, 2},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			machine := createMachine()
+			job := createJob("test", machine, getAction("BootPXE"), getAction("PowerOn"), getAction("PowerStatus"))
+			job.Spec.ContinueOnError = tt.continueOnError
+			job.Status = tt.status
+			objs := []client.Object{job, machine, createSecret()}
+			for i, conditions := range tt.existing {
+				if slices.Contains(tt.deleted, i) {
+					continue
+				}
+				task := &v1alpha1.Task{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      v1alpha1.FormatTaskName(*job, i),
+						Namespace: job.Namespace,
+						OwnerReferences: []metav1.OwnerReference{{
+							APIVersion: job.APIVersion,
+							Kind:       job.Kind,
+							Name:       job.Name,
+							Controller: ptr.To(true),
+						}},
+					},
+					Spec:   v1alpha1.TaskSpec{Task: job.Spec.Tasks[i]},
+					Status: v1alpha1.TaskStatus{Conditions: conditions},
+				}
+				objs = append(objs, task)
+			}
+			clnt := newClientBuilder().
+				WithObjects(objs...).
+				WithStatusSubresource(job, machine).
+				WithIndex(&v1alpha1.Task{}, ".metadata.controller", controller.TaskOwnerIndexFunc).
+				Build()
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: job.Namespace, Name: job.Name}}
+			_, err := controller.NewJobReconciler(clnt).Reconcile(context.Background(), request)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("expected err %v, got: %v", tt.shouldErr, err)
+			}
+
+			for _, i := range tt.wantCreated {
+				var task v1alpha1.Task
+				key := types.NamespacedName{Namespace: job.Namespace, Name: v1alpha1.FormatTaskName(*job, i)}
+				if err := clnt.Get(context.Background(), key, &task); err != nil {
+					t.Fatalf("expected task %d to be created, got: %v", i, err)
+				}
+			}
+			for _, i := range tt.wantNotCreated {
+				var task v1alpha1.Task
+				key := types.NamespacedName{Namespace: job.Namespace, Name: v1alpha1.FormatTaskName(*job, i)}
+				if err := clnt.Get(context.Background(), key, &task); !apierrors.IsNotFound(err) {
+					t.Fatalf("expected task %d not to be created, got: %v", i, err)
+				}
+			}
+
+			var retrieved v1alpha1.Job
+			if err := clnt.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCurrentIndex, retrieved.Status.CurrentTaskIndex); diff != "" {
+				t.Fatalf("unexpected current task index: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantSkipped, retrieved.Status.SkippedTasks); diff != "" {
+				t.Fatalf("unexpected skipped tasks: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed, retrieved.HasCondition(v1alpha1.JobFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected job failed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.JobCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected job completed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantSucceeded, retrieved.Status.SucceededTasks); diff != "" {
+				t.Fatalf("unexpected succeeded tasks: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailedTasks, retrieved.Status.FailedTasks); diff != "" {
+				t.Fatalf("unexpected failed tasks: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFinished, retrieved.Status.FinishedTasks); diff != "" {
+				t.Fatalf("unexpected finished tasks: %v", diff)
+			}
+		})
+	}
+}
+
+func TestJobReconcileDryRun(t *testing.T) {
+	machine := createMachine()
+	job := createJob("test", machine, getAction("BootPXE"), getAction("PowerOn"))
+	job.Spec.DryRun = true
+	clnt := newClientBuilder().
+		WithObjects(job, machine, createSecret()).
+		WithStatusSubresource(job, machine).
+		WithIndex(&v1alpha1.Task{}, ".metadata.controller", controller.TaskOwnerIndexFunc).
+		Build()
+	reconciler := controller.NewJobReconciler(clnt)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: job.Namespace, Name: job.Name}}
+
+	// finishTask creates the Task at index i, if the Job did not, and sets its finished condition.
+	finishTask := func(i int, condition v1alpha1.TaskCondition) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var task v1alpha1.Task
+		key := types.NamespacedName{Namespace: job.Namespace, Name: v1alpha1.FormatTaskName(*job, i)}
+		if err := clnt.Get(context.Background(), key, &task); err != nil {
+			t.Fatalf("expected task %d to be created, got: %v", i, err)
+		}
+		if !task.Spec.DryRun {
+			t.Fatalf("expected task %d to be a dry run", i)
+		}
+		task.Status.Conditions = []v1alpha1.TaskCondition{condition}
+		if err := clnt.Update(context.Background(), &task); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	finishTask(0, v1alpha1.TaskCondition{Type: v1alpha1.TaskFailed, Status: v1alpha1.ConditionTrue, Message: "dry run: boot device not supported"})
+	finishTask(1, v1alpha1.TaskCondition{Type: v1alpha1.TaskCompleted, Status: v1alpha1.ConditionTrue, Message: "dry run: connection verified"})
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var retrieved v1alpha1.Job
+	if err := clnt.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !retrieved.HasCondition(v1alpha1.JobCompleted, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the dry run Job to be completed, got: %v", retrieved.Status.Conditions)
+	}
+	want := []v1alpha1.DryRunResult{
+		{TaskIndex: 0, Feasible: false, Message: "dry run: boot device not supported"},
+		{TaskIndex: 1, Feasible: true, Message: "dry run: connection verified"},
+	}
+	if diff := cmp.Diff(want, retrieved.Status.DryRunResults); diff != "" {
+		t.Fatalf("unexpected dry run results: %v", diff)
+	}
+}
+
+func TestJobReconcileSuspend(t *testing.T) {
+	machine := createMachine()
+	job := createJob("test", machine, getAction("PowerOn"))
+	job.Spec.Suspend = true
+	clnt := newClientBuilder().
+		WithObjects(job, machine, createSecret()).
+		WithStatusSubresource(job, machine).
+		WithIndex(&v1alpha1.Task{}, ".metadata.controller", controller.TaskOwnerIndexFunc).
+		Build()
+	reconciler := controller.NewJobReconciler(clnt)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: job.Namespace, Name: job.Name}}
+
+	// reconcileJob reconciles the Job and returns it with the number of its Tasks.
+	reconcileJob := func() (*v1alpha1.Job, int) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		retrieved := &v1alpha1.Job{}
+		if err := clnt.Get(context.Background(), request.NamespacedName, retrieved); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var tasks v1alpha1.TaskList
+		if err := clnt.List(context.Background(), &tasks); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return retrieved, len(tasks.Items)
+	}
+
+	retrieved, tasks := reconcileJob()
+	if !retrieved.HasCondition(v1alpha1.JobSuspended, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the Job to be Suspended, got: %v", retrieved.Status.Conditions)
+	}
+	if tasks != 0 {
+		t.Fatalf("expected the suspended Job to create no Task, got %d", tasks)
+	}
+
+	retrieved.Spec.Suspend = false
+	if err := clnt.Update(context.Background(), retrieved); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	retrieved, tasks = reconcileJob()
+	if !retrieved.HasCondition(v1alpha1.JobSuspended, v1alpha1.ConditionFalse) {
+		t.Fatalf("expected the Job to be resumed, got: %v", retrieved.Status.Conditions)
+	}
+	if tasks != 1 {
+		t.Fatalf("expected the resumed Job to create its Task, got %d Tasks", tasks)
+	}
+}
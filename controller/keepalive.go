@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// longRunningAction reports whether a can keep the BMC busy for minutes, long enough for an idle BMC session
+// to time out while it runs.
+func longRunningAction(a v1alpha1.Action) bool {
+	return a.VirtualMediaAction != nil || a.SetBIOSConfigAction != nil || a.GetFirmwareInventoryAction != nil || a.UpdateFirmwareAction != nil
+}
+
+// startSessionKeepalive reads the power state of bmcClient every interval until the returned func is called,
+// so that the BMC session is not closed for being idle during a long running action. Failed reads are only
+// logged. The returned func stops the keepalive and waits for a read in flight to return.
+func startSessionKeepalive(ctx context.Context, logger logr.Logger, bmcClient *bmclib.Client, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := bmcClient.GetPowerState(ctx); err != nil && ctx.Err() == nil {
+					logger.Info("BMC session keepalive failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -2,6 +2,9 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strings"
 
@@ -12,23 +15,37 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// resolveAuthSecretRef Gets the Secret from the SecretReference.
-// Returns the username and password encoded in the Secret.
-func resolveAuthSecretRef(ctx context.Context, c client.Client, secretRef v1.SecretReference) (string, string, error) {
+// secretKey returns the key of the Secret referenced by secretRef. A reference without a namespace is to a
+// Secret in namespace, the namespace of the object holding the reference.
+func secretKey(secretRef v1.SecretReference, namespace string) types.NamespacedName {
+	if secretRef.Namespace != "" {
+		namespace = secretRef.Namespace
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: secretRef.Name}
+}
+
+// resolveAuthSecretRef Gets the Secret from the SecretReference, defaulting its namespace to namespace.
+// Returns the username and password encoded in the Secret. When username is set, it is returned instead
+// of the username of the Secret, which then only needs a password.
+func resolveAuthSecretRef(ctx context.Context, c client.Reader, secretRef v1.SecretReference, username, namespace string) (string, string, error) {
 	secret := &v1.Secret{}
-	key := types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}
+	key := secretKey(secretRef, namespace)
 
 	if err := c.Get(ctx, key, secret); err != nil {
 		if apierrors.IsNotFound(err) {
 			return "", "", fmt.Errorf("secret %s not found: %w", key, err)
 		}
 
-		return "", "", fmt.Errorf("failed to retrieve secret %s : %w", secretRef, err)
+		return "", "", fmt.Errorf("failed to retrieve secret %s : %w", key, err)
 	}
 
-	username, ok := secret.Data["username"]
-	if !ok {
-		return "", "", fmt.Errorf("'username' required in Machine secret")
+	if username == "" {
+		u, ok := secret.Data["username"]
+		if !ok {
+			return "", "", fmt.Errorf("'username' required in Machine secret")
+		}
+		username = string(u)
 	}
 
 	password, ok := secret.Data["password"]
@@ -36,9 +53,122 @@ func resolveAuthSecretRef(ctx context.Context, c client.Client, secretRef v1.Sec
 		return "", "", fmt.Errorf("'password' required in Machine secret")
 	}
 
-	return string(username), string(password), nil
+	return username, string(password), nil
+}
+
+// resolveClientCertificate Gets the kubernetes.io/tls Secret from the SecretReference, defaulting its
+// namespace to namespace. Returns the client certificate and key encoded in the Secret.
+func resolveClientCertificate(ctx context.Context, c client.Reader, secretRef v1.SecretReference, namespace string) (*tls.Certificate, error) {
+	secret := &v1.Secret{}
+	key := secretKey(secretRef, namespace)
+
+	if err := c.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("client certificate secret %s not found: %w", key, err)
+		}
+
+		return nil, fmt.Errorf("failed to retrieve client certificate secret %s : %w", key, err)
+	}
+
+	certPEM, ok := secret.Data[v1.TLSCertKey]
+	if !ok {
+		return nil, fmt.Errorf("'%s' required in client certificate secret %s", v1.TLSCertKey, key)
+	}
+	keyPEM, ok := secret.Data[v1.TLSPrivateKeyKey]
+	if !ok {
+		return nil, fmt.Errorf("'%s' required in client certificate secret %s", v1.TLSPrivateKeyKey, key)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate in secret %s: %w", key, err)
+	}
+
+	return &cert, nil
+}
+
+// resolveCACertificates Gets the Secret or ConfigMap from the CACertReference, defaulting its namespace to
+// namespace. Returns the CA certificates of the PEM encoded bundle under its key.
+func resolveCACertificates(ctx context.Context, c client.Reader, ref v1alpha1.CACertReference, namespace string) ([]*x509.Certificate, error) {
+	key := secretKey(v1.SecretReference{Name: ref.Name, Namespace: ref.Namespace}, namespace)
+	kind := ref.CAKind()
+
+	bundle, ok, err := readCABundle(ctx, c, kind, key, ref.CAKey())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("CA certificate %s %s not found: %w", kind, key, err)
+		}
+
+		return nil, fmt.Errorf("failed to retrieve CA certificate %s %s : %w", kind, key, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("'%s' required in CA certificate %s %s", ref.CAKey(), kind, key)
+	}
+
+	certs, err := parseCertificates(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate in %s %s: %w", kind, key, err)
+	}
+
+	return certs, nil
+}
+
+// readCABundle returns the data under dataKey of the Secret or ConfigMap key, and whether it has the data key.
+func readCABundle(ctx context.Context, c client.Reader, kind v1alpha1.CACertKind, key types.NamespacedName, dataKey string) ([]byte, bool, error) {
+	if kind == v1alpha1.CACertKindConfigMap {
+		cm := &v1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			return nil, false, err
+		}
+		if data, ok := cm.Data[dataKey]; ok {
+			return []byte(data), true, nil
+		}
+		data, ok := cm.BinaryData[dataKey]
+
+		return data, ok, nil
+	}
+
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, false, err
+	}
+	data, ok := secret.Data[dataKey]
+
+	return data, ok, nil
+}
+
+// parseCertificates returns the certificates of a PEM encoded bundle. Blocks other than certificates are skipped.
+func parseCertificates(bundle []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	return certs, nil
 }
 
+const (
+	// insecureTLSEventReason is the reason of the Event recorded when connecting to a BMC without TLS verification.
+	insecureTLSEventReason = "InsecureTLS"
+	// insecureTLSEventMessage is the message of the insecureTLSEventReason Event. It takes the BMC host.
+	insecureTLSEventMessage = "TLS certificate verification is disabled for BMC %s"
+)
+
 // toPowerState takes a raw BMC power state response and converts it to a v1alpha1.PowerState.
 func toPowerState(state string) v1alpha1.PowerState {
 	// Normalize the response string for comparison.
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// bmcHostAnnotation is the annotation of a BMC Lease with the host it serializes operations against,
+// as the Lease name is a hash of the host.
+const bmcHostAnnotation = "rufio.tinkerbell.org/bmc-host"
+
+// errBMCLeaseLost is the cause of the cancellation of the BMC operations of a reconcile whose replica lost the
+// Lease of the BMC host to another replica.
+var errBMCLeaseLost = errors.New("the BMC Lease was taken over by another controller replica")
+
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
+
+// BMCLeaser serializes the operations against a BMC host across controller replicas, which the HostLimiter
+// doesn't. Before a reconcile operates on a BMC, it takes the coordination.k8s.io Lease of the host, which is
+// renewed while it is held and released when the reconcile is done. A Lease held by another replica that was not
+// renewed within its duration is taken over, the replica that lost it cancels the BMC operations started with
+// Context. Reconciles of the same replica share the Lease, the HostLimiter limits them. A nil BMCLeaser does not
+// take Leases.
+type BMCLeaser struct {
+	client client.Client
+	// reader reads Leases without the informer cache.
+	reader        client.Reader
+	namespace     string
+	identity      string
+	duration      time.Duration
+	renewInterval time.Duration
+	log           logr.Logger
+
+	mu    sync.Mutex
+	holds map[string]*leaseHold
+}
+
+// leaseHold is a Lease held by the reconciles of this replica.
+type leaseHold struct {
+	// count is the number of reconciles holding the Lease.
+	count int
+	// stop is closed to release the Lease, done is closed once it was released.
+	stop chan struct{}
+	done chan struct{}
+	// lost is cancelled with errBMCLeaseLost once another replica took the Lease over.
+	lost context.Context
+	lose context.CancelCauseFunc
+}
+
+// NewBMCLeaser returns a BMCLeaser that holds Leases in namespace as identity, which must be unique per replica.
+// The Leases last for duration and are renewed every renewInterval, which must be shorter than duration.
+func NewBMCLeaser(c client.Client, reader client.Reader, namespace, identity string, duration, renewInterval time.Duration, log logr.Logger) *BMCLeaser {
+	return &BMCLeaser{
+		client:        c,
+		reader:        reader,
+		namespace:     namespace,
+		identity:      identity,
+		duration:      duration,
+		renewInterval: renewInterval,
+		log:           log,
+		holds:         map[string]*leaseHold{},
+	}
+}
+
+// TryAcquire takes the Lease of host. It returns false, without blocking, when another replica holds the Lease.
+// A successful TryAcquire must be followed by Release.
+func (l *BMCLeaser) TryAcquire(ctx context.Context, host string) (bool, error) {
+	if l == nil {
+		return true, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.holds[host]; ok {
+		// A lost Lease is held by another replica until the reconciles of this replica released it.
+		if h.lost.Err() != nil {
+			return false, nil
+		}
+		h.count++
+		return true, nil
+	}
+	lease, err := l.take(ctx, host)
+	if err != nil || lease == nil {
+		return false, err
+	}
+	h := &leaseHold{count: 1, stop: make(chan struct{}), done: make(chan struct{})}
+	h.lost, h.lose = context.WithCancelCause(context.Background())
+	l.holds[host] = h
+	go l.renew(host, lease, h)
+
+	return true, nil
+}
+
+// Release releases the Lease of host taken by TryAcquire, once no reconcile of this replica holds it anymore.
+func (l *BMCLeaser) Release(host string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	h, ok := l.holds[host]
+	if !ok {
+		l.mu.Unlock()
+		return
+	}
+	h.count--
+	if h.count > 0 {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.holds, host)
+	l.mu.Unlock()
+
+	close(h.stop)
+	<-h.done
+}
+
+// Context returns a context derived from ctx that is cancelled, with errBMCLeaseLost as its cause, when the Lease of
+// host taken by TryAcquire is lost to another replica. The returned func must be called once the operations
+// with the context have finished.
+func (l *BMCLeaser) Context(ctx context.Context, host string) (context.Context, context.CancelFunc) {
+	if l == nil {
+		return ctx, func() {}
+	}
+	l.mu.Lock()
+	h, ok := l.holds[host]
+	l.mu.Unlock()
+	if !ok {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(h.lost, func() { cancel(context.Cause(h.lost)) })
+
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// bmcLeaseLost reports whether ctx, returned by Context, was cancelled because the Lease of the BMC host was lost.
+func bmcLeaseLost(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errBMCLeaseLost)
+}
+
+// take creates the Lease of host, or takes it over when it is released or expired. It returns nil when
+// another replica holds the Lease, or took it first.
+func (l *BMCLeaser) take(ctx context.Context, host string) (*coordinationv1.Lease, error) {
+	key := types.NamespacedName{Namespace: l.namespace, Name: leaseName(host)}
+	lease := &coordinationv1.Lease{}
+	err := l.reader.Get(ctx, key, lease)
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+			Name:        key.Name,
+			Namespace:   key.Namespace,
+			Annotations: map[string]string{bmcHostAnnotation: host},
+		}}
+		l.hold(lease)
+		if err := l.client.Create(ctx, lease); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to create Lease %s: %w", key, err)
+		}
+		return lease, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Lease %s: %w", key, err)
+	}
+	if heldByOther(lease, l.identity, time.Now()) {
+		return nil, nil
+	}
+
+	l.hold(lease)
+	if err := l.client.Update(ctx, lease); err != nil {
+		if apierrors.IsConflict(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update Lease %s: %w", key, err)
+	}
+
+	return lease, nil
+}
+
+// hold makes lease held by the identity of l from now on.
+func (l *BMCLeaser) hold(lease *coordinationv1.Lease) {
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.HolderIdentity = ptr.To(l.identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32((l.duration + time.Second - 1) / time.Second))
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+}
+
+// renew renews lease every renewInterval until h is stopped, and then releases it. When the Lease can't be
+// renewed, it expires after its duration and another replica may take it over. Once another replica took it over,
+// h is lost and the Lease is no longer renewed nor released.
+func (l *BMCLeaser) renew(host string, lease *coordinationv1.Lease, h *leaseHold) {
+	defer close(h.done)
+	defer h.lose(context.Canceled)
+	ticker := time.NewTicker(l.renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if h.lost.Err() != nil {
+				continue
+			}
+			now := metav1.NewMicroTime(time.Now())
+			lease.Spec.RenewTime = &now
+			ctx, cancel := context.WithTimeout(context.Background(), l.renewInterval)
+			if err := l.client.Update(ctx, lease); err != nil {
+				l.log.Error(err, "failed to renew BMC Lease", "host", host, "lease", lease.Name)
+				current, held := l.reacquire(ctx, lease)
+				if !held {
+					l.log.Info("BMC Lease was taken over by another controller replica", "host", host, "lease", lease.Name)
+					h.lose(errBMCLeaseLost)
+				}
+				lease = current
+			}
+			cancel()
+		case <-h.stop:
+			if h.lost.Err() != nil {
+				return
+			}
+			lease.Spec.HolderIdentity = nil
+			ctx, cancel := context.WithTimeout(context.Background(), l.renewInterval)
+			if err := l.client.Update(ctx, lease); err != nil && !apierrors.IsNotFound(err) {
+				l.log.Error(err, "failed to release BMC Lease, it expires after its duration", "host", host, "lease", lease.Name)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// reacquire reads lease again after a failed renewal, which may have left it with a stale resourceVersion, and
+// renews the current Lease when it is still held by l. It returns false when the Lease was deleted or is held by
+// another replica. When the Lease can't be read, lease is returned to be renewed again at the next interval.
+func (l *BMCLeaser) reacquire(ctx context.Context, lease *coordinationv1.Lease) (*coordinationv1.Lease, bool) {
+	current := &coordinationv1.Lease{}
+	if err := l.reader.Get(ctx, client.ObjectKeyFromObject(lease), current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return lease, false
+		}
+		l.log.Error(err, "failed to read BMC Lease", "lease", lease.Name)
+		return lease, true
+	}
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != l.identity {
+		return current, false
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	current.Spec.RenewTime = &now
+	if err := l.client.Update(ctx, current); err != nil {
+		l.log.Error(err, "failed to renew BMC Lease", "lease", lease.Name)
+	}
+
+	return current, true
+}
+
+// heldByOther reports whether lease is held by a holder other than identity and was renewed within its duration.
+func heldByOther(lease *coordinationv1.Lease, identity string, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" || *spec.HolderIdentity == identity {
+		return false
+	}
+	if spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return false
+	}
+
+	return now.Before(spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second))
+}
+
+// leaseName returns the name of the Lease of host. Hosts, like IPv6 addresses, aren't valid object names,
+// so the name is derived from a hash of the host.
+func leaseName(host string) string {
+	return fmt.Sprintf("rufio-bmc-%x", sha256.Sum256([]byte(host)))[:len("rufio-bmc-")+16]
+}
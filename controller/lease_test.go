@@ -0,0 +1,192 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tinkerbell/rufio/controller"
+)
+
+func TestBMCLeaser(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	newLeaser := func(identity string) *controller.BMCLeaser {
+		return controller.NewBMCLeaser(c, c, "rufio-system", identity, 30*time.Second, time.Hour, logr.Discard())
+	}
+	ctx := context.Background()
+	a, b := newLeaser("a"), newLeaser("b")
+
+	tryAcquire := func(l *controller.BMCLeaser, want bool) {
+		t.Helper()
+		got, err := l.TryAcquire(ctx, "fd00::10")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if got != want {
+			t.Fatalf("expected TryAcquire to return %v, got %v", want, got)
+		}
+	}
+
+	tryAcquire(a, true)
+	// Reconciles of the same replica share the Lease.
+	tryAcquire(a, true)
+	tryAcquire(b, false)
+	a.Release("fd00::10")
+	tryAcquire(b, false)
+	a.Release("fd00::10")
+	tryAcquire(b, true)
+	tryAcquire(a, false)
+	b.Release("fd00::10")
+
+	var leases coordinationv1.LeaseList
+	if err := c.List(ctx, &leases); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(leases.Items) != 1 {
+		t.Fatalf("expected 1 Lease, got %d", len(leases.Items))
+	}
+	if holder := leases.Items[0].Spec.HolderIdentity; holder != nil {
+		t.Fatalf("expected the Lease to be released, got holder %q", *holder)
+	}
+}
+
+func TestBMCLeaserExpired(t *testing.T) {
+	tests := map[string]struct {
+		renewed time.Time
+		want    bool
+	}{
+		"expired lease is taken over":   {renewed: time.Now().Add(-time.Minute), want: true},
+		"lease held by another replica": {renewed: time.Now(), want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := coordinationv1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			// Take the Lease once, to create it with its name, and hand it over to another replica.
+			leaser := controller.NewBMCLeaser(c, c, "rufio-system", "a", 30*time.Second, time.Hour, logr.Discard())
+			if ok, err := leaser.TryAcquire(context.Background(), "10.0.0.1"); !ok || err != nil {
+				t.Fatalf("expected the Lease to be acquired, got %v, %v", ok, err)
+			}
+			leaser.Release("10.0.0.1")
+			var leases coordinationv1.LeaseList
+			if err := c.List(context.Background(), &leases); err != nil || len(leases.Items) != 1 {
+				t.Fatalf("expected 1 Lease, got %v, %v", leases.Items, err)
+			}
+			lease := &leases.Items[0]
+			renewed := metav1.NewMicroTime(tt.renewed)
+			lease.Spec.HolderIdentity = ptr.To("b")
+			lease.Spec.RenewTime = &renewed
+			if err := c.Update(context.Background(), lease); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			got, err := leaser.TryAcquire(context.Background(), "10.0.0.1")
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected TryAcquire to return %v, got %v", tt.want, got)
+			}
+			if got {
+				leaser.Release("10.0.0.1")
+			}
+		})
+	}
+}
+
+func TestBMCLeaserLost(t *testing.T) {
+	tests := map[string]struct {
+		// holder is the holder of the Lease once another client changed it.
+		holder   string
+		wantLost bool
+	}{
+		"renewed after a conflict": {holder: "a"},
+		"taken over":               {holder: "b", wantLost: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := coordinationv1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			leaser := controller.NewBMCLeaser(c, c, "rufio-system", "a", 30*time.Second, 10*time.Millisecond, logr.Discard())
+			if ok, err := leaser.TryAcquire(context.Background(), "10.0.0.1"); !ok || err != nil {
+				t.Fatalf("expected the Lease to be acquired, got %v, %v", ok, err)
+			}
+			ctx, cancel := leaser.Context(context.Background(), "10.0.0.1")
+			defer cancel()
+
+			// Another client updates the Lease, so that the next renewal conflicts.
+			var leases coordinationv1.LeaseList
+			if err := c.List(context.Background(), &leases); err != nil || len(leases.Items) != 1 {
+				t.Fatalf("expected 1 Lease, got %v, %v", leases.Items, err)
+			}
+			lease := &leases.Items[0]
+			changed := metav1.NewMicroTime(time.Now())
+			err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := c.Get(context.Background(), client.ObjectKeyFromObject(lease), lease); err != nil {
+					return err
+				}
+				lease.Spec.HolderIdentity = ptr.To(tt.holder)
+				lease.Spec.RenewTime = &changed
+				return c.Update(context.Background(), lease)
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			// renewed reports whether the Lease was renewed by a since it was changed.
+			renewed := func() bool {
+				got := &coordinationv1.Lease{}
+				if err := c.Get(context.Background(), client.ObjectKeyFromObject(lease), got); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				return *got.Spec.HolderIdentity == "a" && got.Spec.RenewTime.After(changed.Time)
+			}
+			deadline := time.Now().Add(time.Second)
+			for ctx.Err() == nil && !renewed() && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			if lost := ctx.Err() != nil; lost != tt.wantLost {
+				t.Fatalf("expected the Lease to be lost to be %v, got context error %v", tt.wantLost, context.Cause(ctx))
+			}
+			if tt.wantLost {
+				if ok, err := leaser.TryAcquire(context.Background(), "10.0.0.1"); ok || err != nil {
+					t.Fatalf("expected the lost Lease not to be acquired, got %v, %v", ok, err)
+				}
+			}
+			leaser.Release("10.0.0.1")
+
+			got := &coordinationv1.Lease{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(lease), got); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if tt.wantLost && (got.Spec.HolderIdentity == nil || *got.Spec.HolderIdentity != "b") {
+				t.Fatalf("expected the Lease to be kept by b, got holder %v", got.Spec.HolderIdentity)
+			}
+			if !tt.wantLost && got.Spec.HolderIdentity != nil {
+				t.Fatalf("expected the Lease to be released, got holder %q", *got.Spec.HolderIdentity)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// connectionLogValues returns the logger key/value pairs identifying the BMC of c. Credentials are only
+// logged by reference, the username and password are never logged.
+func connectionLogValues(c v1alpha1.Connection) []any {
+	kv := []any{"host", c.Host}
+	if c.Port != 0 {
+		kv = append(kv, "port", c.Port)
+	}
+	if c.AuthSecretRef.Name != "" {
+		kv = append(kv, "authSecretRef", types.NamespacedName{Namespace: c.AuthSecretRef.Namespace, Name: c.AuthSecretRef.Name}.String())
+	}
+	if c.AuthProviderRef != nil {
+		kv = append(kv, "authProvider", c.AuthProviderRef.Name)
+	}
+
+	return kv
+}
+
+// withProviders returns logger with the providers the BMC connection was opened with, so that every
+// log line of the actions run with the connection names them.
+func withProviders(logger logr.Logger, providers []string) logr.Logger {
+	return logger.WithValues("providers", providers)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+)
+
+// LoginLimiter limits the rate of new BMC sessions across the controller with a token bucket. It protects
+// authentication services shared by many BMCs, like LDAP, during large rollouts, which the HostLimiter doesn't.
+// Cached connections that are reused don't log in again and are not limited. A nil LoginLimiter does not limit.
+type LoginLimiter struct {
+	limiter *rate.Limiter
+}
+
+// NewLoginLimiter returns a LoginLimiter that allows perSecond new BMC sessions per second on average,
+// with bursts of up to burst sessions.
+func NewLoginLimiter(perSecond float64, burst int) *LoginLimiter {
+	return &LoginLimiter{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)}
+}
+
+// Wrap returns a ClientFunc that opens connections with open while the rate limit allows a new session.
+// Otherwise it returns an error, without connecting to the BMC, from which loginRetryAfter tells when
+// to try again.
+func (l *LoginLimiter) Wrap(open ClientFunc) ClientFunc {
+	if l == nil {
+		return open
+	}
+
+	return func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *BMCOptions) (*bmclib.Client, error) {
+		r := l.limiter.Reserve()
+		if delay := r.Delay(); delay > 0 {
+			// Give the token back, the reconcile is requeued instead of waiting for it.
+			r.Cancel()
+			loginRateLimited.Inc()
+			return nil, &loginRateLimitedError{retryAfter: delay}
+		}
+
+		return open(ctx, log, hostIP, username, password, opts)
+	}
+}
+
+// loginRateLimitedError is returned instead of opening a connection when the BMC login rate limit is reached.
+type loginRateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *loginRateLimitedError) Error() string {
+	return fmt.Sprintf("BMC login rate limit reached, retrying in %s", e.retryAfter.Round(time.Millisecond))
+}
+
+// loginRetryAfter returns how long to wait before opening a connection again when err is returned because
+// the BMC login rate limit was reached.
+func loginRetryAfter(err error) (time.Duration, bool) {
+	var limited *loginRateLimitedError
+	if !errors.As(err, &limited) {
+		return 0, false
+	}
+
+	return limited.retryAfter, true
+}
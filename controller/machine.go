@@ -26,11 +26,12 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 )
@@ -40,6 +41,24 @@ type MachineReconciler struct {
 	client    client.Client
 	recorder  record.EventRecorder
 	bmcClient ClientFunc
+	// clientCache, when set, is used to reuse open BMC connections instead of opening one per reconcile.
+	clientCache *ClientCache
+	// hostLimiter, when set, limits the concurrent reconciles against a single BMC host.
+	hostLimiter *HostLimiter
+	// bmcLeaser, when set, serializes the reconciles against a single BMC host across controller replicas.
+	bmcLeaser *BMCLeaser
+	// credentialProviders are the credential providers that Connection.AuthProviderRef can reference, by name.
+	credentialProviders map[string]CredentialProvider
+	// inventoryInterval, when set, is how often the manufacturer, model and serial number of a Machine are read.
+	inventoryInterval time.Duration
+	// capabilitiesInterval, when set, is how often the providers and capabilities of a Machine are detected.
+	capabilitiesInterval time.Duration
+	// powerCheckInterval, when set, is how often the power state of a Machine without a PowerCheckInterval is polled.
+	powerCheckInterval time.Duration
+	// requeueJitter is the fraction of the requeue interval that is added at random to spread out reconciles.
+	requeueJitter float64
+	// httpConfig configures the HTTP requests to the BMCs.
+	httpConfig HTTPClientConfig
 }
 
 const (
@@ -57,16 +76,79 @@ func NewMachineReconciler(c client.Client, recorder record.EventRecorder, bmcCli
 	}
 }
 
+// WithClientCache makes the reconciler reuse open BMC connections from cache. Machines get the MachineFinalizer,
+// so that the cached connections to the BMC of a deleted Machine are closed.
+func (r *MachineReconciler) WithClientCache(cache *ClientCache) *MachineReconciler {
+	r.clientCache = cache
+	return r
+}
+
+// WithCredentialProviders makes the reconciler resolve a Connection.AuthProviderRef with providers, by name.
+func (r *MachineReconciler) WithCredentialProviders(providers map[string]CredentialProvider) *MachineReconciler {
+	r.credentialProviders = providers
+	return r
+}
+
+// WithInventoryInterval makes the reconciler read the manufacturer, model and serial number of a Machine
+// every interval. A zero interval disables reading them.
+func (r *MachineReconciler) WithInventoryInterval(interval time.Duration) *MachineReconciler {
+	r.inventoryInterval = interval
+	return r
+}
+
+// WithCapabilitiesInterval makes the reconciler detect the providers and capabilities of a Machine on first
+// contact and again every interval. A zero interval disables detecting them.
+func (r *MachineReconciler) WithCapabilitiesInterval(interval time.Duration) *MachineReconciler {
+	r.capabilitiesInterval = interval
+	return r
+}
+
+// WithPowerCheckInterval makes the reconciler poll the power state of a Machine without a PowerCheckInterval
+// every interval instead of every 3 minutes.
+func (r *MachineReconciler) WithPowerCheckInterval(interval time.Duration) *MachineReconciler {
+	r.powerCheckInterval = interval
+	return r
+}
+
+// WithRequeueJitter makes the reconciler add up to fraction of the requeue interval at random to every requeue,
+// so that Machines polled with the same interval don't all reconcile at the same time.
+func (r *MachineReconciler) WithRequeueJitter(fraction float64) *MachineReconciler {
+	r.requeueJitter = fraction
+	return r
+}
+
+// WithHostLimiter makes the reconciler requeue a Machine while its BMC host is at the concurrency limit of limiter.
+func (r *MachineReconciler) WithHostLimiter(limiter *HostLimiter) *MachineReconciler {
+	r.hostLimiter = limiter
+	return r
+}
+
+// WithBMCLeaser makes the reconciler requeue a Machine while another controller replica holds the Lease of its BMC host.
+func (r *MachineReconciler) WithBMCLeaser(leaser *BMCLeaser) *MachineReconciler {
+	r.bmcLeaser = leaser
+	return r
+}
+
+// WithHTTPClientConfig makes the reconciler send the HTTP requests to BMCs with config, for example with its
+// User-Agent and request timeout.
+func (r *MachineReconciler) WithHTTPClientConfig(config HTTPClientConfig) *MachineReconciler {
+	r.httpConfig = config
+	return r
+}
+
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=machines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=machines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=machines/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets;,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
-// Reconcile reports on the state of a Machine. It does not change the state of the Machine in any way.
+// Reconcile reports on the state of a Machine. It only changes the power state of the Machine
+// when EnforcePowerState is set and the observed power state drifts from DesiredPowerState.
 // Updates the Power status and conditions accordingly.
-func (r *MachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := ctrl.LoggerFrom(ctx).WithName("controllers/Machine")
+func (r *MachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := ctrl.LoggerFrom(ctx).WithName("controllers/Machine").WithValues("machine", req.NamespacedName)
 	logger.Info("reconciling machine")
+	defer recoverPanic(logger, &err)
 
 	// Fetch the Machine object
 	machine := &v1alpha1.Machine{}
@@ -79,14 +161,47 @@ func (r *MachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
-	// Deletion is a noop.
+	// The cached connections to the BMC of a deleted Machine are closed, so that the sessions of decommissioned
+	// hardware don't linger until their TTL expires.
 	if !machine.DeletionTimestamp.IsZero() {
-		return ctrl.Result{}, nil
+		if !controllerutil.ContainsFinalizer(machine, v1alpha1.MachineFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		if r.clientCache != nil {
+			logger.Info("closing cached BMC connections of deleted machine", "host", machine.Spec.Connection.Host)
+			r.clientCache.Evict(ctx, machine.Spec.Connection.Host)
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, machine)
+	}
+	if r.clientCache != nil {
+		if err := r.addFinalizer(ctx, machine); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	// Create a patch from the initial Machine object
 	// Patch is used to update Status after reconciliation
 	machinePatch := client.MergeFrom(machine.DeepCopy())
+	logger = logger.WithValues(connectionLogValues(machine.Spec.Connection)...)
+	ctx = ctrl.LoggerInto(ctx, logger)
+
+	// Only a limited number of operations may run against a single BMC at a time.
+	if !r.hostLimiter.TryAcquire(machine.Spec.Connection.Host) {
+		logger.Info("BMC host is at the concurrency limit, requeueing")
+		observeRequeue(requeueReasonConcurrencyLimited)
+		return ctrl.Result{RequeueAfter: jitter(hostBusyRequeueAfter, r.requeueJitter)}, nil
+	}
+	defer r.hostLimiter.Release(machine.Spec.Connection.Host)
+
+	// Across controller replicas, only the one holding the Lease of the BMC host operates on it.
+	if ok, err := r.bmcLeaser.TryAcquire(ctx, machine.Spec.Connection.Host); err != nil {
+		return ctrl.Result{}, err
+	} else if !ok {
+		logger.Info("BMC host is leased by another controller replica, requeueing")
+		observeRequeue(requeueReasonConcurrencyLimited)
+		return ctrl.Result{RequeueAfter: jitter(hostBusyRequeueAfter, r.requeueJitter)}, nil
+	}
+	defer r.bmcLeaser.Release(machine.Spec.Connection.Host)
 
 	return r.doReconcile(ctx, machine, machinePatch, logger)
 }
@@ -95,61 +210,104 @@ func (r *MachineReconciler) doReconcile(ctx context.Context, bm *v1alpha1.Machin
 	var username, password string
 	opts := &BMCOptions{
 		ProviderOptions: bm.Spec.Connection.ProviderOptions,
+		insecureTLS:     bm.Spec.Connection.InsecureTLS,
+		port:            bm.Spec.Connection.Port,
+		httpConfig:      r.httpConfig,
+	}
+	if bm.Spec.Connection.IPMIOptions != nil {
+		opts.ipmiCipherSuite = bm.Spec.Connection.IPMIOptions.CipherSuite
+	}
+	proxyURL, err := parseProxyURL(bm.Spec.Connection.ProxyURL)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("resolving Machine %s/%s proxy URL: %w", bm.Namespace, bm.Name, err)
+	}
+	opts.proxyURL = proxyURL
+	if bm.Spec.Connection.ClientCertSecretRef != nil {
+		cert, err := resolveClientCertificate(ctx, r.client, *bm.Spec.Connection.ClientCertSecretRef, bm.Namespace)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("resolving Machine %s/%s client certificate: %w", bm.Namespace, bm.Name, err)
+		}
+		opts.clientCert = cert
+	}
+	if bm.Spec.Connection.CACertSecretRef != nil {
+		certs, err := resolveCACertificates(ctx, r.client, *bm.Spec.Connection.CACertSecretRef, bm.Namespace)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("resolving Machine %s/%s CA certificate: %w", bm.Namespace, bm.Name, err)
+		}
+		opts.caCerts = certs
 	}
 	if bm.Spec.Connection.ProviderOptions != nil && bm.Spec.Connection.ProviderOptions.RPC != nil {
 		opts.ProviderOptions = bm.Spec.Connection.ProviderOptions
 		if len(bm.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets) > 0 {
-			se, err := retrieveHMACSecrets(ctx, r.client, bm.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets)
+			se, err := retrieveHMACSecrets(ctx, r.client, bm.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets, bm.Namespace)
 			if err != nil {
 				return ctrl.Result{}, fmt.Errorf("unable to get hmac secrets: %w", err)
 			}
 			opts.rpcSecrets = se
 		}
 	} else {
-		// Fetching username, password from SecretReference
-		// Requeue if error fetching secret
+		// Fetching username, password from the SecretReference or credential provider
+		// Requeue if error fetching credentials
 		var err error
-		username, password, err = resolveAuthSecretRef(ctx, r.client, bm.Spec.Connection.AuthSecretRef)
+		username, password, err = resolveCredentials(ctx, r.client, r.credentialProviders, bm.Spec.Connection, bm.Namespace)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("resolving Machine %s/%s SecretReference: %w", bm.Namespace, bm.Name, err)
+			return ctrl.Result{}, fmt.Errorf("resolving Machine %s/%s credentials: %w", bm.Namespace, bm.Name, err)
 		}
 	}
 
+	if bm.Spec.Connection.InsecureTLS {
+		r.recorder.Eventf(bm, corev1.EventTypeWarning, insecureTLSEventReason, insecureTLSEventMessage, bm.Spec.Connection.Host)
+	}
+
 	// Initializing BMC Client and Open the connection.
-	bmcClient, err := r.bmcClient(ctx, logger, bm.Spec.Connection.Host, username, password, opts)
+	bmcClient, err := openClient(ctx, logger, r.clientCache, r.bmcClient, bm.Spec.Connection.Host, username, password, opts)
+	if retryAfter, ok := loginRetryAfter(err); ok {
+		logger.Info("BMC login rate limit reached, requeueing", "requeueAfter", retryAfter)
+		observeRequeue(requeueReasonRateLimited)
+		return ctrl.Result{RequeueAfter: jitter(retryAfter, r.requeueJitter)}, nil
+	}
 	if err != nil {
-		logger.Error(err, "BMC connection failed", "host", bm.Spec.Connection.Host)
-		bm.SetCondition(v1alpha1.Contactable, v1alpha1.ConditionFalse, v1alpha1.WithMachineConditionMessage(err.Error()))
+		logger.Error(err, "BMC connection failed")
+		bm.SetCondition(v1alpha1.Contactable, v1alpha1.ConditionFalse, v1alpha1.WithMachineConditionMessage(err.Error()+cipherSuiteHint(err, bm.Spec.Connection)))
 		bm.Status.Power = v1alpha1.Unknown
 		if patchErr := r.patchStatus(ctx, bm, bmPatch); patchErr != nil {
 			return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
 		}
 
 		// requeue as bmc connections can be transient.
-		return ctrl.Result{RequeueAfter: machineRequeueInterval}, nil
+		observeRequeue(requeueReasonTransientError)
+		return ctrl.Result{RequeueAfter: jitter(r.requeueInterval(bm), r.requeueJitter)}, nil
 	}
 
 	// Close BMC connection after reconciliation
+	// bmcErr is the error of using the BMC connection, a cached connection is discarded on authentication errors.
+	var bmcErr error
 	defer func() {
-		if err := bmcClient.Close(ctx); err != nil {
-			md := bmcClient.GetMetadata()
-			logger.Error(err, "BMC close connection failed", "host", bm.Spec.Connection.Host, "providersAttempted", md.ProvidersAttempted)
-
-			return
-		}
-		md := bmcClient.GetMetadata()
-		logger.Info("BMC connection closed", "host", bm.Spec.Connection.Host, "successfulCloseConns", md.SuccessfulCloseConns, "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+		releaseClient(ctx, logger, r.clientCache, bmcClient, bmcErr)
 	}()
+	logger = withProviders(logger, bmcClient.GetMetadata().SuccessfulOpenConns)
+	ctx = ctrl.LoggerInto(ctx, logger)
 
 	contactable := v1alpha1.ConditionTrue
 	conditionMsg := v1alpha1.WithMachineConditionMessage("")
 	multiErr := []error{}
 	pErr := r.updatePowerState(ctx, bm, bmcClient)
 	if pErr != nil {
-		logger.Error(pErr, "failed to get Machine power state", "host", bm.Spec.Connection.Host)
+		bmcErr = pErr
+		logger.Error(pErr, "failed to get Machine power state")
 		contactable = v1alpha1.ConditionFalse
 		conditionMsg = v1alpha1.WithMachineConditionMessage(pErr.Error())
 		multiErr = append(multiErr, pErr)
+	} else if err := r.enforcePowerState(ctx, logger, bm, bmcClient); err != nil {
+		bmcErr = err
+		logger.Error(err, "failed to enforce Machine power state")
+		multiErr = append(multiErr, err)
+	}
+	if pErr == nil && inventoryDue(bm, r.inventoryInterval) {
+		r.updateInventory(ctx, logger, bm, bmcClient)
+	}
+	if pErr == nil && !capabilitiesFresh(bm, r.capabilitiesInterval) {
+		updateCapabilities(ctx, logger, bm, bmcClient, opts)
 	}
 
 	// Set condition.
@@ -161,7 +319,7 @@ func (r *MachineReconciler) doReconcile(ctx context.Context, bm *v1alpha1.Machin
 		return ctrl.Result{}, utilerrors.NewAggregate(multiErr)
 	}
 
-	return ctrl.Result{RequeueAfter: machineRequeueInterval}, nil
+	return ctrl.Result{RequeueAfter: jitter(r.requeueInterval(bm), r.requeueJitter)}, nil
 }
 
 // updatePowerState gets the current power state of the machine.
@@ -174,10 +332,45 @@ func (r *MachineReconciler) updatePowerState(ctx context.Context, bm *v1alpha1.M
 	}
 
 	bm.Status.Power = toPowerState(rawState)
+	now := metav1.Now()
+	bm.Status.LastPowerStateUpdate = &now
 
 	return nil
 }
 
+// enforcePowerState sets the power state of the Machine to DesiredPowerState when EnforcePowerState is set
+// and the observed power state differs.
+func (r *MachineReconciler) enforcePowerState(ctx context.Context, logger logr.Logger, bm *v1alpha1.Machine, bmcClient *bmclib.Client) error {
+	desired := bm.Spec.DesiredPowerState
+	if !bm.Spec.EnforcePowerState || desired == "" || bm.Status.Power == desired {
+		return nil
+	}
+
+	logger.Info("enforcing Machine power state", "observedPowerState", bm.Status.Power, "desiredPowerState", desired)
+	if _, err := bmcClient.SetPowerState(ctx, string(desired)); err != nil {
+		r.recorder.Eventf(bm, corev1.EventTypeWarning, "EnforcePowerStateFailed", "set power state %s: %v", desired, err)
+		return fmt.Errorf("set power state %s: %w", desired, err)
+	}
+
+	now := metav1.Now()
+	bm.Status.LastEnforcementTime = &now
+	r.recorder.Eventf(bm, corev1.EventTypeNormal, "PowerStateEnforced", "power state drifted from %s to %s, set it to %s", desired, bm.Status.Power, desired)
+
+	return nil
+}
+
+// requeueInterval returns how often the power state of bm is polled.
+func (r *MachineReconciler) requeueInterval(bm *v1alpha1.Machine) time.Duration {
+	if bm.Spec.PowerCheckInterval != nil && bm.Spec.PowerCheckInterval.Duration > 0 {
+		return bm.Spec.PowerCheckInterval.Duration
+	}
+	if r.powerCheckInterval > 0 {
+		return r.powerCheckInterval
+	}
+
+	return machineRequeueInterval
+}
+
 // patchStatus patches the specifies patch on the Machine.
 func (r *MachineReconciler) patchStatus(ctx context.Context, bm *v1alpha1.Machine, patch client.Patch) error {
 	if err := r.client.Status().Patch(ctx, bm, patch); err != nil {
@@ -187,19 +380,21 @@ func (r *MachineReconciler) patchStatus(ctx context.Context, bm *v1alpha1.Machin
 	return nil
 }
 
-func retrieveHMACSecrets(ctx context.Context, c client.Client, hmacSecrets v1alpha1.HMACSecrets) (rpc.Secrets, error) {
+// retrieveHMACSecrets returns the "secret" keys of the Secrets in hmacSecrets by algorithm. References
+// without a namespace are to Secrets in namespace.
+func retrieveHMACSecrets(ctx context.Context, c client.Client, hmacSecrets v1alpha1.HMACSecrets, namespace string) (rpc.Secrets, error) {
 	sec := rpc.Secrets{}
 	for k, v := range hmacSecrets {
 		for _, s := range v {
 			secret := &corev1.Secret{}
-			key := types.NamespacedName{Namespace: s.Namespace, Name: s.Name}
+			key := secretKey(s, namespace)
 
 			if err := c.Get(ctx, key, secret); err != nil {
 				if apierrors.IsNotFound(err) {
 					return nil, fmt.Errorf("secret %s not found: %w", key, err)
 				}
 
-				return nil, fmt.Errorf("failed to retrieve secret %s : %w", s, err)
+				return nil, fmt.Errorf("failed to retrieve secret %s : %w", key, err)
 			}
 
 			sec[rpc.Algorithm(k)] = append(sec[rpc.Algorithm(k)], string(secret.Data["secret"]))
@@ -210,8 +405,19 @@ func retrieveHMACSecrets(ctx context.Context, c client.Client, hmacSecrets v1alp
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *MachineReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&v1alpha1.Machine{},
+		connectionSecretKey,
+		MachineSecretIndexFunc,
+	); err != nil {
+		return err
+	}
+
+	// Machines are requeued when a Secret referenced by their Connection changes, for example rotated credentials.
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Machine{}).
+		Watches(&corev1.Secret{}, enqueueMachinesForSecret(r.client)).
 		Complete(r)
 }
@@ -3,12 +3,21 @@ package controller_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/bmc-toolbox/common"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/tinkerbell/rufio/api/v1alpha1"
@@ -148,6 +157,345 @@ func TestMachineReconcile(t *testing.T) {
 	}
 }
 
+func TestMachineReconcileInsecureTLSEvent(t *testing.T) {
+	tests := map[string]struct {
+		insecureTLS bool
+		wantEvent   bool
+	}{
+		"insecure tls records a warning event": {insecureTLS: true, wantEvent: true},
+		"secure tls records no event":          {insecureTLS: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bm := createMachine()
+			bm.Spec.Connection.InsecureTLS = tt.insecureTLS
+			client := newClientBuilder().
+				WithObjects(bm, createSecret()).
+				WithStatusSubresource(bm).
+				Build()
+
+			fakeRecorder := record.NewFakeRecorder(2)
+			reconciler := controller.NewMachineReconciler(client, fakeRecorder, newTestClient(&testProvider{Powerstate: "on"}))
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+			if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			select {
+			case e := <-fakeRecorder.Events:
+				if !tt.wantEvent {
+					t.Fatalf("expected no event, got %q", e)
+				}
+				if !strings.HasPrefix(e, corev1.EventTypeWarning+" InsecureTLS ") {
+					t.Fatalf("unexpected event: %q", e)
+				}
+			default:
+				if tt.wantEvent {
+					t.Fatal("expected an InsecureTLS event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestMachineReconcileEnforcePowerState(t *testing.T) {
+	tests := map[string]struct {
+		enforce      bool
+		desired      v1alpha1.PowerState
+		provider     *testProvider
+		wantEnforced bool
+	}{
+		"drift is enforced": {
+			enforce:      true,
+			desired:      v1alpha1.On,
+			provider:     &testProvider{Powerstate: "off", PowerSetOK: true},
+			wantEnforced: true,
+		},
+		"no drift": {
+			enforce:  true,
+			desired:  v1alpha1.On,
+			provider: &testProvider{Powerstate: "on"},
+		},
+		"drift is not enforced when disabled": {
+			desired:  v1alpha1.On,
+			provider: &testProvider{Powerstate: "off"},
+		},
+		"failure to enforce": {
+			enforce:  true,
+			desired:  v1alpha1.Off,
+			provider: &testProvider{Powerstate: "on", ErrPowerStateSet: errors.New("failed to set power state")},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bm := createMachine()
+			bm.Spec.EnforcePowerState = tt.enforce
+			bm.Spec.DesiredPowerState = tt.desired
+			bm.Spec.PowerCheckInterval = &metav1.Duration{Duration: 30 * time.Second}
+			client := newClientBuilder().
+				WithObjects(bm, createSecret()).
+				WithStatusSubresource(bm).
+				Build()
+
+			reconciler := controller.NewMachineReconciler(client, record.NewFakeRecorder(2), newTestClient(tt.provider))
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+			result, err := reconciler.Reconcile(context.Background(), req)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if result.RequeueAfter != 30*time.Second {
+				t.Fatalf("expected requeue after 30s, got %v", result.RequeueAfter)
+			}
+
+			var retrieved v1alpha1.Machine
+			if err := client.Get(context.Background(), req.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if enforced := retrieved.Status.LastEnforcementTime != nil; enforced != tt.wantEnforced {
+				t.Fatalf("expected enforced %v, got %v", tt.wantEnforced, enforced)
+			}
+		})
+	}
+}
+
+func TestMachineReconcileInventory(t *testing.T) {
+	device := &common.Device{Common: common.Common{Vendor: "Dell Inc.", Model: "PowerEdge R640", Serial: "ABC1234"}}
+	tests := map[string]struct {
+		provider          *testProvider
+		lastInventoryTime *metav1.Time
+		want              v1alpha1.MachineStatus
+	}{
+		"inventory is read": {
+			provider: &testProvider{Powerstate: "on", Device: device},
+			want:     v1alpha1.MachineStatus{Manufacturer: "Dell Inc.", Model: "PowerEdge R640", SerialNumber: "ABC1234"},
+		},
+		"inventory is not read within interval": {
+			provider:          &testProvider{Powerstate: "on", Device: device},
+			lastInventoryTime: ptr.To(metav1.Now()),
+		},
+		"inventory is read after interval": {
+			provider:          &testProvider{Powerstate: "on", Device: device},
+			lastInventoryTime: ptr.To(metav1.NewTime(time.Now().Add(-2 * time.Hour))),
+			want:              v1alpha1.MachineStatus{Manufacturer: "Dell Inc.", Model: "PowerEdge R640", SerialNumber: "ABC1234"},
+		},
+		"unsupported inventory is not an error": {
+			provider: &testProvider{Powerstate: "on", ErrInventory: bmclibErrs.ErrProviderImplementation},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bm := createMachine()
+			bm.Status.LastInventoryTime = tt.lastInventoryTime
+			client := newClientBuilder().
+				WithObjects(bm, createSecret()).
+				WithStatusSubresource(bm).
+				Build()
+
+			reconciler := controller.NewMachineReconciler(client, record.NewFakeRecorder(10), newTestClient(tt.provider)).
+				WithInventoryInterval(time.Hour)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+			if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			var retrieved v1alpha1.Machine
+			if err := client.Get(context.Background(), req.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			got := v1alpha1.MachineStatus{Manufacturer: retrieved.Status.Manufacturer, Model: retrieved.Status.Model, SerialNumber: retrieved.Status.SerialNumber}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("unexpected inventory: %v", diff)
+			}
+			if retrieved.Status.LastInventoryTime == nil {
+				t.Fatal("expected last inventory time to be set")
+			}
+		})
+	}
+}
+
+func TestMachineReconcileCapabilities(t *testing.T) {
+	system := func(modes string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1": fmt.Sprintf(`{"Name":"System","Boot":{"BootSourceOverrideMode@Redfish.AllowableValues":%s}}`, modes),
+		}
+	}
+	capabilities := []string{"power", "bootdevice", "virtualmedia", "biosconfig", "sel", "inventory"}
+	tests := map[string]struct {
+		// resources are the Redfish resources of the BMC by path.
+		resources map[string]string
+		protocol  string
+		want      []string
+	}{
+		"uefi only":                 {resources: system(`["UEFI"]`), want: append(capabilities, v1alpha1.UEFIOnlyCapability)},
+		"uefi and legacy":           {resources: system(`["UEFI","Legacy"]`), want: capabilities},
+		"override modes unreported": {resources: system(`[]`), want: capabilities},
+		"unreadable system":         {want: capabilities},
+		"ipmi":                      {resources: system(`["UEFI"]`), protocol: "ipmi", want: capabilities},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bm := createMachine()
+			newFakeRedfish(t, tt.resources).connectMachine(bm)
+			client := newClientBuilder().
+				WithObjects(bm, createSecret()).
+				WithStatusSubresource(bm).
+				Build()
+
+			reconciler := controller.NewMachineReconciler(client, record.NewFakeRecorder(10), newTestClient(&testProvider{Powerstate: "on", Proto: tt.protocol})).
+				WithCapabilitiesInterval(time.Hour)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+			if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			var retrieved v1alpha1.Machine
+			if err := client.Get(context.Background(), req.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if diff := cmp.Diff([]string{"tester"}, retrieved.Status.Providers); diff != "" {
+				t.Fatalf("unexpected providers: %v", diff)
+			}
+			if diff := cmp.Diff(tt.want, retrieved.Status.Capabilities); diff != "" {
+				t.Fatalf("unexpected capabilities: %v", diff)
+			}
+			if retrieved.Status.LastCapabilitiesTime == nil {
+				t.Fatal("expected last capabilities time to be set")
+			}
+		})
+	}
+}
+
+func TestMachineReconcileRequeueJitter(t *testing.T) {
+	bm := createMachine()
+	bm.Spec.PowerCheckInterval = &metav1.Duration{Duration: 30 * time.Second}
+	client := newClientBuilder().
+		WithObjects(bm, createSecret()).
+		WithStatusSubresource(bm).
+		Build()
+
+	reconciler := controller.NewMachineReconciler(client, record.NewFakeRecorder(10), newTestClient(&testProvider{Powerstate: "on"})).
+		WithRequeueJitter(0.5)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+	// The requeue is spread out over the interval extended by up to half of it.
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 10; i++ {
+		result, err := reconciler.Reconcile(context.Background(), req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.RequeueAfter < 30*time.Second || result.RequeueAfter > 45*time.Second {
+			t.Fatalf("expected requeue after between 30s and 45s, got %v", result.RequeueAfter)
+		}
+		seen[result.RequeueAfter] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected jittered requeue intervals, got: %v", seen)
+	}
+}
+
+func TestMachineReconcilePowerCheckInterval(t *testing.T) {
+	tests := map[string]struct {
+		specInterval *metav1.Duration
+		provider     *testProvider
+		wantRequeue  time.Duration
+		wantUpdated  bool
+	}{
+		"controller interval": {
+			provider:    &testProvider{Powerstate: "on"},
+			wantRequeue: time.Minute,
+			wantUpdated: true,
+		},
+		"machine interval overrides controller interval": {
+			specInterval: &metav1.Duration{Duration: 30 * time.Second},
+			provider:     &testProvider{Powerstate: "off"},
+			wantRequeue:  30 * time.Second,
+			wantUpdated:  true,
+		},
+		"power state not read": {
+			provider:    &testProvider{ErrPowerStateGet: errors.New("power state unavailable")},
+			wantRequeue: time.Minute,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			bm := createMachine()
+			bm.Spec.PowerCheckInterval = tt.specInterval
+			client := newClientBuilder().
+				WithObjects(bm, createSecret()).
+				WithStatusSubresource(bm).
+				Build()
+
+			reconciler := controller.NewMachineReconciler(client, record.NewFakeRecorder(10), newTestClient(tt.provider)).
+				WithPowerCheckInterval(time.Minute)
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+			result, _ := reconciler.Reconcile(context.Background(), req)
+			if diff := cmp.Diff(tt.wantRequeue, result.RequeueAfter); diff != "" {
+				t.Fatalf("unexpected requeue: %v", diff)
+			}
+
+			var retrieved v1alpha1.Machine
+			if err := client.Get(context.Background(), req.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if diff := cmp.Diff(tt.wantUpdated, retrieved.Status.LastPowerStateUpdate != nil); diff != "" {
+				t.Fatalf("unexpected last power state update: %v", diff)
+			}
+		})
+	}
+}
+
+func TestMachineReconcileDeletionClosesCachedConnection(t *testing.T) {
+	bm := createMachine()
+	cluster := newClientBuilder().
+		WithObjects(bm, createSecret()).
+		WithStatusSubresource(bm).
+		Build()
+	provider := &testProvider{Powerstate: "on"}
+	cache := controller.NewClientCache(newTestClient(provider), 1, time.Minute, logr.Discard())
+	reconciler := controller.NewMachineReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+		WithClientCache(cache)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "test-bm"}}
+
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var retrieved v1alpha1.Machine
+	if err := cluster.Get(context.Background(), req.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if diff := cmp.Diff([]string{v1alpha1.MachineFinalizer}, retrieved.Finalizers); diff != "" {
+		t.Fatalf("unexpected finalizers: %v", diff)
+	}
+	if closes := provider.Closes.Load(); closes != 0 {
+		t.Fatalf("expected the connection to be cached, got %d closes", closes)
+	}
+
+	if err := cluster.Delete(context.Background(), &retrieved); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if closes := provider.Closes.Load(); closes != 1 {
+		t.Fatalf("expected the cached connection to be closed, got %d closes", closes)
+	}
+	if err := cluster.Get(context.Background(), req.NamespacedName, &retrieved); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the Machine to be deleted, got %v", err)
+	}
+}
+
 func createMachineWithRPC(secret *corev1.Secret) *v1alpha1.Machine {
 	return &v1alpha1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
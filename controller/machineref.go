@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// resolveMachineRef sets the Connection of a task that references a Machine to the Connection of the Machine, with
+// its Secret references made absolute to the namespace of the Machine. The resolved Connection is only kept in
+// memory, it is never written to the Task. A Machine that doesn't exist is returned as a terminal error.
+func (r *TaskReconciler) resolveMachineRef(ctx context.Context, task *v1alpha1.Task) error {
+	ref := task.Spec.MachineRef
+	if ref == nil {
+		return nil
+	}
+
+	machine := &v1alpha1.Machine{}
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if err := r.client.Get(ctx, key, machine); err != nil {
+		if apierrors.IsNotFound(err) {
+			return &terminalError{err: fmt.Errorf("the Machine %s referenced by machineRef does not exist", key)}
+		}
+		return fmt.Errorf("failed to get Machine %s: %w", key, err)
+	}
+	task.Spec.Connection = withSecretNamespace(machine.Spec.Connection, machine.Namespace)
+
+	return nil
+}
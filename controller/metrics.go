@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// Results of a finished Task, used as the result label of taskTotal.
+const (
+	taskResultCompleted   = "completed"
+	taskResultFailed      = "failed"
+	taskResultUnsupported = "unsupported"
+)
+
+// Reasons a reconcile was requeued, used as the reason label of reconcileRequeueTotal.
+const (
+	// requeueReasonTransientError is a BMC operation that failed with an error that is retried.
+	requeueReasonTransientError = "transient_error"
+	// requeueReasonRateLimited is a BMC connection not opened because the login rate limit was reached.
+	requeueReasonRateLimited = "rate_limited"
+	// requeueReasonConcurrencyLimited is a reconcile that waits for the limits of Tasks in flight or of operations
+	// per BMC host, for the Lease of the BMC host or for an identical running Task.
+	requeueReasonConcurrencyLimited = "concurrency_limited"
+)
+
+var (
+	// taskTotal counts finished Tasks by action type and result.
+	taskTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rufio_task_total",
+		Help: "Total number of finished Tasks by action and result.",
+	}, []string{"action", "result"})
+
+	// taskDuration observes how long finished Tasks took, from start to completion or failure.
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rufio_task_duration_seconds",
+		Help:    "Duration of finished Tasks in seconds by action.",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"action"})
+
+	// tasksInFlight is the number of Tasks currently being reconciled against a BMC.
+	tasksInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rufio_tasks_in_flight",
+		Help: "Number of Tasks currently being reconciled against a BMC.",
+	})
+
+	// circuitOpen is 1 for each BMC host whose circuit is open or waits for a successful connection.
+	circuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rufio_bmc_circuit_open",
+		Help: "Whether the circuit breaker of a BMC host is open, by host.",
+	}, []string{"host"})
+
+	// circuitRejections counts the Tasks failed fast because the circuit of their BMC host was open.
+	circuitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rufio_bmc_circuit_rejections_total",
+		Help: "Total number of Tasks failed because the circuit breaker of their BMC host was open, by host.",
+	}, []string{"host"})
+
+	// loginRateLimited counts the BMC connections not opened because the login rate limit was reached.
+	loginRateLimited = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rufio_bmc_login_rate_limited_total",
+		Help: "Total number of BMC connections requeued because the controller-wide login rate limit was reached.",
+	})
+
+	// reconcileRequeueTotal counts the Machine and Task reconciles requeued by the controller, by reason.
+	reconcileRequeueTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rufio_reconcile_requeue_total",
+		Help: "Total number of Machine and Task reconciles requeued, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	// Registered with the controller-runtime registry, so they are served on the manager's /metrics endpoint.
+	metrics.Registry.MustRegister(taskTotal, taskDuration, tasksInFlight, circuitOpen, circuitRejections, loginRateLimited, reconcileRequeueTotal)
+}
+
+// observeRequeue records a reconcile requeued for reason.
+func observeRequeue(reason string) {
+	reconcileRequeueTotal.WithLabelValues(reason).Inc()
+}
+
+// observeTaskFinished records the result and duration of task, which just finished with result.
+// The duration is measured from the StartTime of the Task, or its creation when it never started.
+func observeTaskFinished(task *v1alpha1.Task, result string) {
+	action := actionType(task.CurrentAction())
+	taskTotal.WithLabelValues(action, result).Inc()
+
+	start := task.CreationTimestamp.Time
+	if task.Status.StartTime != nil {
+		start = task.Status.StartTime.Time
+	}
+	if !start.IsZero() {
+		taskDuration.WithLabelValues(action).Observe(time.Since(start).Seconds())
+	}
+}
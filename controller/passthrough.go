@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// redfishPassthrough sends the request of action to the Redfish service of the BMC and stores the response,
+// truncated to maxRedfishResponseBytes, in the Task status. bmclib does not expose raw Redfish requests, so the
+// request is sent with an HTTP client configured like the one of bmclib, authenticated with HTTP basic authentication.
+// A response status other than 2xx is returned as an error.
+func redfishPassthrough(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions, action v1alpha1.RedfishActionPassthroughAction) error {
+	var body []byte
+	if action.Body != nil {
+		body = action.Body.Raw
+	}
+	resp, err := redfishRequest(ctx, bmcClient, opts, action.Method, action.Path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read one byte more than stored to tell whether the body was truncated.
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRedfishResponseBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read Redfish response: %w", err)
+	}
+	truncated := len(b) > maxRedfishResponseBytes
+	if truncated {
+		b = b[:maxRedfishResponseBytes]
+	}
+	task.Status.RedfishResponse = &v1alpha1.RedfishResponse{StatusCode: resp.StatusCode, Body: string(b), Truncated: truncated}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("request %s %s to the Redfish service returned %s", action.Method, action.Path, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// redfishControl is the part of a Redfish Control resource, the power control of newer BMCs.
+type redfishControl struct {
+	ControlType  string   `json:"ControlType"`
+	SetPoint     *float64 `json:"SetPoint"`
+	AllowableMin *float64 `json:"AllowableMin"`
+	AllowableMax *float64 `json:"AllowableMax"`
+	Sensor       *struct {
+		Reading *float64 `json:"Reading"`
+	} `json:"Sensor"`
+}
+
+// redfishPower is the part of a Redfish Power resource, the power control of older BMCs.
+type redfishPower struct {
+	PowerControl []struct {
+		PowerConsumedWatts *float64 `json:"PowerConsumedWatts"`
+		PowerCapacityWatts *float64 `json:"PowerCapacityWatts"`
+		PowerLimit         *struct {
+			LimitInWatts *float64 `json:"LimitInWatts"`
+		} `json:"PowerLimit"`
+	} `json:"PowerControl"`
+}
+
+// powerControl is the Redfish resource that limits the power of the Machine.
+type powerControl struct {
+	// path is the path of the resource patched to change the limit.
+	path string
+	// patch returns the body of the request that sets the limit to watts.
+	patch func(watts int) map[string]any
+	// limit is the limit, the consumption and the allowable range read from the resource.
+	limit v1alpha1.PowerLimit
+}
+
+// getPowerLimit reads the power limit and consumption of the Machine and stores them in the Task status.
+func getPowerLimit(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	pc, err := findPowerControl(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	task.Status.PowerLimit = &pc.limit
+
+	return nil
+}
+
+// setPowerLimit sets the power limit of the Machine to action.LimitWatts. The limit read before is stored in
+// the Task status. A limit outside the allowable range reported by the BMC is returned as a terminal error,
+// without changing the limit.
+func setPowerLimit(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions, action v1alpha1.SetPowerLimitAction) error {
+	pc, err := findPowerControl(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	task.Status.PowerLimit = &pc.limit
+
+	if (pc.limit.AllowableMinWatts != 0 && action.LimitWatts < pc.limit.AllowableMinWatts) || (pc.limit.AllowableMaxWatts != 0 && action.LimitWatts > pc.limit.AllowableMaxWatts) {
+		return &terminalError{err: fmt.Errorf("power limit %dW is out of the range %s allowed by the BMC", action.LimitWatts, allowableRange(pc.limit))}
+	}
+
+	return redfishSend(ctx, bmcClient, opts, http.MethodPatch, pc.path, pc.patch(action.LimitWatts))
+}
+
+// findPowerControl returns the power control of the first chassis of the Machine that has one. The Control
+// resource of type Power is preferred over the PowerControl of the deprecated Power resource. IPMI-only
+// providers don't support power limits, which is returned as an unsupported error.
+func findPowerControl(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) (*powerControl, error) {
+	if err := requireRedfish(bmcClient, "power limits"); err != nil {
+		return nil, err
+	}
+
+	var chassis redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, chassisCollectionPath, &chassis); err != nil {
+		return nil, err
+	}
+	for _, member := range chassis.Members {
+		var c redfishChassis
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &c); err != nil {
+			return nil, err
+		}
+
+		if c.Controls != nil {
+			var controls redfishCollection
+			if err := redfishGet(ctx, bmcClient, opts, c.Controls.ID, &controls); err != nil {
+				return nil, err
+			}
+			for _, link := range controls.Members {
+				var control redfishControl
+				if err := redfishGet(ctx, bmcClient, opts, link.ID, &control); err != nil {
+					return nil, err
+				}
+				if control.ControlType != "Power" {
+					continue
+				}
+				limit := v1alpha1.PowerLimit{
+					LimitWatts:        watts(control.SetPoint),
+					AllowableMinWatts: watts(control.AllowableMin),
+					AllowableMaxWatts: watts(control.AllowableMax),
+				}
+				if control.Sensor != nil {
+					limit.ConsumedWatts = watts(control.Sensor.Reading)
+				}
+				return &powerControl{
+					path:  link.ID,
+					patch: func(w int) map[string]any { return map[string]any{"SetPoint": w} },
+					limit: limit,
+				}, nil
+			}
+		}
+
+		if c.Power != nil {
+			var power redfishPower
+			if err := redfishGet(ctx, bmcClient, opts, c.Power.ID, &power); err != nil {
+				return nil, err
+			}
+			if len(power.PowerControl) == 0 {
+				continue
+			}
+			p := power.PowerControl[0]
+			limit := v1alpha1.PowerLimit{
+				ConsumedWatts:     watts(p.PowerConsumedWatts),
+				AllowableMaxWatts: watts(p.PowerCapacityWatts),
+			}
+			if p.PowerLimit != nil {
+				limit.LimitWatts = watts(p.PowerLimit.LimitInWatts)
+			}
+			return &powerControl{
+				path: c.Power.ID,
+				patch: func(w int) map[string]any {
+					return map[string]any{"PowerControl": []any{map[string]any{"PowerLimit": map[string]any{"LimitInWatts": w}}}}
+				},
+				limit: limit,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no chassis of the BMC has a power control: %w", bmclibErrs.ErrProviderImplementation)
+}
+
+// watts returns f rounded to whole watts, or 0 when the BMC did not report it.
+func watts(f *float64) int {
+	if f == nil {
+		return 0
+	}
+	return int(math.Round(*f))
+}
+
+// allowableRange formats the power limit range allowed by the BMC, with the unreported bounds left open.
+func allowableRange(l v1alpha1.PowerLimit) string {
+	lower, upper := "", ""
+	if l.AllowableMinWatts != 0 {
+		lower = fmt.Sprintf("%dW", l.AllowableMinWatts)
+	}
+	if l.AllowableMaxWatts != 0 {
+		upper = fmt.Sprintf("%dW", l.AllowableMaxWatts)
+	}
+
+	return fmt.Sprintf("[%s, %s]", lower, upper)
+}
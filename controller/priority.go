@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"time"
+)
+
+// priorityWaiter is a Task that was refused by a limiter and is waiting to be requeued.
+type priorityWaiter struct {
+	priority int32
+	// seen is when the Task was last refused. Tasks that were deleted or finished stop retrying, so waiters
+	// not seen for a while are forgotten.
+	seen time.Time
+}
+
+// priorityWaiters are the Tasks waiting for a slot of a limiter, by namespace/name. A slot that frees up is
+// kept for the waiting Task with the highest priority: Tasks with a lower priority are refused while it waits.
+// It is not safe for concurrent use, the limiter holding it guards it.
+type priorityWaiters struct {
+	// ttl is how long a waiter is remembered after it was last refused, a few times its requeue interval.
+	ttl     time.Duration
+	waiters map[string]priorityWaiter
+}
+
+// newPriorityWaiters returns priorityWaiters that forget a waiter ttl after it was last refused.
+func newPriorityWaiters(ttl time.Duration) *priorityWaiters {
+	return &priorityWaiters{ttl: ttl, waiters: map[string]priorityWaiter{}}
+}
+
+// outranked reports whether a Task other than key, with a priority higher than priority, is waiting.
+func (w *priorityWaiters) outranked(key string, priority int32) bool {
+	now := time.Now()
+	for k, waiter := range w.waiters {
+		if now.Sub(waiter.seen) > w.ttl {
+			delete(w.waiters, k)
+			continue
+		}
+		if k != key && waiter.priority > priority {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wait records that the Task key with priority was refused. An empty key, a reconcile that isn't a Task,
+// is not recorded.
+func (w *priorityWaiters) wait(key string, priority int32) {
+	if key == "" {
+		return
+	}
+	w.waiters[key] = priorityWaiter{priority: priority, seen: time.Now()}
+}
+
+// admit forgets the Task key once it got a slot.
+func (w *priorityWaiters) admit(key string) {
+	delete(w.waiters, key)
+}
+
+// empty reports whether no Task is waiting.
+func (w *priorityWaiters) empty() bool {
+	return len(w.waiters) == 0
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// panicError returns the error a reconcile that panicked with p fails with, and logs it with the stack trace
+// of the panic. It must be called from the deferred function that recovered p.
+func panicError(logger logr.Logger, p any) error {
+	err := fmt.Errorf("reconcile panicked: %v", p)
+	logger.Error(err, "recovered from panic", "stack", string(debug.Stack()))
+
+	return err
+}
+
+// recoverPanic recovers from a panic of a reconcile and returns it in err instead of crashing the manager,
+// which would stop the reconciles of all objects. It must be deferred by the reconcile.
+func recoverPanic(logger logr.Logger, err *error) {
+	if p := recover(); p != nil {
+		*err = panicError(logger, p)
+	}
+}
+
+// taskPanicked fails the Task key whose reconcile panicked with p. The Task is read again, as the one of the
+// reconcile may have been changed partially before the panic. The error of the panic is returned so that the
+// reconcile is logged as failed.
+func (r *TaskReconciler) taskPanicked(ctx context.Context, logger logr.Logger, key types.NamespacedName, p any) error {
+	err := panicError(logger, p)
+
+	task := &v1alpha1.Task{}
+	if getErr := r.client.Get(ctx, key, task); getErr != nil {
+		return utilerrors.NewAggregate([]error{err, client.IgnoreNotFound(getErr)})
+	}
+	if task.Finished() {
+		return err
+	}
+	taskPatch := client.MergeFrom(task.DeepCopy())
+	r.setTaskFailed(task, err.Error(), err)
+	if patchErr := r.patchStatus(ctx, task, taskPatch); patchErr != nil {
+		return utilerrors.NewAggregate([]error{err, patchErr})
+	}
+
+	return err
+}
+
+// recoverTaskPanic recovers from a panic of the reconcile of the Task key and fails the Task with it. It must be
+// deferred by the reconcile, before any other deferred function, so that those run before the Task is failed.
+func (r *TaskReconciler) recoverTaskPanic(ctx context.Context, logger logr.Logger, key types.NamespacedName, result *ctrl.Result, err *error) {
+	if p := recover(); p != nil {
+		*result, *err = ctrl.Result{}, r.taskPanicked(ctx, logger, key, p)
+	}
+}
@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+)
+
+// maxRedfishResponseBytes is the maximum size of a Redfish response body read from the BMC.
+const maxRedfishResponseBytes = 32 << 10
+
+// redfishDefaultPort is the port of the Redfish service when neither the Connection nor the Redfish options set one.
+const redfishDefaultPort = 443
+
+// systemCollectionPath is the path of the Redfish computer system collection.
+const systemCollectionPath = "/redfish/v1/Systems"
+
+// chassisCollectionPath is the path of the Redfish chassis collection.
+const chassisCollectionPath = "/redfish/v1/Chassis"
+
+// redfishLink is a link to a Redfish resource.
+type redfishLink struct {
+	ID string `json:"@odata.id"`
+}
+
+// redfishCollection is the part of a Redfish resource collection used to find its members.
+type redfishCollection struct {
+	Members []redfishLink `json:"Members"`
+}
+
+// redfishChassis is the part of a Redfish Chassis resource used to find its power control, identify LED and sensors.
+type redfishChassis struct {
+	Controls *redfishLink `json:"Controls"`
+	Power    *redfishLink `json:"Power"`
+	Thermal  *redfishLink `json:"Thermal"`
+	Sensors  *redfishLink `json:"Sensors"`
+	// LocationIndicatorActive is the state of the identify LED on newer BMCs.
+	LocationIndicatorActive *bool `json:"LocationIndicatorActive"`
+	// IndicatorLED is the state of the identify LED on older BMCs, one of Lit, Blinking and Off.
+	IndicatorLED string `json:"IndicatorLED"`
+}
+
+// redfishSystem is the part of a Redfish ComputerSystem resource used by the actions sent through Redfish.
+type redfishSystem struct {
+	Name    string               `json:"Name"`
+	Boot    redfishBoot          `json:"Boot"`
+	Actions redfishSystemActions `json:"Actions"`
+	// BootProgress is only reported by BMCs that implement Redfish 1.13 or later.
+	BootProgress *redfishBootProgress `json:"BootProgress"`
+}
+
+// redfishBootProgress is the boot progress of a Redfish ComputerSystem resource.
+type redfishBootProgress struct {
+	// LastState is the last boot progress state, for example MemoryInitializationStarted, or OEM.
+	LastState string `json:"LastState"`
+	// OemLastState is the last boot progress state when LastState is OEM.
+	OemLastState string `json:"OemLastState"`
+}
+
+// redfishSystemActions is the actions of a Redfish ComputerSystem resource.
+type redfishSystemActions struct {
+	Reset *redfishResetAction `json:"#ComputerSystem.Reset"`
+}
+
+// redfishResetAction is the ComputerSystem.Reset action of a Redfish ComputerSystem resource.
+type redfishResetAction struct {
+	Target string `json:"target"`
+	// AllowableValues are the reset types the BMC accepts, when it reports them.
+	AllowableValues []string `json:"ResetType@Redfish.AllowableValues"`
+}
+
+// redfishBoot is the boot settings of a Redfish ComputerSystem resource.
+type redfishBoot struct {
+	BootOrder   []string     `json:"BootOrder"`
+	BootOptions *redfishLink `json:"BootOptions"`
+	// BootSourceOverrideTarget is the boot source of the last boot override applied, for example Pxe.
+	BootSourceOverrideTarget string `json:"BootSourceOverrideTarget"`
+	// BootSourceOverrideEnabled is whether the boot override applies Once, Continuous, or is Disabled.
+	BootSourceOverrideEnabled string `json:"BootSourceOverrideEnabled"`
+	// BootSourceOverrideModes are the boot override modes the BMC accepts, for example UEFI and Legacy,
+	// when it reports them.
+	BootSourceOverrideModes []string `json:"BootSourceOverrideMode@Redfish.AllowableValues"`
+	// BootSourceOverrideTargets are the boot sources the BMC accepts as boot override, when it reports them.
+	BootSourceOverrideTargets []string `json:"BootSourceOverrideTarget@Redfish.AllowableValues"`
+}
+
+// redfishBootOption is the part of a Redfish BootOption resource used to map boot devices to boot order references.
+type redfishBootOption struct {
+	BootOptionReference string `json:"BootOptionReference"`
+	DisplayName         string `json:"DisplayName"`
+	// Alias is the boot source of the boot option, for example Pxe or Hdd.
+	Alias string `json:"Alias"`
+	// UefiDevicePath is only set on the boot options of UEFI boot.
+	UefiDevicePath string `json:"UefiDevicePath"`
+}
+
+// requireRedfish returns an error wrapping ErrProviderImplementation when none of the opened providers of
+// bmcClient speak Redfish. feature names what is not supported in the error, for example "power limits".
+func requireRedfish(bmcClient *bmclib.Client, feature string) error {
+	var protocols []string
+	for _, d := range bmcClient.Registry.Drivers {
+		if strings.EqualFold(d.Protocol, "redfish") {
+			return nil
+		}
+		protocols = append(protocols, d.Protocol)
+	}
+
+	return fmt.Errorf("%s are not supported by protocols [%s], a Redfish capable BMC is required: %w", feature, strings.Join(protocols, ", "), bmclibErrs.ErrProviderImplementation)
+}
+
+// findRedfishSystem returns the path and the resource of the computer system of the Machine. Like bmclib,
+// the system named by the Redfish SystemName option is used when there is one, otherwise the first system.
+func findRedfishSystem(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions) (string, *redfishSystem, error) {
+	var systems redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, systemCollectionPath, &systems); err != nil {
+		return "", nil, err
+	}
+
+	var first *redfishSystem
+	var firstPath string
+	for _, member := range systems.Members {
+		var s redfishSystem
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &s); err != nil {
+			return "", nil, err
+		}
+		if opts.ProviderOptions == nil || opts.Redfish == nil || opts.Redfish.SystemName == "" || s.Name == opts.Redfish.SystemName {
+			return member.ID, &s, nil
+		}
+		if first == nil {
+			first, firstPath = &s, member.ID
+		}
+	}
+	if first == nil {
+		return "", nil, fmt.Errorf("the BMC has no computer system: %w", bmclibErrs.ErrProviderImplementation)
+	}
+
+	return firstPath, first, nil
+}
+
+// redfishGet reads the Redfish resource at path into v.
+func redfishGet(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, path string, v any) error {
+	resp, err := redfishRequest(ctx, bmcClient, opts, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRedfishResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read Redfish resource %s: %w", path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("reading Redfish resource %s returned %s", path, resp.Status)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode Redfish resource %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// redfishSend sends a request with the JSON encoding of body to path on the Redfish service of the BMC.
+// A response status other than 2xx is returned as an error, with the start of the response body.
+func redfishSend(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, method, path string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode Redfish request %s %s: %w", method, path, err)
+	}
+	resp, err := redfishRequest(ctx, bmcClient, opts, method, path, b)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxRedfishResponseBytes))
+		return fmt.Errorf("request %s %s to the Redfish service returned %s: %s", method, path, resp.Status, b)
+	}
+
+	return nil
+}
+
+// redfishRequest sends a request with body, when not empty, to path on the Redfish service of the BMC of
+// bmcClient. bmclib does not expose raw Redfish requests, so the request is sent with an HTTP client configured
+// like the one of bmclib, authenticated with HTTP basic authentication with the credentials of bmcClient.
+func redfishRequest(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, method, path string, body []byte) (*http.Response, error) {
+	u := "https://" + net.JoinHostPort(unbracketHost(bmcClient.Auth.Host), strconv.Itoa(opts.redfishPort())) + path
+
+	var r io.Reader
+	if len(body) > 0 {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Redfish request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if r != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if opts.httpConfig.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.httpConfig.UserAgent)
+	}
+	req.SetBasicAuth(bmcClient.Auth.User, bmcClient.Auth.Pass)
+
+	resp, err := opts.redfishHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send Redfish request %s %s: %w", method, path, err)
+	}
+
+	return resp, nil
+}
+
+// redfishPort returns the port of the Redfish service: the Redfish provider port, the Connection port or 443.
+func (b *BMCOptions) redfishPort() int {
+	if b.ProviderOptions != nil && b.Redfish != nil && b.Redfish.Port != 0 {
+		return b.Redfish.Port
+	}
+	// Like in Translate, the IPMI port defaulted by older versions of Rufio is not used for Redfish.
+	if b.port != 0 && b.port != ipmiDefaultPort {
+		return b.port
+	}
+
+	return redfishDefaultPort
+}
+
+// redfishHTTPClient returns an HTTP client with the client certificate, proxy, request timeout, CA certificates
+// and TLS verification of the options.
+func (b *BMCOptions) redfishHTTPClient() *http.Client {
+	c := newHTTPClient(b.clientCert, b.proxyURL, b.httpConfig.Timeout)
+	if !b.insecureTLS {
+		// Verify against the CA bundle of the Connection or the system root CAs, the same as bmclib.WithSecureTLS.
+		c.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = false
+		c.Transport.(*http.Transport).TLSClientConfig.RootCAs = b.rootCAs()
+	}
+
+	return c
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// resetTypePowerStates maps a ResetType to the bmclib power state that performs it, by provider name.
+// The bmclib power states don't mean the same reset across providers: the Redfish based providers
+// issue a ForceRestart for "cycle" and a PowerCycle for "reset", ipmitool issues a hard reset for
+// "reset" and a chassis power cycle for "cycle".
+var resetTypePowerStates = map[string]map[v1alpha1.ResetType]string{
+	"gofish":   {v1alpha1.ResetForceRestart: "cycle", v1alpha1.ResetPowerCycle: "reset"},
+	"dell":     {v1alpha1.ResetForceRestart: "cycle", v1alpha1.ResetPowerCycle: "reset"},
+	"openbmc":  {v1alpha1.ResetForceRestart: "cycle", v1alpha1.ResetPowerCycle: "reset"},
+	"ipmitool": {v1alpha1.ResetForceRestart: "reset", v1alpha1.ResetPowerCycle: "cycle"},
+}
+
+// powerSetter is implemented by providers that can set the power state of the Machine.
+type powerSetter interface {
+	PowerSet(ctx context.Context, state string) (ok bool, err error)
+}
+
+// nmiSender is implemented by providers that can send a diagnostic interrupt (NMI) to the Machine.
+type nmiSender interface {
+	SendNMI(ctx context.Context) error
+}
+
+// resetMachine resets the Machine with resetType and returns the name of the provider that reset it. When a
+// Redfish provider is opened, the ComputerSystem.Reset action of the Redfish computer system is sent with
+// resetType. Otherwise, or when the BMC has no reset action for resetType, the first opened provider that supports
+// resetType is used. An error wrapping ErrProviderImplementation is returned when no opened provider supports
+// resetType.
+func resetMachine(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, resetType v1alpha1.ResetType) (provider string, err error) {
+	if requireRedfish(bmcClient, "reset types") == nil {
+		err := resetRedfishSystem(ctx, bmcClient, opts, resetType)
+		if err == nil {
+			return "redfish", nil
+		}
+		if !isUnsupported(err) {
+			return "", err
+		}
+	}
+
+	var names []string
+	for _, driver := range bmcClient.Registry.Drivers {
+		names = append(names, driver.Name)
+
+		if resetType == v1alpha1.ResetNmi {
+			if sender, ok := driver.DriverInterface.(nmiSender); ok {
+				if err := sender.SendNMI(ctx); err != nil {
+					return "", fmt.Errorf("provider %s: %w", driver.Name, err)
+				}
+				return driver.Name, nil
+			}
+			continue
+		}
+
+		state, ok := resetTypePowerStates[driver.Name][resetType]
+		if !ok {
+			continue
+		}
+		if setter, ok := driver.DriverInterface.(powerSetter); ok {
+			if _, err := setter.PowerSet(ctx, state); err != nil {
+				return "", fmt.Errorf("provider %s: %w", driver.Name, err)
+			}
+			return driver.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("reset type %s is not supported by providers [%s]: %w", resetType, strings.Join(names, ", "), bmclibErrs.ErrProviderImplementation)
+}
+
+// resetRedfishSystem sends the ComputerSystem.Reset action with resetType to the Redfish computer system of the
+// Machine. An error wrapping ErrProviderImplementation is returned when the computer system has no reset action,
+// or when the BMC reports the reset types it accepts and resetType is not one of them.
+func resetRedfishSystem(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, resetType v1alpha1.ResetType) error {
+	_, system, err := findRedfishSystem(ctx, bmcClient, opts)
+	if err != nil {
+		return err
+	}
+	reset := system.Actions.Reset
+	if reset == nil || reset.Target == "" {
+		return fmt.Errorf("the computer system has no reset action: %w", bmclibErrs.ErrProviderImplementation)
+	}
+	if len(reset.AllowableValues) > 0 && !slices.Contains(reset.AllowableValues, string(resetType)) {
+		return fmt.Errorf("reset type %s is not one of the reset types [%s] of the computer system: %w", resetType, strings.Join(reset.AllowableValues, ", "), bmclibErrs.ErrProviderImplementation)
+	}
+
+	return redfishSend(ctx, bmcClient, opts, http.MethodPost, reset.Target, map[string]string{"ResetType": string(resetType)})
+}
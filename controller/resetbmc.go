@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+const (
+	// bmcResetSettleTime is how long after a ResetBMCAction the BMC is not checked for readiness, as it may
+	// still respond for a while before it goes down for the reset.
+	bmcResetSettleTime = 30 * time.Second
+	// bmcResetRequeueAfter is how often a BMC that is not ready after a ResetBMCAction is checked again.
+	bmcResetRequeueAfter = 10 * time.Second
+)
+
+// errBMCReset is the error a connection to a BMC that was reset is released with, so that the connection
+// cache discards it. The sessions of the BMC don't survive its reset.
+var errBMCReset = fmt.Errorf("the BMC was reset: %w", bmclibErrs.ErrSessionExpired)
+
+// resetBMC resets the BMC itself with the manager reset of the first opened provider that supports it.
+func resetBMC(ctx context.Context, bmcClient *bmclib.Client, action v1alpha1.ResetBMCAction) error {
+	ok, err := bmcClient.ResetBMC(ctx, string(action.Type))
+	if err != nil {
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support resetting the BMC: %w", err)
+		}
+		return fmt.Errorf("failed to %s reset the BMC: %w", action.Type, err)
+	}
+	if !ok {
+		return fmt.Errorf("BMC did not confirm the %s reset", action.Type)
+	}
+
+	return nil
+}
+
+// waitingForBMCReset reports whether the current action of task is a ResetBMCAction that was run and waits
+// for the BMC to be ready again.
+func waitingForBMCReset(task *v1alpha1.Task) bool {
+	action := task.CurrentAction().ResetBMCAction
+
+	return action != nil && action.WaitForReady && !task.Status.StartTime.IsZero()
+}
+
+// checkResetBMC checks whether the BMC responds again after a ResetBMCAction that waits for it to be ready.
+// A non-zero Result means the BMC is not ready yet.
+func checkResetBMC(ctx context.Context, log logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client) (ctrl.Result, error) {
+	if !task.CurrentAction().ResetBMCAction.WaitForReady {
+		return ctrl.Result{}, nil
+	}
+	if elapsed := time.Since(task.Status.StartTime.Time); elapsed < bmcResetSettleTime {
+		return ctrl.Result{RequeueAfter: bmcResetSettleTime - elapsed}, nil
+	}
+	if _, err := bmcClient.GetPowerState(ctx); err != nil {
+		log.Info("BMC not ready after reset, requeuing task", "error", err.Error(), "requeueAfter", bmcResetRequeueAfter)
+		return ctrl.Result{RequeueAfter: bmcResetRequeueAfter}, nil
+	}
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage("BMC ready after reset"))
+
+	return ctrl.Result{}, nil
+}
+
+// requeueBMCReset requeues task when the connection to its BMC failed with err while waiting for the BMC to be
+// ready after a ResetBMCAction. The Task is failed when the BMC was not ready within timeout.
+func (r *TaskReconciler) requeueBMCReset(ctx context.Context, logger logr.Logger, task *v1alpha1.Task, taskPatch client.Patch, timeout time.Duration, err error) (ctrl.Result, error) {
+	if time.Since(task.Status.StartTime.Time) >= timeout {
+		return r.failTask(ctx, task, taskPatch, fmt.Errorf("BMC was not ready within %s after reset: %w", timeout, err))
+	}
+	logger.Info("BMC not reachable after reset, requeuing task", "error", err.Error(), "requeueAfter", bmcResetRequeueAfter)
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("Waiting for the BMC to be ready after reset: %v", err)))
+	if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: jitter(bmcResetRequeueAfter, r.requeueJitter)}, nil
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+const (
+	// defaultRetryBackoffBase is the wait before the first retry when a RetryPolicy has no BackoffBase.
+	defaultRetryBackoffBase = 5 * time.Second
+	// maxRetryBackoffShift bounds the exponent of the backoff so the wait can't overflow.
+	maxRetryBackoffShift = 16
+)
+
+// retryBackoff returns how long to wait before the action of task is run again after it failed with err.
+// ok is false if the action should not be retried, either because err is not transient or the
+// RetryPolicy of the task has no retries left.
+func retryBackoff(task *v1alpha1.Task, err error) (backoff time.Duration, ok bool) {
+	policy := task.Spec.RetryPolicy
+	if policy == nil || task.Status.Attempts > policy.MaxRetries || !isTransient(err) {
+		return 0, false
+	}
+
+	base := defaultRetryBackoffBase
+	if policy.BackoffBase != nil {
+		base = policy.BackoffBase.Duration
+	}
+	shift := task.Status.Attempts - 1
+	if shift > maxRetryBackoffShift {
+		shift = maxRetryBackoffShift
+	}
+	backoff = base << shift
+	if policy.MaxBackoff != nil && backoff > policy.MaxBackoff.Duration {
+		backoff = policy.MaxBackoff.Duration
+	}
+
+	return backoff, true
+}
+
+// retryBudgetExhausted returns err annotated with the attempts of task when a retry after backoff would start after
+// the RetryPolicy MaxElapsedTime of task, measured from the first attempt of the action. It returns nil otherwise.
+func retryBudgetExhausted(task *v1alpha1.Task, backoff time.Duration, err error) error {
+	policy := task.Spec.RetryPolicy
+	if policy == nil || policy.MaxElapsedTime == nil || task.Status.FirstAttemptTime.IsZero() {
+		return nil
+	}
+	if time.Since(task.Status.FirstAttemptTime.Time)+backoff <= policy.MaxElapsedTime.Duration {
+		return nil
+	}
+
+	return fmt.Errorf("retry budget of %s exhausted after %d attempts: %w", policy.MaxElapsedTime.Duration, task.Status.Attempts, err)
+}
+
+// failureRequeueAfter returns how long to wait before the action of task is run again after it failed with err,
+// once the RetryPolicy of the task is exhausted. ok is false if the Task should fail permanently, either because
+// failure requeues are disabled, err is not transient or the failure requeue window has elapsed.
+func (r *TaskReconciler) failureRequeueAfter(task *v1alpha1.Task, err error) (requeueAfter time.Duration, ok bool) {
+	if r.failureRequeueInterval <= 0 || !isTransient(err) {
+		return 0, false
+	}
+	if time.Since(task.CreationTimestamp.Time)+r.failureRequeueInterval > r.failureRequeueWindow {
+		return 0, false
+	}
+
+	return r.failureRequeueInterval, true
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// connectionSecretKey is the field index of Machines and Tasks by the Secrets their Connection references,
+// in namespace/name format.
+const connectionSecretKey = ".spec.connection.secretRefs"
+
+// MachineSecretIndexFunc is Indexer func which returns the Secrets referenced by the Connection of a Machine.
+func MachineSecretIndexFunc(obj client.Object) []string {
+	machine, ok := obj.(*v1alpha1.Machine)
+	if !ok {
+		return nil
+	}
+
+	return connectionSecrets(machine.Spec.Connection, machine.Namespace)
+}
+
+// TaskSecretIndexFunc is Indexer func which returns the Secrets referenced by the Connection of a Task.
+func TaskSecretIndexFunc(obj client.Object) []string {
+	task, ok := obj.(*v1alpha1.Task)
+	if !ok {
+		return nil
+	}
+
+	return connectionSecrets(task.Spec.Connection, task.Namespace)
+}
+
+// connectionSecrets returns the Secrets referenced by c in namespace/name format: the auth Secret, the client
+// certificate Secret, the CA certificate Secret and the RPC HMAC Secrets. References without a namespace are to
+// Secrets in namespace, the namespace of the object holding c.
+func connectionSecrets(c v1alpha1.Connection, namespace string) []string {
+	var refs []corev1.SecretReference
+	if c.AuthSecretRef.Name != "" {
+		refs = append(refs, c.AuthSecretRef)
+	}
+	if c.ClientCertSecretRef != nil {
+		refs = append(refs, *c.ClientCertSecretRef)
+	}
+	if c.CACertSecretRef != nil && c.CACertSecretRef.CAKind() == v1alpha1.CACertKindSecret {
+		refs = append(refs, corev1.SecretReference{Name: c.CACertSecretRef.Name, Namespace: c.CACertSecretRef.Namespace})
+	}
+	if c.ProviderOptions != nil && c.ProviderOptions.RPC != nil {
+		for _, secrets := range c.ProviderOptions.RPC.HMAC.Secrets {
+			refs = append(refs, secrets...)
+		}
+	}
+
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		keys = append(keys, secretKey(ref, namespace).String())
+	}
+
+	return keys
+}
+
+// withSecretNamespace returns a copy of c with the namespace of its Secret and ConfigMap references without one set
+// to namespace.
+func withSecretNamespace(c v1alpha1.Connection, namespace string) v1alpha1.Connection {
+	out := c.DeepCopy()
+	if out.AuthSecretRef.Name != "" {
+		out.AuthSecretRef.Namespace = secretKey(out.AuthSecretRef, namespace).Namespace
+	}
+	if out.ClientCertSecretRef != nil {
+		out.ClientCertSecretRef.Namespace = secretKey(*out.ClientCertSecretRef, namespace).Namespace
+	}
+	if out.CACertSecretRef != nil && out.CACertSecretRef.Namespace == "" {
+		out.CACertSecretRef.Namespace = namespace
+	}
+	if out.ProviderOptions != nil && out.ProviderOptions.RPC != nil {
+		for _, secrets := range out.ProviderOptions.RPC.HMAC.Secrets {
+			for i := range secrets {
+				secrets[i].Namespace = secretKey(secrets[i], namespace).Namespace
+			}
+		}
+	}
+
+	return *out
+}
+
+// enqueueMachinesForSecret returns a handler that enqueues the Machines referencing a changed Secret.
+func enqueueMachinesForSecret(c client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, secret client.Object) []reconcile.Request {
+		machines := &v1alpha1.MachineList{}
+		if err := c.List(ctx, machines, client.MatchingFields{connectionSecretKey: client.ObjectKeyFromObject(secret).String()}); err != nil {
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(machines.Items))
+		for _, m := range machines.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&m)})
+		}
+
+		return requests
+	})
+}
+
+// enqueueTasksForSecret returns a handler that enqueues the unfinished Tasks referencing a changed Secret.
+// Finished Tasks don't use their credentials anymore, so they are not enqueued.
+func enqueueTasksForSecret(c client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, secret client.Object) []reconcile.Request {
+		tasks := &v1alpha1.TaskList{}
+		if err := c.List(ctx, tasks, client.MatchingFields{connectionSecretKey: client.ObjectKeyFromObject(secret).String()}); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, t := range tasks.Items {
+			if t.Finished() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&t)})
+		}
+
+		return requests
+	})
+}
@@ -0,0 +1,77 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+	"github.com/tinkerbell/rufio/controller"
+)
+
+func TestConnectionSecretIndexFuncs(t *testing.T) {
+	clientCertTask := createTask("ClientCert", getAction("PowerOn"), createSecret())
+	clientCertTask.Spec.Connection.ClientCertSecretRef = &corev1.SecretReference{Name: "bmc-client-cert", Namespace: "default"}
+	caCertMachine := createMachine()
+	caCertMachine.Spec.Connection.CACertSecretRef = &v1alpha1.CACertReference{Name: "bmc-ca"}
+	caConfigMapMachine := createMachine()
+	caConfigMapMachine.Spec.Connection.CACertSecretRef = &v1alpha1.CACertReference{Kind: v1alpha1.CACertKindConfigMap, Name: "bmc-ca"}
+	localSecretMachine := createMachine()
+	localSecretMachine.Spec.Connection.AuthSecretRef.Namespace = ""
+
+	tests := map[string]struct {
+		obj     client.Object
+		indexer func(client.Object) []string
+		want    []string
+	}{
+		"machine auth secret": {
+			obj:     createMachine(),
+			indexer: controller.MachineSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-auth"},
+		},
+		"machine hmac secrets": {
+			obj:     createMachineWithRPC(createHMACSecret()),
+			indexer: controller.MachineSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-hmac"},
+		},
+		"task auth and client certificate secrets": {
+			obj:     clientCertTask,
+			indexer: controller.TaskSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-auth", "default/bmc-client-cert"},
+		},
+		"machine CA certificate secret": {
+			obj:     caCertMachine,
+			indexer: controller.MachineSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-auth", "test-namespace/bmc-ca"},
+		},
+		"machine CA certificate configmap": {
+			obj:     caConfigMapMachine,
+			indexer: controller.MachineSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-auth"},
+		},
+		"machine auth secret without namespace": {
+			obj:     localSecretMachine,
+			indexer: controller.MachineSecretIndexFunc,
+			want:    []string{"test-namespace/test-bm-auth"},
+		},
+		"wrong kind": {
+			obj:     createMachine(),
+			indexer: controller.TaskSecretIndexFunc,
+		},
+		"task without secrets": {
+			obj:     &v1alpha1.Task{},
+			indexer: controller.TaskSecretIndexFunc,
+			want:    []string{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.indexer(tt.obj)); diff != "" {
+				t.Fatalf("unexpected secret keys: %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// defaultMaxSELEntries is the number of SEL entries stored in the Task status when GetSELAction.MaxEntries is not set.
+const defaultMaxSELEntries = 100
+
+// selSeverities are the severities a provider may report in the description of a SEL entry.
+var selSeverities = []string{"OK", "Warning", "Critical"}
+
+// getSEL reads the System Event Log of the Machine and stores the newest entries in the Task status.
+// Providers return the entries oldest first, so the entries are reversed.
+func getSEL(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	entries, err := bmcClient.GetSystemEventLog(ctx)
+	if err != nil {
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support reading the system event log: %w", err)
+		}
+		return err
+	}
+
+	maxEntries := task.CurrentAction().GetSELAction.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxSELEntries
+	}
+
+	sel := make([]v1alpha1.SELEntry, 0, min(len(entries), maxEntries))
+	for i := len(entries) - 1; i >= 0 && len(sel) < maxEntries; i-- {
+		sel = append(sel, toSELEntry(entries[i]))
+	}
+	task.Status.SELEntries = sel
+
+	return nil
+}
+
+// toSELEntry converts a bmclib SEL entry, in ID, Timestamp, Description, Message format, to a SELEntry.
+func toSELEntry(e []string) v1alpha1.SELEntry {
+	field := func(i int) string {
+		if i < len(e) {
+			return e[i]
+		}
+		return ""
+	}
+
+	entry := v1alpha1.SELEntry{
+		ID:        field(0),
+		Timestamp: field(1),
+		Message:   field(3),
+	}
+	for _, s := range selSeverities {
+		if strings.EqualFold(field(2), s) {
+			entry.Severity = s
+		}
+	}
+
+	return entry
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// maxSensorReadings is the maximum number of sensor readings stored in the Task status.
+const maxSensorReadings = 100
+
+// redfishStatus is the status of a Redfish resource or sensor.
+type redfishStatus struct {
+	Health string `json:"Health"`
+}
+
+// redfishThermal is the part of a Redfish Thermal resource with the temperature and fan sensors of a chassis.
+type redfishThermal struct {
+	Temperatures []struct {
+		Name           string        `json:"Name"`
+		ReadingCelsius *float64      `json:"ReadingCelsius"`
+		Status         redfishStatus `json:"Status"`
+	} `json:"Temperatures"`
+	Fans []struct {
+		Name         string        `json:"Name"`
+		Reading      *float64      `json:"Reading"`
+		ReadingUnits string        `json:"ReadingUnits"`
+		Status       redfishStatus `json:"Status"`
+	} `json:"Fans"`
+}
+
+// redfishPowerVoltages is the part of a Redfish Power resource with the voltage sensors of a chassis.
+type redfishPowerVoltages struct {
+	Voltages []struct {
+		Name         string        `json:"Name"`
+		ReadingVolts *float64      `json:"ReadingVolts"`
+		Status       redfishStatus `json:"Status"`
+	} `json:"Voltages"`
+}
+
+// redfishSensor is the part of a Redfish Sensor resource, the sensors of newer BMCs.
+type redfishSensor struct {
+	Name         string        `json:"Name"`
+	ReadingType  string        `json:"ReadingType"`
+	Reading      *float64      `json:"Reading"`
+	ReadingUnits string        `json:"ReadingUnits"`
+	Status       redfishStatus `json:"Status"`
+}
+
+// sensorHealthOrder orders sensor readings by health, most severe first.
+var sensorHealthOrder = map[string]int{"Critical": 0, "Warning": 1}
+
+// getSensors reads the sensors of every Redfish chassis of the Machine and stores them in the Task status. When
+// there are more than maxSensorReadings readings, critical and warning readings are kept first and the Task
+// condition message reports how many were dropped. IPMI-only providers don't report sensors, which is returned as
+// an unsupported error.
+func getSensors(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	if err := requireRedfish(bmcClient, "sensors"); err != nil {
+		return err
+	}
+
+	var chassis redfishCollection
+	if err := redfishGet(ctx, bmcClient, opts, chassisCollectionPath, &chassis); err != nil {
+		return err
+	}
+	var readings []v1alpha1.SensorReading
+	for _, member := range chassis.Members {
+		var c redfishChassis
+		if err := redfishGet(ctx, bmcClient, opts, member.ID, &c); err != nil {
+			return err
+		}
+		r, err := getChassisSensors(ctx, bmcClient, opts, c)
+		if err != nil {
+			return err
+		}
+		readings = append(readings, r...)
+	}
+
+	slices.SortStableFunc(readings, func(a, b v1alpha1.SensorReading) int {
+		return sensorHealthRank(a.Health) - sensorHealthRank(b.Health)
+	})
+	if len(readings) > maxSensorReadings {
+		msg := fmt.Sprintf("%d of %d sensor readings stored, critical and warning readings first", maxSensorReadings, len(readings))
+		task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(msg))
+		readings = readings[:maxSensorReadings]
+	}
+	task.Status.Sensors = readings
+
+	return nil
+}
+
+// getChassisSensors reads the sensors of chassis c from its Thermal and Power resources, or from its Sensors
+// collection on newer BMCs that don't have them. Sensors without a reading are left out.
+func getChassisSensors(ctx context.Context, bmcClient *bmclib.Client, opts *BMCOptions, c redfishChassis) ([]v1alpha1.SensorReading, error) {
+	var readings []v1alpha1.SensorReading
+	if c.Thermal == nil && c.Power == nil {
+		if c.Sensors == nil {
+			return nil, nil
+		}
+		var sensors redfishCollection
+		if err := redfishGet(ctx, bmcClient, opts, c.Sensors.ID, &sensors); err != nil {
+			return nil, err
+		}
+		for _, link := range sensors.Members {
+			var s redfishSensor
+			if err := redfishGet(ctx, bmcClient, opts, link.ID, &s); err != nil {
+				return nil, err
+			}
+			if s.Reading != nil {
+				readings = append(readings, v1alpha1.SensorReading{Name: s.Name, Type: s.ReadingType, Value: *s.Reading, Units: s.ReadingUnits, Health: s.Status.Health})
+			}
+		}
+		return readings, nil
+	}
+
+	if c.Thermal != nil {
+		var thermal redfishThermal
+		if err := redfishGet(ctx, bmcClient, opts, c.Thermal.ID, &thermal); err != nil {
+			return nil, err
+		}
+		for _, t := range thermal.Temperatures {
+			if t.ReadingCelsius != nil {
+				readings = append(readings, v1alpha1.SensorReading{Name: t.Name, Type: "Temperature", Value: *t.ReadingCelsius, Units: "Cel", Health: t.Status.Health})
+			}
+		}
+		for _, f := range thermal.Fans {
+			if f.Reading != nil {
+				readings = append(readings, v1alpha1.SensorReading{Name: f.Name, Type: "Fan", Value: *f.Reading, Units: f.ReadingUnits, Health: f.Status.Health})
+			}
+		}
+	}
+	if c.Power != nil {
+		var power redfishPowerVoltages
+		if err := redfishGet(ctx, bmcClient, opts, c.Power.ID, &power); err != nil {
+			return nil, err
+		}
+		for _, v := range power.Voltages {
+			if v.ReadingVolts != nil {
+				readings = append(readings, v1alpha1.SensorReading{Name: v.Name, Type: "Voltage", Value: *v.ReadingVolts, Units: "V", Health: v.Status.Health})
+			}
+		}
+	}
+
+	return readings, nil
+}
+
+// sensorHealthRank returns the sort rank of a sensor health, unknown and OK health sort last.
+func sensorHealthRank(health string) int {
+	for h, rank := range sensorHealthOrder {
+		if strings.EqualFold(health, h) {
+			return rank
+		}
+	}
+	return len(sensorHealthOrder)
+}
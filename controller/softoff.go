@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// defaultSoftPowerOffGracePeriod is how long a SoftPowerOffAction without a GracePeriod waits before
+// falling back to a hard power off.
+const defaultSoftPowerOffGracePeriod = 5 * time.Minute
+
+// softPowerOffGracePeriod returns the grace period of a.
+func softPowerOffGracePeriod(a *v1alpha1.SoftPowerOffAction) time.Duration {
+	if a.GracePeriod == nil || a.GracePeriod.Duration <= 0 {
+		return defaultSoftPowerOffGracePeriod
+	}
+
+	return a.GracePeriod.Duration
+}
+
+// checkSoftPowerOff polls the power state of a Machine after a soft power off was requested.
+// Once the grace period since the Task started has passed and the Machine is still powered on,
+// a hard power off is issued. The Task condition message reports whether the fallback was used.
+// A non-zero Result means the Machine is not powered off yet.
+func checkSoftPowerOff(ctx context.Context, log logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client) (ctrl.Result, error) {
+	grace := softPowerOffGracePeriod(task.CurrentAction().SoftPowerOffAction)
+	fallbackMessage := fmt.Sprintf("soft power off did not complete within %s, hard power off issued", grace)
+
+	rawState, err := bmcClient.GetPowerState(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get power state: %w", err)
+	}
+	if toPowerState(rawState) == v1alpha1.Off {
+		message := "soft power off completed"
+		if task.Status.HardPowerOffFallback {
+			message = fallbackMessage
+		}
+		task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(message))
+
+		return ctrl.Result{}, nil
+	}
+
+	if task.Status.HardPowerOffFallback || time.Since(task.Status.StartTime.Time) < grace {
+		log.Info("requeuing task", "currentPowerState", rawState, "requeueAfter", powerActionRequeueAfter)
+		return ctrl.Result{RequeueAfter: powerActionRequeueAfter}, nil
+	}
+
+	if _, err := bmcClient.SetPowerState(ctx, string(v1alpha1.PowerHardOff)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to perform hard power off fallback: %w", err)
+	}
+	task.Status.HardPowerOffFallback = true
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(fallbackMessage))
+	md := bmcClient.GetMetadata()
+	log.Info("soft power off timed out, hard power off issued", "gracePeriod", grace, "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+
+	return ctrl.Result{RequeueAfter: powerActionRequeueAfter}, nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+const (
+	taskSuspendedMessage = "Task is suspended, unset spec.suspend to resume it"
+	jobSuspendedMessage  = "Job is suspended, unset spec.suspend to resume it"
+)
+
+// reconcileTaskSuspend sets the Suspended condition of task to its Spec.Suspend, when it changed. It returns
+// true when task is suspended and must not be processed any further.
+func (r *TaskReconciler) reconcileTaskSuspend(ctx context.Context, task *v1alpha1.Task) (bool, error) {
+	if task.Spec.Suspend == task.HasCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionTrue) {
+		return task.Spec.Suspend, nil
+	}
+
+	taskPatch := client.MergeFrom(task.DeepCopy())
+	if task.Spec.Suspend {
+		// The status of an action in flight is no longer checked, so the Task is no longer Running.
+		task.RemoveCondition(v1alpha1.TaskRunning)
+		task.SetCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(taskSuspendedMessage))
+	} else {
+		resumeTaskStartTime(task)
+		task.SetCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionFalse)
+	}
+	task.Status.ObservedGeneration = task.Generation
+	if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+		return false, err
+	}
+
+	return task.Spec.Suspend, nil
+}
+
+// resumeTaskStartTime moves the StartTime of a started task forward by the time it was suspended, so that the
+// suspension doesn't count toward its timeout and the durations measured from its StartTime.
+func resumeTaskStartTime(task *v1alpha1.Task) {
+	if task.Status.StartTime.IsZero() {
+		return
+	}
+	for _, c := range task.Status.Conditions {
+		if c.Type == v1alpha1.TaskSuspended && c.Status == v1alpha1.ConditionTrue && c.LastTransitionTime != nil {
+			start := metav1.NewTime(task.Status.StartTime.Add(time.Since(c.LastTransitionTime.Time)))
+			task.Status.StartTime = &start
+			return
+		}
+	}
+}
+
+// reconcileJobSuspend sets the Suspended condition of job to its Spec.Suspend, when it changed. It returns
+// true when job is suspended and must not create its next Task.
+func (r *JobReconciler) reconcileJobSuspend(ctx context.Context, job *v1alpha1.Job) (bool, error) {
+	if job.Spec.Suspend == job.HasCondition(v1alpha1.JobSuspended, v1alpha1.ConditionTrue) {
+		return job.Spec.Suspend, nil
+	}
+
+	jobPatch := client.MergeFrom(job.DeepCopy())
+	if job.Spec.Suspend {
+		job.SetCondition(v1alpha1.JobSuspended, v1alpha1.ConditionTrue, v1alpha1.WithJobConditionMessage(jobSuspendedMessage))
+	} else {
+		job.SetCondition(v1alpha1.JobSuspended, v1alpha1.ConditionFalse)
+	}
+	if err := r.patchStatus(ctx, job, jobPatch); err != nil {
+		return false, err
+	}
+
+	return job.Spec.Suspend, nil
+}
@@ -15,36 +15,196 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/bmc"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 )
 
-const powerActionRequeueAfter = 3 * time.Second
+const (
+	powerActionRequeueAfter = 3 * time.Second
+	// defaultTaskTimeout is how long a Task without a Spec.Timeout may run before it is failed.
+	defaultTaskTimeout = 10 * time.Minute
+)
 
 // TaskReconciler reconciles a Task object.
 type TaskReconciler struct {
 	client           client.Client
+	recorder         record.EventRecorder
 	bmcClientFactory ClientFunc
+	// clientCache, when set, is used to reuse open BMC connections instead of opening one per reconcile.
+	clientCache *ClientCache
+	// hostLimiter, when set, limits the concurrent reconciles against a single BMC host.
+	hostLimiter *HostLimiter
+	// bmcLeaser, when set, serializes the reconciles against a single BMC host across controller replicas.
+	bmcLeaser *BMCLeaser
+	// hostBreaker, when set, fails Tasks fast while the BMC host had too many consecutive connection failures.
+	hostBreaker *HostBreaker
+	// inflightLimiter, when set, limits the total number of Tasks executing BMC operations.
+	inflightLimiter *InflightLimiter
+	// credentialProviders are the credential providers that Connection.AuthProviderRef can reference, by name.
+	credentialProviders map[string]CredentialProvider
+	// apiReader, when set, is used to re-read the auth Secret without the informer cache after an authentication error.
+	apiReader client.Reader
+	// failureRequeueInterval, when set, is the wait before a Task that failed with a transient error is run again.
+	failureRequeueInterval time.Duration
+	// failureRequeueWindow is how long after its creation a Task is requeued on transient errors before it fails permanently.
+	failureRequeueWindow time.Duration
+	// defaultBMCTimeout, when set, bounds each BMC operation of a Task without a Spec.Timeout.
+	defaultBMCTimeout time.Duration
+	// requeueJitter is the fraction of the requeue interval that is added at random to spread out status polling.
+	requeueJitter float64
+	// capabilitiesInterval, when set, is how long the capabilities detected on a Machine are trusted to fail
+	// Tasks of Jobs for the Machine without connecting to the BMC.
+	capabilitiesInterval time.Duration
+	// powerVerificationWindow, when set, is how long after a PowerAction of on, off, soft or cycle the observed
+	// power state may differ from the requested one before the Task is failed.
+	powerVerificationWindow time.Duration
+	// sessionKeepaliveInterval, when set, is how often the BMC is pinged while a long running action is in
+	// progress, to keep the BMC session from timing out.
+	sessionKeepaliveInterval time.Duration
+	// duplicatePolicy is what is done with a Task identical to a running Task, by default they run independently.
+	duplicatePolicy DuplicateTaskPolicy
+	// shutdownDrain is how long a reconcile in flight may continue once the controller shuts down.
+	shutdownDrain time.Duration
+	// httpConfig configures the HTTP requests to the BMCs.
+	httpConfig HTTPClientConfig
+	// operations are the BMC operations in flight, cancelled when a Task with the abandon DeletePolicy is deleted.
+	operations taskOperations
 }
 
 // NewTaskReconciler returns a new TaskReconciler.
-func NewTaskReconciler(c client.Client, bmcClientFactory ClientFunc) *TaskReconciler {
+func NewTaskReconciler(c client.Client, recorder record.EventRecorder, bmcClientFactory ClientFunc) *TaskReconciler {
 	return &TaskReconciler{
 		client:           c,
+		recorder:         recorder,
 		bmcClientFactory: bmcClientFactory,
 	}
 }
 
+// WithClientCache makes the reconciler reuse open BMC connections from cache.
+func (r *TaskReconciler) WithClientCache(cache *ClientCache) *TaskReconciler {
+	r.clientCache = cache
+	return r
+}
+
+// WithCredentialProviders makes the reconciler resolve a Connection.AuthProviderRef with providers, by name.
+func (r *TaskReconciler) WithCredentialProviders(providers map[string]CredentialProvider) *TaskReconciler {
+	r.credentialProviders = providers
+	return r
+}
+
+// WithHostLimiter makes the reconciler requeue a Task while its BMC host is at the concurrency limit of limiter.
+func (r *TaskReconciler) WithHostLimiter(limiter *HostLimiter) *TaskReconciler {
+	r.hostLimiter = limiter
+	return r
+}
+
+// WithBMCLeaser makes the reconciler requeue a Task while another controller replica holds the Lease of its BMC host.
+func (r *TaskReconciler) WithBMCLeaser(leaser *BMCLeaser) *TaskReconciler {
+	r.bmcLeaser = leaser
+	return r
+}
+
+// WithHostBreaker makes the reconciler record the connection failures of BMC hosts in breaker, and fail a Task
+// without connecting while the circuit of its BMC host is open.
+func (r *TaskReconciler) WithHostBreaker(breaker *HostBreaker) *TaskReconciler {
+	r.hostBreaker = breaker
+	return r
+}
+
+// WithInflightLimiter makes the reconciler set a Task Pending and requeue it while limiter is at its limit.
+func (r *TaskReconciler) WithInflightLimiter(limiter *InflightLimiter) *TaskReconciler {
+	r.inflightLimiter = limiter
+	return r
+}
+
+// WithRequeueJitter makes the reconciler add up to fraction of the requeue interval at random when it polls
+// the status of a Task or waits for a busy BMC host, so that many Tasks don't poll at the same time.
+func (r *TaskReconciler) WithRequeueJitter(fraction float64) *TaskReconciler {
+	r.requeueJitter = fraction
+	return r
+}
+
+// WithFailureRequeue makes the reconciler requeue a Task that failed with a transient error after interval,
+// instead of failing it, until window has elapsed since the Task was created.
+func (r *TaskReconciler) WithFailureRequeue(interval, window time.Duration) *TaskReconciler {
+	r.failureRequeueInterval = interval
+	r.failureRequeueWindow = window
+	return r
+}
+
+// WithDefaultBMCTimeout sets the timeout of each BMC operation of a Task without a Spec.Timeout.
+// Opening the connection, running or checking the action and closing the connection are bounded separately.
+func (r *TaskReconciler) WithDefaultBMCTimeout(timeout time.Duration) *TaskReconciler {
+	r.defaultBMCTimeout = timeout
+	return r
+}
+
+// WithCapabilitiesInterval makes the reconciler fail a Task of a Job right away when its action requires a
+// capability missing from the capabilities of the Machine of the Job detected less than interval ago.
+func (r *TaskReconciler) WithCapabilitiesInterval(interval time.Duration) *TaskReconciler {
+	r.capabilitiesInterval = interval
+	return r
+}
+
+// WithPowerVerificationWindow makes the reconciler store the power state observed after a PowerAction of on, off,
+// soft or cycle in the Task status, and fail the Task when it doesn't match the requested state within window.
+func (r *TaskReconciler) WithPowerVerificationWindow(window time.Duration) *TaskReconciler {
+	r.powerVerificationWindow = window
+	return r
+}
+
+// WithSessionKeepalive makes the reconciler ping the BMC every interval while a long running action, for example
+// a VirtualMediaAction, is in progress, so that the BMC does not close the session for being idle.
+func (r *TaskReconciler) WithSessionKeepalive(interval time.Duration) *TaskReconciler {
+	r.sessionKeepaliveInterval = interval
+	return r
+}
+
+// WithDuplicatePolicy sets what the reconciler does with a Task that has not started yet when an identical Task,
+// with the same actions against the same BMC host, is running. The reconciler must be set up with the manager
+// to index Tasks by host and actions.
+func (r *TaskReconciler) WithDuplicatePolicy(policy DuplicateTaskPolicy) *TaskReconciler {
+	r.duplicatePolicy = policy
+	return r
+}
+
+// WithShutdownDrain lets a reconcile in flight continue for period once the controller shuts down, so BMC
+// operations are not cut off halfway. A reconcile still running after period is cancelled and its Task is
+// left to be reconciled again, instead of failed. The manager must wait at least period for reconciles.
+func (r *TaskReconciler) WithShutdownDrain(period time.Duration) *TaskReconciler {
+	r.shutdownDrain = period
+	return r
+}
+
+// WithAPIReader makes the reconciler re-read the auth Secret with reader, usually the uncached API reader of
+// the manager, when the BMC rejects the credentials.
+func (r *TaskReconciler) WithAPIReader(reader client.Reader) *TaskReconciler {
+	r.apiReader = reader
+	return r
+}
+
+// WithHTTPClientConfig makes the reconciler send the HTTP requests to BMCs with config, for example with its
+// User-Agent and request timeout.
+func (r *TaskReconciler) WithHTTPClientConfig(config HTTPClientConfig) *TaskReconciler {
+	r.httpConfig = config
+	return r
+}
+
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=tasks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=tasks/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=tasks/finalizers,verbs=update
@@ -52,9 +212,15 @@ func NewTaskReconciler(c client.Client, bmcClientFactory ClientFunc) *TaskReconc
 // Reconcile runs a Task.
 // Establishes a connection to the BMC.
 // Runs the specified action in the Task.
-func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	logger := ctrl.LoggerFrom(ctx).WithName("controllers/Task").WithValues("task", req.NamespacedName)
 	logger.Info("Reconciling Task")
+	// A panic, like one of a provider on a malformed BMC response, fails the Task instead of crashing the manager.
+	defer r.recoverTaskPanic(ctx, logger, req.NamespacedName, &result, &err)
+
+	// A reconcile in flight when the controller shuts down may finish within the drain period.
+	ctx, cancelDrain := drainContext(ctx, r.shutdownDrain)
+	defer cancelDrain()
 
 	// Fetch the Task object
 	task := &v1alpha1.Task{}
@@ -67,54 +233,257 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, err
 	}
 
-	// Deletion is a noop.
-	if !task.DeletionTimestamp.IsZero() {
-		return ctrl.Result{}, nil
+	// With the wait DeletePolicy, the status of the action of a deleted Task that has started is checked until
+	// it has finished, as a soft power off, a power cycle or a firmware update keep going on the BMC. With the
+	// abandon DeletePolicy, the BMC operation in flight was cancelled and the finalizer is removed right away.
+	if !task.DeletionTimestamp.IsZero() && (task.Spec.DeletePolicy == v1alpha1.DeletePolicyAbandon || !actionInFlight(task)) {
+		return ctrl.Result{}, r.removeFinalizer(ctx, task)
 	}
 
-	// Task is Completed or Failed is noop.
-	if task.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) ||
-		task.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
-		return ctrl.Result{}, nil
+	// Task is Completed, Failed or Unsupported only needs to be cleaned up.
+	if task.Finished() {
+		if err := r.removeFinalizer(ctx, task); err != nil {
+			return ctrl.Result{}, err
+		}
+		return r.cleanupFinished(ctx, logger, task)
+	}
+
+	if err := r.addFinalizer(ctx, task); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A suspended Task is skipped until its Suspend is unset, which changes the Task and reconciles it again.
+	// A deleted Task waiting for its action in flight to finish keeps checking it.
+	if task.DeletionTimestamp.IsZero() {
+		suspended, err := r.reconcileTaskSuspend(ctx, task)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if suspended {
+			logger.Info("task is suspended, skipping")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// A Task referencing a Machine runs against the Connection of the Machine. It is resolved before the patch is
+	// created, so that the status patches don't write it to the Task.
+	if err := r.resolveMachineRef(ctx, task); err != nil {
+		if isTerminal(err) {
+			return r.failTask(ctx, task, client.MergeFrom(task.DeepCopy()), err)
+		}
+		logger.Error(err, "Failed to resolve the Machine of the Task")
+		return ctrl.Result{}, err
 	}
 
 	// Create a patch from the initial Task object
 	// Patch is used to update Status after reconciliation
 	taskPatch := client.MergeFrom(task.DeepCopy())
-	logger = logger.WithValues("action", task.Spec.Task, "host", task.Spec.Connection.Host)
+	logger = logger.WithValues("action", actionType(task.CurrentAction())).WithValues(connectionLogValues(task.Spec.Connection)...)
+	ctx = ctrl.LoggerInto(ctx, logger)
+
+	// Status patched during this reconcile reflects the current generation of the spec.
+	task.Status.ObservedGeneration = task.Generation
+
+	// A Task that has not run yet may duplicate a running Task, multiplying the load on the BMC.
+	if r.duplicatePolicy == DuplicateTaskPolicyDefer || r.duplicatePolicy == DuplicateTaskPolicySkip {
+		if task.Status.StartTime.IsZero() && task.Status.Attempts == 0 && task.Status.ActionIndex == 0 {
+			running, err := r.runningDuplicate(ctx, task)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if running != nil && r.duplicatePolicy == DuplicateTaskPolicySkip {
+				logger.Info("identical task is running, skipping", "runningTask", client.ObjectKeyFromObject(running))
+				task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(duplicateTaskMessage(running)))
+				return ctrl.Result{}, r.completeTask(ctx, task, taskPatch)
+			}
+			if running != nil {
+				logger.Info("identical task is running, requeueing", "runningTask", client.ObjectKeyFromObject(running))
+				task.SetCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("waiting for identical Task %s to finish", client.ObjectKeyFromObject(running))))
+				if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+					return ctrl.Result{}, err
+				}
+				observeRequeue(requeueReasonConcurrencyLimited)
+				return ctrl.Result{RequeueAfter: jitter(duplicateTaskRequeueAfter, r.requeueJitter)}, nil
+			}
+		}
+	}
+
+	// Only a limited number of Tasks may execute BMC operations at a time, the others are left Pending.
+	key := client.ObjectKeyFromObject(task).String()
+	if !r.inflightLimiter.TryAcquirePriority(key, task.Spec.Priority) {
+		logger.Info("maximum number of tasks in flight reached, requeueing")
+		if !task.HasCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue) {
+			task.SetCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage("waiting for the number of tasks in flight to drop below the limit"))
+			if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		observeRequeue(requeueReasonConcurrencyLimited)
+		return ctrl.Result{RequeueAfter: jitter(inflightFullRequeueAfter, r.requeueJitter)}, nil
+	}
+	defer r.inflightLimiter.Release()
+	if task.HasCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue) {
+		task.SetCondition(v1alpha1.TaskPending, v1alpha1.ConditionFalse)
+	}
+
+	// Only a limited number of operations may run against a single BMC at a time.
+	if !r.hostLimiter.TryAcquirePriority(task.Spec.Connection.Host, key, task.Spec.Priority) {
+		logger.Info("BMC host is at the concurrency limit, requeueing")
+		observeRequeue(requeueReasonConcurrencyLimited)
+		return ctrl.Result{RequeueAfter: jitter(hostBusyRequeueAfter, r.requeueJitter)}, nil
+	}
+	defer r.hostLimiter.Release(task.Spec.Connection.Host)
+
+	// Across controller replicas, only the one holding the Lease of the BMC host operates on it.
+	if ok, err := r.bmcLeaser.TryAcquire(ctx, task.Spec.Connection.Host); err != nil {
+		return ctrl.Result{}, err
+	} else if !ok {
+		logger.Info("BMC host is leased by another controller replica, requeueing")
+		observeRequeue(requeueReasonConcurrencyLimited)
+		return ctrl.Result{RequeueAfter: jitter(hostBusyRequeueAfter, r.requeueJitter)}, nil
+	}
+	defer r.bmcLeaser.Release(task.Spec.Connection.Host)
+
+	tasksInFlight.Inc()
+	defer tasksInFlight.Dec()
 
 	return r.doReconcile(ctx, task, taskPatch, logger)
 }
 
 func (r *TaskReconciler) doReconcile(ctx context.Context, task *v1alpha1.Task, taskPatch client.Patch, logger logr.Logger) (ctrl.Result, error) {
+	if task.Status.StartTime.IsZero() {
+		if err := r.checkMachineCapabilities(ctx, logger, task); err != nil {
+			return r.failTask(ctx, task, taskPatch, err)
+		}
+	}
+
 	var username, password string
 	opts := &BMCOptions{
 		ProviderOptions: task.Spec.Connection.ProviderOptions,
+		insecureTLS:     task.Spec.Connection.InsecureTLS,
+		port:            task.Spec.Connection.Port,
+		httpConfig:      r.httpConfig,
+	}
+	if task.Spec.Connection.IPMIOptions != nil {
+		opts.ipmiCipherSuite = task.Spec.Connection.IPMIOptions.CipherSuite
+	}
+	proxyURL, err := parseProxyURL(task.Spec.Connection.ProxyURL)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("resolving proxy URL for task %s/%s: %w", task.Namespace, task.Name, err)
+	}
+	opts.proxyURL = proxyURL
+	if task.Spec.Connection.ClientCertSecretRef != nil {
+		cert, err := resolveClientCertificate(ctx, r.client, *task.Spec.Connection.ClientCertSecretRef, task.Namespace)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("resolving client certificate for task %s/%s: %w", task.Namespace, task.Name, err)
+		}
+		opts.clientCert = cert
+	}
+	if task.Spec.Connection.CACertSecretRef != nil {
+		certs, err := resolveCACertificates(ctx, r.client, *task.Spec.Connection.CACertSecretRef, task.Namespace)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("resolving CA certificate for task %s/%s: %w", task.Namespace, task.Name, err)
+		}
+		opts.caCerts = certs
 	}
 	if task.Spec.Connection.ProviderOptions != nil && task.Spec.Connection.ProviderOptions.RPC != nil {
 		opts.ProviderOptions = task.Spec.Connection.ProviderOptions
 		if len(task.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets) > 0 {
-			se, err := retrieveHMACSecrets(ctx, r.client, task.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets)
+			se, err := retrieveHMACSecrets(ctx, r.client, task.Spec.Connection.ProviderOptions.RPC.HMAC.Secrets, task.Namespace)
 			if err != nil {
 				return ctrl.Result{}, fmt.Errorf("unable to get hmac secrets: %w", err)
 			}
 			opts.rpcSecrets = se
 		}
 	} else {
-		// Fetching username, password from SecretReference in Connection.
-		// Requeue if error fetching secret
+		// Fetching username, password from the SecretReference or credential provider in Connection.
+		// Requeue if error fetching credentials
 		var err error
-		username, password, err = resolveAuthSecretRef(ctx, r.client, task.Spec.Connection.AuthSecretRef)
+		username, password, err = resolveCredentials(ctx, r.client, r.credentialProviders, task.Spec.Connection, task.Namespace)
 		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("resolving connection secret for task %s/%s: %w", task.Namespace, task.Name, err)
+			return ctrl.Result{}, fmt.Errorf("resolving connection credentials for task %s/%s: %w", task.Namespace, task.Name, err)
 		}
 	}
 
+	// bmcCtx bounds all BMC operations, including opening the connection, by the Task timeout.
+	// Status patches keep using ctx so that a timed out Task can still be marked failed.
+	// bmcCtx is also cancelled when the Task is deleted with the abandon DeletePolicy.
+	bmcCtx, done := r.operations.start(ctx, client.ObjectKeyFromObject(task))
+	defer done()
+	// bmcCtx is also cancelled when the Lease of the BMC host is lost to another controller replica.
+	bmcCtx, releaseLease := r.bmcLeaser.Context(bmcCtx, task.Spec.Connection.Host)
+	defer releaseLease()
+	timeout := defaultTaskTimeout
+	if task.Spec.Timeout != nil {
+		timeout = task.Spec.Timeout.Duration
+		var cancel context.CancelFunc
+		bmcCtx, cancel = context.WithTimeout(bmcCtx, timeout)
+		defer cancel()
+	}
+
+	if task.Spec.Connection.InsecureTLS {
+		r.recorder.Eventf(task, corev1.EventTypeWarning, insecureTLSEventReason, insecureTLSEventMessage, task.Spec.Connection.Host)
+	}
+
+	// BMC hosts that keep failing to connect are not attempted until their cooldown passed.
+	if err := r.hostBreaker.Allow(task.Spec.Connection.Host); err != nil {
+		logger.Info("BMC host circuit is open, failing task", "error", err.Error())
+		return r.failTask(ctx, task, taskPatch, err)
+	}
+
 	// Initializing BMC Client
-	bmcClient, err := r.bmcClientFactory(ctx, logger, task.Spec.Connection.Host, username, password, opts)
+	openCtx, cancelOpen := r.bmcOperationContext(bmcCtx, task)
+	defer cancelOpen()
+	bmcClient, err := openClient(openCtx, logger, r.clientCache, r.bmcClientFactory, task.Spec.Connection.Host, username, password, opts)
+	if retryAfter, ok := loginRetryAfter(err); ok {
+		logger.Info("BMC login rate limit reached, requeueing", "requeueAfter", retryAfter)
+		observeRequeue(requeueReasonRateLimited)
+		return ctrl.Result{RequeueAfter: jitter(retryAfter, r.requeueJitter)}, nil
+	}
+	if err != nil && isAuthError(err) && usesAuthSecret(task.Spec.Connection) {
+		// The credentials may have been rotated since the Secret was read, retry once with the current ones.
+		if u, p, changed := r.refreshCredentials(ctx, logger, task, username, password); changed {
+			username, password = u, p
+			bmcClient, err = openClient(openCtx, logger, r.clientCache, r.bmcClientFactory, task.Spec.Connection.Host, username, password, opts)
+		}
+	}
 	if err != nil {
-		logger.Error(err, "BMC connection failed", "host", task.Spec.Connection.Host)
-		task.SetCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("Failed to connect to BMC: %v", err)))
+		err = r.defaultTimeoutError(openCtx, err)
+		if bmcLeaseLost(bmcCtx) {
+			return r.requeueBMCLeaseLost(logger, err)
+		}
+		if waitingForBMCReset(task) && !isAuthError(err) {
+			// The BMC is unreachable while it resets, which doesn't count as a failure of the BMC host.
+			return r.requeueBMCReset(ctx, logger, task, taskPatch, timeout, err)
+		}
+		logger.Error(err, "BMC connection failed")
+		// Rejected credentials, providers disabled by the controller or a cancelled reconcile don't tell whether the
+		// BMC host is reachable.
+		if !isAuthError(err) && !errors.Is(err, errProvidersDisabled) && !errors.Is(err, context.Canceled) {
+			r.hostBreaker.Failure(task.Spec.Connection.Host)
+		}
+		if timeoutErr := taskTimeoutError(bmcCtx, timeout, err); timeoutErr != nil {
+			return r.failTask(ctx, task, taskPatch, timeoutErr)
+		}
+		task.Status.ProviderErrors = providerErrorsOf(err)
+		if requeueAfter, ok := r.failureRequeueAfter(task, err); ok {
+			logger.Info("requeueing task after transient error", "error", err.Error(), "requeueAfter", requeueAfter)
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("Failed to connect to BMC, retrying in %s: %v", requeueAfter, err)), v1alpha1.WithTaskConditionReason(conditionReason(failureReason(err))))
+			if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			observeRequeue(requeueReasonTransientError)
+			return ctrl.Result{RequeueAfter: requeueAfter}, nil
+		}
+		if shutdownDrainExpired(ctx) {
+			return ctrl.Result{}, err
+		}
+		message := fmt.Sprintf("Failed to connect to BMC: %v%s", err, cipherSuiteHint(err, task.Spec.Connection))
+		if isAuthError(err) {
+			message = fmt.Sprintf("Authentication failed, the BMC rejected the credentials: %v", err)
+		}
+		r.setTaskFailed(task, message, err)
 		patchErr := r.patchStatus(ctx, task, taskPatch)
 		if patchErr != nil {
 			return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
@@ -122,86 +491,264 @@ func (r *TaskReconciler) doReconcile(ctx context.Context, task *v1alpha1.Task, t
 
 		return ctrl.Result{}, err
 	}
+	r.hostBreaker.Success(task.Spec.Connection.Host)
+	// bmcErr is the error of using the BMC connection, a cached connection is discarded on authentication errors.
+	var bmcErr error
 	defer func() {
-		// Close BMC connection after reconciliation
-		if err := bmcClient.Close(ctx); err != nil {
-			md := bmcClient.GetMetadata()
-			logger.Error(err, "BMC close connection failed", "providersAttempted", md.ProvidersAttempted)
+		// Close or release the BMC connection after reconciliation
+		closeCtx, cancelClose := r.bmcOperationContext(ctx, task)
+		defer cancelClose()
+		releaseClient(closeCtx, logger, r.clientCache, bmcClient, bmcErr)
+	}()
+	logger = withProviders(logger, bmcClient.GetMetadata().SuccessfulOpenConns)
+	ctx = ctrl.LoggerInto(ctx, logger)
 
-			return
+	if task.Spec.DryRun {
+		// A dry run only checks the connection and the capabilities of the providers, no action is run.
+		actionCtx, cancelAction := r.bmcOperationContext(bmcCtx, task)
+		defer cancelAction()
+		if err := dryRunTask(actionCtx, task, bmcClient); err != nil {
+			bmcErr = r.defaultTimeoutError(actionCtx, err)
+			return r.failTask(ctx, task, taskPatch, bmcErr)
 		}
-		md := bmcClient.GetMetadata()
-		logger.Info("BMC connection closed", "successfulCloseConns", md.SuccessfulCloseConns, "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
-	}()
+		task.Status.Provider = successfulProvider(bmcClient.GetMetadata())
+		return ctrl.Result{}, r.completeTask(ctx, task, taskPatch)
+	}
 
-	// Task has StartTime, we check the status.
-	// Requeue if actions did not complete.
-	if !task.Status.StartTime.IsZero() {
-		jobRunningTime := time.Since(task.Status.StartTime.Time)
-		// TODO(pokearu): add timeout for tasks on API spec
-		if jobRunningTime >= 10*time.Minute {
-			timeOutErr := fmt.Errorf("bmc task timeout: %d", jobRunningTime)
-			// Set Task Condition Failed True
-			task.SetCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(timeOutErr.Error()))
-			patchErr := r.patchStatus(ctx, task, taskPatch)
-			if patchErr != nil {
-				return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, timeOutErr})
+	for {
+		// actionCtx bounds running or checking the action.
+		actionCtx, cancelAction := r.bmcOperationContext(bmcCtx, task)
+		defer cancelAction() //nolint:gocritic // Actions has at most 20 actions, the contexts are cancelled on return.
+
+		// Task has StartTime, we check the status.
+		// Requeue if actions did not complete.
+		if !task.Status.StartTime.IsZero() {
+			jobRunningTime := time.Since(task.Status.StartTime.Time)
+			if jobRunningTime >= timeout {
+				return r.failTask(ctx, task, taskPatch, fmt.Errorf("task exceeded timeout %s", timeout))
 			}
 
-			return ctrl.Result{}, timeOutErr
-		}
+			result, err := r.checkTaskStatus(actionCtx, logger, task, bmcClient, opts)
+			if err != nil {
+				err = r.defaultTimeoutError(actionCtx, err)
+				bmcErr = err
+				if bmcLeaseLost(bmcCtx) {
+					return r.requeueBMCLeaseLost(logger, err)
+				}
+				// Retrying with rejected credentials may lock the BMC account.
+				if isTerminal(err) || isAuthError(err) {
+					return r.failTask(ctx, task, taskPatch, err)
+				}
+				if timeoutErr := taskTimeoutError(bmcCtx, timeout, err); timeoutErr != nil {
+					return r.failTask(ctx, task, taskPatch, timeoutErr)
+				}
+				observeRequeue(requeueReasonTransientError)
+				return result, fmt.Errorf("bmc task status check: %w", err)
+			}
 
-		result, err := r.checkTaskStatus(ctx, logger, task.Spec.Task, bmcClient)
-		if err != nil {
-			return result, fmt.Errorf("bmc task status check: %w", err)
-		}
+			if !result.IsZero() {
+				if task.CurrentAction().ResetBMCAction != nil {
+					// The connection may be to the BMC before it went down for the reset.
+					bmcErr = errBMCReset
+				}
+				// Status checks may record progress, for example a soft power off falling back to a hard power off.
+				if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+					return ctrl.Result{}, err
+				}
+				result.RequeueAfter = jitter(result.RequeueAfter, r.requeueJitter)
+				return result, nil
+			}
 
-		if !result.IsZero() {
-			return result, nil
+			next := task.Status.ActionIndex + 1
+			if next >= len(task.Spec.Actions) {
+				return result, r.completeTask(ctx, task, taskPatch)
+			}
+			// The remaining actions of a deleted Task are not run.
+			if !task.DeletionTimestamp.IsZero() {
+				logger.Info("task deleted, not running the remaining actions", "actionIndex", task.Status.ActionIndex)
+				task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("Task deleted after action %d of %d", next, len(task.Spec.Actions))))
+				return result, r.completeTask(ctx, task, taskPatch)
+			}
+			// Run the next action of Actions over the same BMC connection.
+			logger.Info("action completed, running the next action", "actionIndex", next, "nextAction", actionType(task.Spec.Actions[next]))
+			task.Status.ActionIndex = next
+			task.Status.StartTime = nil
+			task.Status.Attempts = 0
+			task.Status.FirstAttemptTime = nil
 		}
 
-		// Set the Task CompletionTime
+		logger.Info("new task run")
+		recordTaskEvent(r.recorder, task, corev1.EventTypeNormal, taskStartedEventReason, fmt.Sprintf("task started, attempt %d", task.Status.Attempts+1))
+
+		// Set the Task StartTime
 		now := metav1.Now()
-		task.Status.CompletionTime = &now
-		// Set Task Condition Completed True
-		task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)
+		task.Status.StartTime = &now
+		if task.Status.Attempts == 0 {
+			task.Status.FirstAttemptTime = &now
+		}
+		task.Status.Attempts++
+		// Clear the message and reason of a previous requeued attempt.
+		if task.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse) {
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(""), v1alpha1.WithTaskConditionReason(""))
+		}
+		// Persist the Running condition before the provider operation, which may take minutes.
+		task.SetCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue)
 		if err := r.patchStatus(ctx, task, taskPatch); err != nil {
-			return result, err
+			return ctrl.Result{}, err
+		}
+		// Later patches must be computed from the persisted status, so that StartTime can be reset on retries.
+		taskPatch = client.MergeFrom(task.DeepCopy())
+		// run the specified Task in Task
+		stopKeepalive := func() {}
+		if r.sessionKeepaliveInterval > 0 && longRunningAction(task.CurrentAction()) {
+			stopKeepalive = startSessionKeepalive(actionCtx, logger, bmcClient, r.sessionKeepaliveInterval)
 		}
+		err = r.runTask(actionCtx, logger, task, bmcClient, opts)
+		stopKeepalive()
+		if err != nil {
+			md := bmcClient.GetMetadata()
+			err = withProviderErrors(r.defaultTimeoutError(actionCtx, err), md.ProvidersAttempted, md.FailedProviderDetail)
+			bmcErr = err
+			logger.Info("failed to perform action", "providersAttempted", md.ProvidersAttempted, "attempt", task.Status.Attempts)
+			if bmcLeaseLost(bmcCtx) {
+				// Leave StartTime unset so the action is run again once the Lease is taken back.
+				task.Status.StartTime = nil
+				task.RemoveCondition(v1alpha1.TaskRunning)
+				if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+					return ctrl.Result{}, err
+				}
 
-		return result, nil
-	}
+				return r.requeueBMCLeaseLost(logger, err)
+			}
+			if timeoutErr := taskTimeoutError(bmcCtx, timeout, err); timeoutErr != nil {
+				return r.failTask(ctx, task, taskPatch, timeoutErr)
+			}
 
-	logger.Info("new task run")
+			if isAuthError(err) && usesAuthSecret(task.Spec.Connection) {
+				if _, _, changed := r.refreshCredentials(ctx, logger, task, username, password); changed {
+					// Leave StartTime unset so the action is run again with the rotated credentials.
+					task.Status.StartTime = nil
+					task.RemoveCondition(v1alpha1.TaskRunning)
+					if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+						return ctrl.Result{}, err
+					}
 
-	// Set the Task StartTime
-	now := metav1.Now()
-	task.Status.StartTime = &now
-	// run the specified Task in Task
-	if err := r.runTask(ctx, logger, task.Spec.Task, bmcClient); err != nil {
-		md := bmcClient.GetMetadata()
-		logger.Info("failed to perform action", "providersAttempted", md.ProvidersAttempted, "action", task.Spec.Task)
-		// Set Task Condition Failed True
-		task.SetCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(err.Error()))
-		patchErr := r.patchStatus(ctx, task, taskPatch)
-		if patchErr != nil {
-			return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
+					return ctrl.Result{Requeue: true}, nil
+				}
+			}
+
+			if backoff, ok := retryBackoff(task, err); ok {
+				if exhausted := retryBudgetExhausted(task, backoff, err); exhausted != nil {
+					return r.failTask(ctx, task, taskPatch, exhausted)
+				}
+				// Leave StartTime unset so the action is run again on the next reconcile.
+				task.Status.StartTime = nil
+				task.RemoveCondition(v1alpha1.TaskRunning)
+				logger.Info("retrying action after transient error", "error", err.Error(), "requeueAfter", backoff)
+				if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+					return ctrl.Result{}, err
+				}
+
+				observeRequeue(requeueReasonTransientError)
+				return ctrl.Result{RequeueAfter: backoff}, nil
+			}
+
+			if requeueAfter, ok := r.failureRequeueAfter(task, err); ok {
+				// Leave StartTime unset so the action is run again after the requeue interval.
+				task.Status.StartTime = nil
+				task.RemoveCondition(v1alpha1.TaskRunning)
+				logger.Info("requeueing task after transient error", "error", err.Error(), "requeueAfter", requeueAfter)
+				task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(fmt.Sprintf("Action failed, retrying in %s: %v", requeueAfter, err)), v1alpha1.WithTaskConditionReason(conditionReason(failureReason(err))))
+				if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+					return ctrl.Result{}, err
+				}
+
+				observeRequeue(requeueReasonTransientError)
+				return ctrl.Result{RequeueAfter: requeueAfter}, nil
+			}
+
+			return r.failTask(ctx, task, taskPatch, err)
+		}
+		task.Status.Provider = successfulProvider(bmcClient.GetMetadata())
+		if task.CurrentAction().ResetBMCAction != nil {
+			bmcErr = errBMCReset
 		}
 
-		return ctrl.Result{}, err
+		if len(task.Spec.Actions) > 0 {
+			// The actions of Actions are checked right away, over the same BMC connection.
+			continue
+		}
+
+		// The BMC confirmed the credentials or network change, or its reset. The Connection may authenticate with
+		// the changed account, or the BMC may not be reachable at its host anymore, so the Task is completed right
+		// away instead of reconnecting to check its status.
+		if task.Spec.Task.SetBMCCredentialsAction != nil || task.Spec.Task.SetBMCNetworkAction != nil ||
+			(task.Spec.Task.ResetBMCAction != nil && !task.Spec.Task.ResetBMCAction.WaitForReady) {
+			return ctrl.Result{}, r.completeTask(ctx, task, taskPatch)
+		}
+
+		if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
 	}
+}
 
-	if err := r.patchStatus(ctx, task, taskPatch); err != nil {
-		return ctrl.Result{}, err
+// successfulProvider returns the provider that performed the last successful call of md. The actions the
+// controller sends over Redfish itself don't go through a provider call, they are attributed to the first
+// provider the connection was opened with.
+func successfulProvider(md bmc.Metadata) string {
+	if md.SuccessfulProvider != "" {
+		return md.SuccessfulProvider
+	}
+	if len(md.SuccessfulOpenConns) > 0 {
+		return md.SuccessfulOpenConns[0]
 	}
 
-	return ctrl.Result{}, nil
+	return ""
 }
 
 // runTask executes the defined Task in a Task.
-func (r *TaskReconciler) runTask(ctx context.Context, logger logr.Logger, task v1alpha1.Action, bmcClient *bmclib.Client) error {
-	if task.PowerAction != nil {
-		ok, err := bmcClient.SetPowerState(ctx, string(*task.PowerAction))
+func (r *TaskReconciler) runTask(ctx context.Context, logger logr.Logger, task *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) error {
+	action := task.CurrentAction()
+	if action.PowerAction != nil && *action.PowerAction == v1alpha1.PowerStatus {
+		rawState, err := bmcClient.GetPowerState(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to perform PowerAction: %w", err)
+		}
+		task.Status.PowerState = string(toPowerState(rawState))
+		md := bmcClient.GetMetadata()
+		logger.Info("power state read successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "powerState", rawState)
+		// The last boot source is informational, the power state is read even when it is unavailable.
+		source, err := getLastBootSource(ctx, bmcClient, opts)
+		if err != nil {
+			logger.Info("last boot source not read", "error", err.Error())
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(lastBootSourceUnavailableMessage(err)))
+		}
+		task.Status.LastBootSource = source
+	} else if action.PowerAction != nil && *action.PowerAction == v1alpha1.PowerNMI {
+		if err := bmcClient.SendNMI(ctx); err != nil {
+			if isUnsupported(err) {
+				return fmt.Errorf("failed to perform PowerAction: provider does not support sending a diagnostic interrupt (NMI): %w", err)
+			}
+			return fmt.Errorf("failed to perform PowerAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("diagnostic interrupt sent successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+	} else if action.PowerAction != nil {
+		if task.Spec.IdempotentPower {
+			skipped, err := powerStateMatches(ctx, bmcClient, *action.PowerAction)
+			if err != nil {
+				return fmt.Errorf("failed to perform PowerAction: %w", err)
+			}
+			if skipped {
+				task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(powerStateMatchesMessage(*action.PowerAction)))
+				logger.Info("power state already matches, no action taken", "powerAction", *action.PowerAction)
+				return nil
+			}
+		}
+		ok, err := bmcClient.SetPowerState(ctx, string(*action.PowerAction))
 		if err != nil {
 			return fmt.Errorf("failed to perform PowerAction: %w", err)
 		}
@@ -209,19 +756,59 @@ func (r *TaskReconciler) runTask(ctx context.Context, logger logr.Logger, task v
 		logger.Info("power state set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "ok", ok)
 	}
 
-	if task.OneTimeBootDeviceAction != nil {
-		// OneTimeBootDeviceAction currently sets the first boot device from Devices.
-		// setPersistent is false.
-		ok, err := bmcClient.SetBootDevice(ctx, string(task.OneTimeBootDeviceAction.Devices[0]), false, task.OneTimeBootDeviceAction.EFIBoot)
+	if action.OneTimeBootDeviceAction != nil && isClearBootOverride(*action.OneTimeBootDeviceAction) {
+		if err := clearBootOverride(ctx, bmcClient, opts); err != nil {
+			return fmt.Errorf("failed to perform OneTimeBootDeviceAction: failed to clear the boot override: %w", err)
+		}
+		task.Status.OneTimeBootOverride = ""
+		md := bmcClient.GetMetadata()
+		logger.Info("boot override cleared successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+	} else if action.OneTimeBootDeviceAction != nil {
+		// setPersistent is false unless the action asks for it.
+		applied, note, err := setBootDevices(ctx, bmcClient, opts, action.OneTimeBootDeviceAction.BootEntries(), action.OneTimeBootDeviceAction.Persistent, action.OneTimeBootDeviceAction.BootURL)
 		if err != nil {
 			return fmt.Errorf("failed to perform OneTimeBootDeviceAction: %w", err)
 		}
+		task.Status.AppliedBootDevices = applied
+		if note != "" {
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(note))
+		}
 		md := bmcClient.GetMetadata()
-		logger.Info("one time boot device set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "ok", ok)
+		logger.Info("one time boot device set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "note", note, "persistent", action.OneTimeBootDeviceAction.Persistent)
+
+		// Read the override back, so the Task shows whether the BMC accepted it before the Machine is rebooted.
+		if !action.OneTimeBootDeviceAction.Persistent {
+			override, err := getOneTimeBootOverride(ctx, bmcClient)
+			if err != nil {
+				logger.Info("failed to read back the one time boot override", "error", err.Error(), "unsupported", isUnsupported(err))
+			} else {
+				logger.Info("one time boot override read back", "oneTimeBootOverride", override)
+			}
+			task.Status.OneTimeBootOverride = override
+		}
 	}
 
-	if task.VirtualMediaAction != nil {
-		ok, err := bmcClient.SetVirtualMedia(ctx, string(task.VirtualMediaAction.Kind), task.VirtualMediaAction.MediaURL)
+	if action.PersistentBootDeviceAction != nil {
+		// setPersistent is true.
+		applied, note, err := setBootDevices(ctx, bmcClient, opts, action.PersistentBootDeviceAction.BootEntries(), true, "")
+		if err != nil {
+			return fmt.Errorf("failed to perform PersistentBootDeviceAction: %w", err)
+		}
+		task.Status.AppliedBootDevices = applied
+		if note != "" {
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(note))
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("persistent boot device set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "note", note)
+	}
+
+	if action.VirtualMediaAction != nil {
+		// An empty media URL ejects any currently inserted media.
+		mediaURL := action.VirtualMediaAction.MediaURL
+		if action.VirtualMediaAction.Eject {
+			mediaURL = ""
+		}
+		ok, err := bmcClient.SetVirtualMedia(ctx, toVirtualMediaKind(action.VirtualMediaAction.Kind), mediaURL)
 		if err != nil {
 			return fmt.Errorf("failed to perform SetVirtualMedia: %w", err)
 		}
@@ -229,14 +816,268 @@ func (r *TaskReconciler) runTask(ctx context.Context, logger logr.Logger, task v
 		logger.Info("virtual media set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "ok", ok)
 	}
 
+	if action.SoftPowerOffAction != nil {
+		ok, err := bmcClient.SetPowerState(ctx, string(v1alpha1.PowerSoftOff))
+		if err != nil {
+			return fmt.Errorf("failed to perform SoftPowerOffAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("soft power off requested successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "ok", ok, "gracePeriod", softPowerOffGracePeriod(action.SoftPowerOffAction))
+	}
+
+	if action.PowerCycleAction != nil {
+		ok, err := bmcClient.SetPowerState(ctx, string(v1alpha1.PowerCycle))
+		if err != nil {
+			return fmt.Errorf("failed to perform PowerCycleAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("power cycled successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "ok", ok, "waitTimeout", powerCycleWaitTimeout(action.PowerCycleAction))
+	}
+
+	if action.VerifyConnectionAction != nil {
+		if err := verifyConnection(ctx, task, bmcClient); err != nil {
+			return fmt.Errorf("failed to perform VerifyConnectionAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("connection verified successfully", "providersAttempted", md.ProvidersAttempted, "successfulOpenConns", md.SuccessfulOpenConns, "capabilities", task.Status.Capabilities)
+	}
+
+	if action.ResetAction != nil {
+		provider, err := resetMachine(ctx, bmcClient, opts, action.ResetAction.ResetType)
+		if err != nil {
+			return fmt.Errorf("failed to perform ResetAction: %w", err)
+		}
+		logger.Info("machine reset successfully", "provider", provider, "resetType", action.ResetAction.ResetType)
+	}
+
+	if action.IdentifyAction != nil {
+		fellBack, err := setChassisIdentify(ctx, bmcClient, opts, action.IdentifyAction.State)
+		if err != nil {
+			return fmt.Errorf("failed to perform IdentifyAction: %w", err)
+		}
+		if fellBack {
+			task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(identifyBlinkFallbackMessage))
+		}
+		logger.Info("chassis identify set successfully", "state", action.IdentifyAction.State, "fellBack", fellBack)
+	}
+
+	if action.SetBMCCredentialsAction != nil {
+		if err := setBMCCredentials(ctx, r.client, bmcClient, *action.SetBMCCredentialsAction, task.Namespace); err != nil {
+			return fmt.Errorf("failed to perform SetBMCCredentialsAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		// Only the username is logged, never the password.
+		logger.Info("BMC credentials set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "username", action.SetBMCCredentialsAction.Username)
+	}
+
+	if action.RedfishActionPassthroughAction != nil {
+		if err := redfishPassthrough(ctx, task, bmcClient, opts, *action.RedfishActionPassthroughAction); err != nil {
+			return fmt.Errorf("failed to perform RedfishActionPassthroughAction: %w", err)
+		}
+		logger.Info("Redfish request sent successfully", "method", action.RedfishActionPassthroughAction.Method, "path", action.RedfishActionPassthroughAction.Path, "statusCode", task.Status.RedfishResponse.StatusCode)
+	}
+
+	if action.UpdateFirmwareAction != nil {
+		if err := updateFirmware(ctx, task, bmcClient, opts, *action.UpdateFirmwareAction); err != nil {
+			return fmt.Errorf("failed to perform UpdateFirmwareAction: %w", err)
+		}
+		logger.Info("firmware update started successfully", "component", action.UpdateFirmwareAction.Component, "firmwareTaskMonitor", task.Status.FirmwareTaskMonitor)
+	}
+
+	if action.SetPowerLimitAction != nil {
+		if err := setPowerLimit(ctx, task, bmcClient, opts, *action.SetPowerLimitAction); err != nil {
+			return fmt.Errorf("failed to perform SetPowerLimitAction: %w", err)
+		}
+		logger.Info("power limit set successfully", "limitWatts", action.SetPowerLimitAction.LimitWatts, "previousLimitWatts", task.Status.PowerLimit.LimitWatts)
+	}
+
+	if action.GetPowerLimitAction != nil {
+		if err := getPowerLimit(ctx, task, bmcClient, opts); err != nil {
+			return fmt.Errorf("failed to perform GetPowerLimitAction: %w", err)
+		}
+		logger.Info("power limit read successfully", "limitWatts", task.Status.PowerLimit.LimitWatts, "consumedWatts", task.Status.PowerLimit.ConsumedWatts)
+	}
+
+	if action.GetBMCNetworkAction != nil {
+		if err := getBMCNetwork(ctx, task, bmcClient, opts); err != nil {
+			return fmt.Errorf("failed to perform GetBMCNetworkAction: %w", err)
+		}
+		logger.Info("BMC network configuration read successfully", "hostName", task.Status.BMCNetwork.HostName, "interfaces", len(task.Status.BMCNetwork.Interfaces))
+	}
+
+	if action.SetBMCNetworkAction != nil {
+		if err := setBMCNetwork(ctx, task, bmcClient, opts, *action.SetBMCNetworkAction); err != nil {
+			return fmt.Errorf("failed to perform SetBMCNetworkAction: %w", err)
+		}
+		logger.Info("BMC network configuration set successfully", "interface", action.SetBMCNetworkAction.Interface, "dhcp", action.SetBMCNetworkAction.DHCP, "address", action.SetBMCNetworkAction.Address)
+	}
+
+	if action.ResetBMCAction != nil {
+		if err := resetBMC(ctx, bmcClient, *action.ResetBMCAction); err != nil {
+			return fmt.Errorf("failed to perform ResetBMCAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("BMC reset successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "type", action.ResetBMCAction.Type, "waitForReady", action.ResetBMCAction.WaitForReady)
+	}
+
+	if action.BootAndPowerAction != nil {
+		a := *action.BootAndPowerAction
+		// The power state is only changed once the BMC accepted the boot device, so the Machine can't boot before.
+		entry := v1alpha1.BootDeviceEntry{Device: a.Device, EFI: &a.EFIBoot}
+		applied, _, err := setBootDevices(ctx, bmcClient, opts, []v1alpha1.BootDeviceEntry{entry}, false, a.BootURL)
+		if err != nil {
+			return fmt.Errorf("failed to perform BootAndPowerAction: failed to set the one time boot device, the power state was not changed: %w", err)
+		}
+		task.Status.AppliedBootDevices = applied
+		override, err := getOneTimeBootOverride(ctx, bmcClient)
+		if err != nil {
+			logger.Info("failed to read back the one time boot override", "error", err.Error(), "unsupported", isUnsupported(err))
+		}
+		task.Status.OneTimeBootOverride = override
+		if _, err := bmcClient.SetPowerState(ctx, string(a.Power())); err != nil {
+			return fmt.Errorf("failed to perform BootAndPowerAction: the one time boot device was set but the power state was not changed: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("one time boot device and power state set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "device", a.Device, "powerAction", a.Power(), "oneTimeBootOverride", override)
+	}
+
+	if action.GetBootProgressAction != nil {
+		if err := getBootProgress(ctx, task, bmcClient, opts); err != nil {
+			return fmt.Errorf("failed to perform GetBootProgressAction: %w", err)
+		}
+		logger.Info("boot progress read successfully", "bootProgress", task.Status.BootProgress)
+	}
+
+	if action.GetBootDeviceAction != nil {
+		partial, err := getBootOrder(ctx, task, bmcClient, opts)
+		if err != nil {
+			return fmt.Errorf("failed to perform GetBootDeviceAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("boot order read successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "bootOrder", task.Status.BootOrder, "partial", partial)
+	}
+
+	if action.GetSensorsAction != nil {
+		if err := getSensors(ctx, task, bmcClient, opts); err != nil {
+			return fmt.Errorf("failed to perform GetSensorsAction: %w", err)
+		}
+		logger.Info("sensors read successfully", "readings", len(task.Status.Sensors))
+	}
+
+	if action.GetBIOSConfigAction != nil {
+		if err := getBIOSConfig(ctx, task, bmcClient); err != nil {
+			return fmt.Errorf("failed to perform GetBIOSConfigAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("BIOS configuration read successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "attributes", len(task.Status.BIOSConfig))
+	}
+
+	if action.SetBIOSConfigAction != nil {
+		if err := setBIOSConfig(ctx, task, bmcClient); err != nil {
+			return fmt.Errorf("failed to perform SetBIOSConfigAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("BIOS configuration set successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "attributes", len(action.SetBIOSConfigAction.Attributes))
+	}
+
+	if action.GetSELAction != nil {
+		if err := getSEL(ctx, task, bmcClient); err != nil {
+			return fmt.Errorf("failed to perform GetSELAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("system event log read successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "entries", len(task.Status.SELEntries))
+	}
+
+	if action.ClearSELAction != nil {
+		if err := bmcClient.ClearSystemEventLog(ctx); err != nil {
+			if isUnsupported(err) {
+				return fmt.Errorf("failed to perform ClearSELAction: provider does not support clearing the system event log: %w", err)
+			}
+			return fmt.Errorf("failed to perform ClearSELAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("system event log cleared successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider)
+	}
+
+	if action.GetFirmwareInventoryAction != nil {
+		if err := getFirmwareInventory(ctx, task, bmcClient); err != nil {
+			return fmt.Errorf("failed to perform GetFirmwareInventoryAction: %w", err)
+		}
+		md := bmcClient.GetMetadata()
+		logger.Info("firmware inventory read successfully", "providersAttempted", md.ProvidersAttempted, "successfulProvider", md.SuccessfulProvider, "components", len(task.Status.Firmware))
+	}
+
 	return nil
 }
 
+// toVirtualMediaKind converts a v1alpha1.VirtualMediaKind to the media kind expected by bmclib.
+func toVirtualMediaKind(kind v1alpha1.VirtualMediaKind) string {
+	switch kind {
+	case v1alpha1.VirtualMediaUSB:
+		return "USBStick"
+	default:
+		return string(kind)
+	}
+}
+
+// powerStateMatches reports whether the Machine is already in the power state that action sets.
+// It is always false for power actions other than on, off and soft.
+func powerStateMatches(ctx context.Context, bmcClient *bmclib.Client, action v1alpha1.PowerAction) (bool, error) {
+	desired, ok := map[v1alpha1.PowerAction]v1alpha1.PowerState{
+		v1alpha1.PowerOn:      v1alpha1.On,
+		v1alpha1.PowerHardOff: v1alpha1.Off,
+		v1alpha1.PowerSoftOff: v1alpha1.Off,
+	}[action]
+	if !ok {
+		return false, nil
+	}
+
+	rawState, err := bmcClient.GetPowerState(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get power state: %w", err)
+	}
+
+	return toPowerState(rawState) == desired, nil
+}
+
+// powerStateMatchesMessage is the Task condition message when action was skipped by IdempotentPower.
+func powerStateMatchesMessage(action v1alpha1.PowerAction) string {
+	state := v1alpha1.On
+	if action != v1alpha1.PowerOn {
+		state = v1alpha1.Off
+	}
+
+	return fmt.Sprintf("already %s; no action taken", state)
+}
+
 // checkTaskStatus checks if Task action completed.
 // This is currently limited only to a few PowerAction types.
-func (r *TaskReconciler) checkTaskStatus(ctx context.Context, log logr.Logger, task v1alpha1.Action, bmcClient *bmclib.Client) (ctrl.Result, error) {
+func (r *TaskReconciler) checkTaskStatus(ctx context.Context, log logr.Logger, t *v1alpha1.Task, bmcClient *bmclib.Client, opts *BMCOptions) (ctrl.Result, error) {
+	task := t.CurrentAction()
+	if task.UpdateFirmwareAction != nil {
+		return checkFirmwareUpdate(ctx, log, t, bmcClient, opts)
+	}
+	if task.SoftPowerOffAction != nil {
+		return checkSoftPowerOff(ctx, log, t, bmcClient)
+	}
+	if task.PowerCycleAction != nil {
+		return checkPowerCycle(ctx, log, t, bmcClient)
+	}
+	if task.IdentifyAction != nil {
+		return checkIdentify(ctx, log, t, bmcClient, opts)
+	}
+	if task.ResetBMCAction != nil {
+		return checkResetBMC(ctx, log, t, bmcClient)
+	}
+
+	powerAction := task.PowerAction
+	if task.BootAndPowerAction != nil {
+		p := task.BootAndPowerAction.Power()
+		powerAction = &p
+	}
+
 	// TODO(pokearu): Extend to all actions.
-	if task.PowerAction != nil {
+	if powerAction != nil {
 		rawState, err := bmcClient.GetPowerState(ctx)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to get power state: %w", err)
@@ -245,8 +1086,11 @@ func (r *TaskReconciler) checkTaskStatus(ctx context.Context, log logr.Logger, t
 		log.Info("power state check")
 
 		state := toPowerState(rawState)
+		if r.powerVerificationWindow > 0 {
+			return r.verifyPowerState(log, t, *powerAction, state)
+		}
 
-		switch *task.PowerAction { //nolint:exhaustive // we only support a few power actions right now.
+		switch *powerAction { //nolint:exhaustive // we only support a few power actions right now.
 		case v1alpha1.PowerOn:
 			if state != v1alpha1.On {
 				log.Info("requeuing task", "requeueAfter", powerActionRequeueAfter)
@@ -263,6 +1107,130 @@ func (r *TaskReconciler) checkTaskStatus(ctx context.Context, log logr.Logger, t
 	return ctrl.Result{}, nil
 }
 
+// verifyPowerState records state, the power state observed after action, in the Task status. The Task is requeued
+// while state differs from the one action sets, and failed when it still differs after the power verification window.
+func (r *TaskReconciler) verifyPowerState(log logr.Logger, t *v1alpha1.Task, action v1alpha1.PowerAction, state v1alpha1.PowerState) (ctrl.Result, error) {
+	desired, ok := map[v1alpha1.PowerAction]v1alpha1.PowerState{
+		v1alpha1.PowerOn:      v1alpha1.On,
+		v1alpha1.PowerHardOff: v1alpha1.Off,
+		v1alpha1.PowerSoftOff: v1alpha1.Off,
+		v1alpha1.PowerCycle:   v1alpha1.On,
+	}[action]
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	t.Status.PowerState = string(state)
+	if state == desired {
+		return ctrl.Result{}, nil
+	}
+	if time.Since(t.Status.StartTime.Time) >= r.powerVerificationWindow {
+		return ctrl.Result{}, &terminalError{err: fmt.Errorf("power state is %s after PowerAction %s, expected %s within %s", state, action, desired, r.powerVerificationWindow)}
+	}
+	log.Info("requeuing task until the power state is verified", "expectedPowerState", desired, "requeueAfter", powerActionRequeueAfter)
+
+	return ctrl.Result{RequeueAfter: powerActionRequeueAfter}, nil
+}
+
+// failTask sets the Task Condition Failed True with the message of err, stores the provider errors err is
+// annotated with, and patches the Task status. For a Task with Actions, the failed action is recorded.
+// A Task cut off by the controller shutting down is not failed. A dry run checks all the actions at once, so no
+// failed action is recorded for it.
+func (r *TaskReconciler) failTask(ctx context.Context, task *v1alpha1.Task, taskPatch client.Patch, err error) (ctrl.Result, error) {
+	if shutdownDrainExpired(ctx) {
+		ctrl.LoggerFrom(ctx).Info("controller shut down before the task finished, leaving it to be reconciled again", "error", err.Error())
+		return ctrl.Result{}, err
+	}
+	if len(task.Spec.Actions) > 0 && !task.Spec.DryRun {
+		i := task.Status.ActionIndex
+		task.Status.FailedActionIndex = &i
+		err = fmt.Errorf("action %d (%s) of %d failed: %w", i, actionType(task.CurrentAction()), len(task.Spec.Actions), err)
+	}
+	task.Status.ProviderErrors = providerErrorsOf(err)
+	message := err.Error()
+	if isAuthError(err) {
+		message = "Authentication failed: " + message
+	}
+	r.setTaskFailed(task, message, err)
+	if patchErr := r.patchStatus(ctx, task, taskPatch); patchErr != nil {
+		return ctrl.Result{}, utilerrors.NewAggregate([]error{patchErr, err})
+	}
+
+	return ctrl.Result{}, err
+}
+
+// completeTask sets the Task Condition Completed True, records the CompletionTime, a Completed Event and the
+// completion metrics, and patches the Task status.
+func (r *TaskReconciler) completeTask(ctx context.Context, task *v1alpha1.Task, taskPatch client.Patch) error {
+	now := metav1.Now()
+	task.Status.CompletionTime = &now
+	task.RemoveCondition(v1alpha1.TaskRunning)
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionReason(v1alpha1.TaskReasonSucceeded))
+	if err := r.patchStatus(ctx, task, taskPatch); err != nil {
+		return err
+	}
+	recordTaskEvent(r.recorder, task, corev1.EventTypeNormal, taskCompletedEventReason, "task completed")
+	observeTaskFinished(task, taskResultCompleted)
+
+	return nil
+}
+
+// setTaskFailed sets the Task Condition Failed True with message, records the FailureTime, the FailureReason
+// classifying err, a Failed Event and the failure metrics. When no provider supports the action, the Condition
+// Unsupported is set instead of Failed, with an Unsupported Event and metrics.
+func (r *TaskReconciler) setTaskFailed(task *v1alpha1.Task, message string, err error) {
+	now := metav1.Now()
+	task.Status.FailureTime = &now
+	task.Status.FailureReason = failureReason(err)
+	task.RemoveCondition(v1alpha1.TaskRunning)
+	cType, result := v1alpha1.TaskFailed, taskResultFailed
+	if task.Status.FailureReason == v1alpha1.FailureReasonUnsupported {
+		cType, result = v1alpha1.TaskUnsupported, taskResultUnsupported
+	}
+	task.SetCondition(cType, v1alpha1.ConditionTrue, v1alpha1.WithTaskConditionMessage(message), v1alpha1.WithTaskConditionReason(conditionReason(task.Status.FailureReason)))
+	recordTaskFailedEvent(r.recorder, task, message)
+	observeTaskFinished(task, result)
+}
+
+// taskTimeoutError returns an error describing that the Task exceeded timeout if the deadline of ctx was
+// exceeded, otherwise nil.
+func taskTimeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+
+	return fmt.Errorf("task exceeded timeout %s: %w", timeout, err)
+}
+
+// bmcOperationContext returns the context of a single BMC operation derived from ctx. When the Task has no
+// Spec.Timeout, the operation is bounded by the default BMC timeout, otherwise ctx is returned as is.
+func (r *TaskReconciler) bmcOperationContext(ctx context.Context, task *v1alpha1.Task) (context.Context, context.CancelFunc) {
+	if task.Spec.Timeout != nil || r.defaultBMCTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, r.defaultBMCTimeout)
+}
+
+// defaultTimeoutError wraps err with the default BMC timeout if the deadline of the operation context ctx was
+// exceeded, otherwise err is returned as is.
+func (r *TaskReconciler) defaultTimeoutError(ctx context.Context, err error) error {
+	if r.defaultBMCTimeout <= 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	return fmt.Errorf("BMC operation exceeded default BMC timeout %s: %w", r.defaultBMCTimeout, err)
+}
+
+// requeueBMCLeaseLost requeues a Task whose BMC operation err was cancelled because the Lease of the BMC host
+// was lost to another controller replica. The Task is not failed, it is reconciled again once the Lease is free.
+func (r *TaskReconciler) requeueBMCLeaseLost(logger logr.Logger, err error) (ctrl.Result, error) {
+	logger.Info("BMC Lease was taken over by another controller replica, requeueing", "error", err.Error())
+	observeRequeue(requeueReasonConcurrencyLimited)
+
+	return ctrl.Result{RequeueAfter: jitter(hostBusyRequeueAfter, r.requeueJitter)}, nil
+}
+
 // patchStatus patches the specified patch on the Task.
 func (r *TaskReconciler) patchStatus(ctx context.Context, task *v1alpha1.Task, patch client.Patch) error {
 	err := r.client.Status().Patch(ctx, task, patch)
@@ -274,8 +1242,27 @@ func (r *TaskReconciler) patchStatus(ctx context.Context, task *v1alpha1.Task, p
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *TaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *TaskReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&v1alpha1.Task{},
+		connectionSecretKey,
+		TaskSecretIndexFunc,
+	); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&v1alpha1.Task{},
+		taskHostActionKey,
+		TaskHostActionIndexFunc,
+	); err != nil {
+		return err
+	}
+
+	// Tasks are requeued when a Secret referenced by their Connection changes, for example rotated credentials.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1alpha1.Task{}).
+		For(&v1alpha1.Task{}, builder.WithPredicates(r.cancelOnDelete())).
+		Watches(&corev1.Secret{}, enqueueTasksForSecret(r.client)).
 		Complete(r)
 }
@@ -2,17 +2,43 @@ package controller_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/bmc"
+	bmclibErrs "github.com/bmc-toolbox/bmclib/v2/errors"
+	"github.com/bmc-toolbox/bmclib/v2/providers"
+	"github.com/bmc-toolbox/common"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/jacobweinstock/registrar"
 	"github.com/tinkerbell/rufio/api/v1alpha1"
 	"github.com/tinkerbell/rufio/controller"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -20,14 +46,36 @@ func getAction(s string) v1alpha1.Action {
 	switch s {
 	case "PowerOn":
 		return v1alpha1.Action{PowerAction: v1alpha1.PowerOn.Ptr()}
+	case "PowerStatus":
+		return v1alpha1.Action{PowerAction: v1alpha1.PowerStatus.Ptr()}
 	case "HardOff":
 		return v1alpha1.Action{PowerAction: v1alpha1.PowerHardOff.Ptr()}
 	case "SoftOff":
 		return v1alpha1.Action{PowerAction: v1alpha1.PowerSoftOff.Ptr()}
 	case "BootPXE":
 		return v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}}
+	case "BootPersistentPXE":
+		return v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}}
 	case "VirtualMedia":
 		return v1alpha1.Action{VirtualMediaAction: &v1alpha1.VirtualMediaAction{MediaURL: "http://example.com/image.iso", Kind: v1alpha1.VirtualMediaCD}}
+	case "GetBIOSConfig":
+		return v1alpha1.Action{GetBIOSConfigAction: &v1alpha1.GetBIOSConfigAction{}}
+	case "GetSEL":
+		return v1alpha1.Action{GetSELAction: &v1alpha1.GetSELAction{MaxEntries: 2}}
+	case "ClearSEL":
+		return v1alpha1.Action{ClearSELAction: &v1alpha1.ClearSELAction{}}
+	case "GetFirmwareInventory":
+		return v1alpha1.Action{GetFirmwareInventoryAction: &v1alpha1.GetFirmwareInventoryAction{}}
+	case "SetBIOSConfig":
+		return v1alpha1.Action{SetBIOSConfigAction: &v1alpha1.SetBIOSConfigAction{Attributes: map[string]string{"boot_mode": "uefi"}}}
+	case "SoftPowerOff":
+		return v1alpha1.Action{SoftPowerOffAction: &v1alpha1.SoftPowerOffAction{GracePeriod: &metav1.Duration{Duration: time.Minute}}}
+	case "VerifyConnection":
+		return v1alpha1.Action{VerifyConnectionAction: &v1alpha1.VerifyConnectionAction{}}
+	case "PowerCycleWait":
+		return v1alpha1.Action{PowerCycleAction: &v1alpha1.PowerCycleAction{WaitTimeout: &metav1.Duration{Duration: time.Minute}}}
+	case "VirtualMediaEject":
+		return v1alpha1.Action{VirtualMediaAction: &v1alpha1.VirtualMediaAction{Kind: v1alpha1.VirtualMediaUSB, Eject: true}}
 	default:
 		return v1alpha1.Action{}
 	}
@@ -49,6 +97,12 @@ func TestTaskReconcile(t *testing.T) {
 			provider: &testProvider{Powerstate: "on", PowerSetOK: true},
 		},
 
+		"success power status": {
+			taskName: "PowerStatus",
+			action:   getAction("PowerStatus"),
+			provider: &testProvider{Powerstate: "on"},
+		},
+
 		"success hard off": {
 			taskName: "HardOff",
 			action:   getAction("HardOff"),
@@ -67,12 +121,54 @@ func TestTaskReconcile(t *testing.T) {
 			provider: &testProvider{BootdeviceOK: true},
 		},
 
+		"success persistent boot pxe": {
+			taskName: "BootPersistentPXE",
+			action:   getAction("BootPersistentPXE"),
+			provider: &testProvider{BootdeviceOK: true},
+		},
+
 		"success virtual media": {
 			taskName: "VirtualMedia",
 			action:   getAction("VirtualMedia"),
 			provider: &testProvider{VirtualMediaOK: true},
 		},
 
+		"success virtual media eject": {
+			taskName: "VirtualMediaEject",
+			action:   getAction("VirtualMediaEject"),
+			provider: &testProvider{VirtualMediaOK: true},
+		},
+
+		"success get bios config": {
+			taskName: "GetBIOSConfig",
+			action:   getAction("GetBIOSConfig"),
+			provider: &testProvider{BIOSConfig: map[string]string{"boot_mode": "uefi"}},
+		},
+
+		"success get sel": {
+			taskName: "GetSEL",
+			action:   getAction("GetSEL"),
+			provider: &testProvider{SEL: [][]string{{"1", "2024-01-01 00:00:00", "Warning", "fan failure"}}},
+		},
+
+		"success clear sel": {
+			taskName: "ClearSEL",
+			action:   getAction("ClearSEL"),
+			provider: &testProvider{},
+		},
+
+		"success get firmware inventory": {
+			taskName: "GetFirmwareInventory",
+			action:   getAction("GetFirmwareInventory"),
+			provider: &testProvider{Device: &common.Device{BIOS: &common.BIOS{Common: common.Common{Firmware: &common.Firmware{Installed: "2.1"}}}}},
+		},
+
+		"success set bios config": {
+			taskName: "SetBIOSConfig",
+			action:   getAction("SetBIOSConfig"),
+			provider: &testProvider{BIOSConfig: map[string]string{"boot_mode": "legacy"}},
+		},
+
 		"success power on with rpc provider": {
 			taskName: "PowerOn",
 			action:   getAction("PowerOn"),
@@ -101,6 +197,13 @@ func TestTaskReconcile(t *testing.T) {
 			shouldErr: true,
 		},
 
+		"failure on set persistent boot device": {
+			taskName:  "BootPersistentPXE",
+			action:    getAction("BootPersistentPXE"),
+			provider:  &testProvider{ErrBootDeviceSet: errors.New("failed to set boot device")},
+			shouldErr: true,
+		},
+
 		"failure on virtual media": {
 			taskName:  "VirtualMedia",
 			action:    getAction("VirtualMedia"),
@@ -108,6 +211,48 @@ func TestTaskReconcile(t *testing.T) {
 			shouldErr: true,
 		},
 
+		"failure on get bios config": {
+			taskName:  "GetBIOSConfig",
+			action:    getAction("GetBIOSConfig"),
+			provider:  &testProvider{ErrBIOSConfigGet: errors.New("failed to get bios config")},
+			shouldErr: true,
+		},
+
+		"failure on get sel unsupported": {
+			taskName:  "GetSEL",
+			action:    getAction("GetSEL"),
+			provider:  &testProvider{ErrSELGet: bmclibErrs.ErrProviderImplementation},
+			shouldErr: true,
+		},
+
+		"failure on clear sel": {
+			taskName:  "ClearSEL",
+			action:    getAction("ClearSEL"),
+			provider:  &testProvider{ErrSELClear: errors.New("failed to clear sel")},
+			shouldErr: true,
+		},
+
+		"failure on get firmware inventory unsupported": {
+			taskName:  "GetFirmwareInventory",
+			action:    getAction("GetFirmwareInventory"),
+			provider:  &testProvider{ErrInventory: bmclibErrs.ErrProviderImplementation},
+			shouldErr: true,
+		},
+
+		"failure on set bios config unknown attribute": {
+			taskName:  "SetBIOSConfig",
+			action:    getAction("SetBIOSConfig"),
+			provider:  &testProvider{BIOSConfig: map[string]string{"sriov": "enabled"}},
+			shouldErr: true,
+		},
+
+		"failure on set bios config": {
+			taskName:  "SetBIOSConfig",
+			action:    getAction("SetBIOSConfig"),
+			provider:  &testProvider{BIOSConfig: map[string]string{"boot_mode": "legacy"}, ErrBIOSConfigSet: errors.New("failed to set bios config")},
+			shouldErr: true,
+		},
+
 		"failure timeout": {
 			taskName:   "PowerOn",
 			action:     getAction("PowerOn"),
@@ -139,13 +284,14 @@ func TestTaskReconcile(t *testing.T) {
 			} else {
 				task = createTask(tt.taskName, tt.action, secret)
 			}
+			task.Generation = 2
 
 			cluster := newClientBuilder().
 				WithObjects(task, secret).
 				WithStatusSubresource(task).
 				Build()
 
-			reconciler := controller.NewTaskReconciler(cluster, newTestClient(tt.provider))
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(tt.provider))
 			request := reconcile.Request{
 				NamespacedName: types.NamespacedName{
 					Namespace: task.Namespace,
@@ -175,8 +321,11 @@ func TestTaskReconcile(t *testing.T) {
 			if !retrieved.Status.CompletionTime.IsZero() {
 				t.Fatalf("expected completion time to be zero, got: %v", retrieved.Status.CompletionTime)
 			}
-			if len(retrieved.Status.Conditions) != 0 {
-				t.Fatalf("expected no conditions, got: %v", retrieved.Status.Conditions)
+			if len(retrieved.Status.Conditions) != 1 || !retrieved.HasCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected only condition %s to be %s, got: %v", v1alpha1.TaskRunning, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if retrieved.Status.ObservedGeneration != retrieved.Generation {
+				t.Fatalf("expected observed generation %d, got: %d", retrieved.Generation, retrieved.Status.ObservedGeneration)
 			}
 
 			// Timeout check
@@ -233,62 +382,3904 @@ func TestTaskReconcile(t *testing.T) {
 	}
 }
 
-func createTask(name string, action v1alpha1.Action, secret *corev1.Secret) *v1alpha1.Task {
-	return &v1alpha1.Task{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: "default",
+func TestTaskReconcileBootOrder(t *testing.T) {
+	tests := map[string]struct {
+		action   v1alpha1.Action
+		protocol string
+		// wantRequests are the requests that change the BMC, formatted as "METHOD path body".
+		wantRequests []string
+		wantBootSet  string
+		wantEFIBoot  bool
+		wantMessage  string
+		wantFailed   string
+		wantApplied  []v1alpha1.BootDevice
+	}{
+		"persistent uefi": {
+			action: v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.Network, v1alpha1.Disk}, EFIBoot: true}},
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootOrder":["Boot0001","Boot0002","Boot0004","Boot0003"],"BootSourceOverrideEnabled":"Disabled"}}`,
+			},
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.Network, v1alpha1.Disk},
 		},
-		Spec: v1alpha1.TaskSpec{
-			Task: action,
-			Connection: v1alpha1.Connection{
-				Host: "host",
-				Port: 22,
-				AuthSecretRef: corev1.SecretReference{
-					Name:      secret.Name,
-					Namespace: secret.Namespace,
-				},
-				ProviderOptions: &v1alpha1.ProviderOptions{
-					Redfish: &v1alpha1.RedfishOptions{
-						Port: 443,
-					},
-				},
+		"persistent legacy partial": {
+			action: v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE, v1alpha1.BIOS}}},
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootOrder":["Boot0004","Boot0002","Boot0001","Boot0003"],"BootSourceOverrideEnabled":"Disabled"}}`,
+			},
+			wantMessage: "partial: no boot option of the BMC boots devices bios (efiBoot: false), only 1 of 2 devices (pxe) were applied",
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.PXE},
+		},
+		"persistent legacy none applied": {
+			action:     v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.BIOS, v1alpha1.Disk}}},
+			wantFailed: "no boot option of the BMC boots devices bios (efiBoot: false), disk (efiBoot: false)",
+		},
+		"one time with persistent": {
+			action: v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.BIOS, v1alpha1.Disk, v1alpha1.PXE}, EFIBoot: true, Persistent: true}},
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootOrder":["Boot0003","Boot0002","Boot0001","Boot0004"],"BootSourceOverrideEnabled":"Disabled"}}`,
+			},
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.BIOS, v1alpha1.Disk, v1alpha1.PXE},
+		},
+		"persistent entries": {
+			action: v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Entries: []v1alpha1.BootDeviceEntry{{Device: v1alpha1.PXE, EFI: ptr.To(false)}, {Device: v1alpha1.Disk, EFI: ptr.To(true)}}}},
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootOrder":["Boot0004","Boot0002","Boot0001","Boot0003"],"BootSourceOverrideEnabled":"Disabled"}}`,
 			},
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.PXE, v1alpha1.Disk},
+		},
+		"persistent entries with action efiBoot": {
+			action: v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Entries: []v1alpha1.BootDeviceEntry{{Device: v1alpha1.BIOS}, {Device: v1alpha1.PXE, EFI: ptr.To(false)}}, EFIBoot: true}},
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootOrder":["Boot0003","Boot0004","Boot0002","Boot0001"],"BootSourceOverrideEnabled":"Disabled"}}`,
+			},
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.BIOS, v1alpha1.PXE},
+		},
+		"one time": {
+			action:      v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE, v1alpha1.Disk}}},
+			wantBootSet: "pxe",
+			wantMessage: "partial: a one time boot override takes a single device, only 1 of 2 devices (pxe) were applied",
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.PXE},
+		},
+		"one time efi": {
+			action:      v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.Disk}, EFIBoot: true}},
+			wantBootSet: "disk",
+			wantEFIBoot: true,
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.Disk},
 		},
+		"one time entries": {
+			action:      v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Entries: []v1alpha1.BootDeviceEntry{{Device: v1alpha1.Network, EFI: ptr.To(true)}, {Device: v1alpha1.Disk, EFI: ptr.To(false)}}}},
+			wantBootSet: "pxe",
+			wantEFIBoot: true,
+			wantMessage: "partial: a one time boot override takes a single device, only 1 of 2 devices (network) were applied",
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.Network},
+		},
+		"persistent ipmi": {
+			action:      v1alpha1.Action{PersistentBootDeviceAction: &v1alpha1.PersistentBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE, v1alpha1.Disk}}},
+			protocol:    "ipmi",
+			wantBootSet: "pxe",
+			wantMessage: "partial: provider does not support ordered boot devices, only 1 of 2 devices (pxe) were applied",
+			wantApplied: []v1alpha1.BootDevice{v1alpha1.PXE},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, redfishBootResources())
+			secret := createSecret()
+			task := createTask("BootOrder", tt.action, secret)
+			redfish.connect(task)
+			provider := &testProvider{Proto: tt.protocol, BootdeviceOK: true}
+
+			retrieved, err := reconcileTask(t, task, secret, provider)
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantBootSet, provider.SetBootDevice); diff != "" {
+				t.Fatalf("unexpected boot device set by the provider: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantEFIBoot, provider.SetEFIBoot); diff != "" {
+				t.Fatalf("unexpected efiBoot set by the provider: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantApplied, retrieved.Status.AppliedBootDevices); diff != "" {
+				t.Fatalf("unexpected applied boot devices: %v", diff)
+			}
+		})
 	}
 }
 
-func createTaskWithRPC(name string, action v1alpha1.Action, secret *corev1.Secret) *v1alpha1.Task {
-	return &v1alpha1.Task{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: "default",
+func TestTaskReconcileClearBootOverride(t *testing.T) {
+	tests := map[string]struct {
+		override     string
+		protocol     string
+		wantRequests []string
+		wantBootSet  string
+	}{
+		"override set": {
+			override: "Once",
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootSourceOverrideEnabled":"Disabled"}}`,
+			},
 		},
-		Spec: v1alpha1.TaskSpec{
-			Task: action,
-			Connection: v1alpha1.Connection{
-				Host: "host",
-				Port: 22,
-				AuthSecretRef: corev1.SecretReference{
-					Name:      secret.Name,
-					Namespace: secret.Namespace,
-				},
-				ProviderOptions: &v1alpha1.ProviderOptions{
-					RPC: &v1alpha1.RPCOptions{
-						ConsumerURL: "http://127.0.0.1:7777",
-						HMAC: &v1alpha1.HMACOpts{
-							Secrets: v1alpha1.HMACSecrets{
-								"sha256": []corev1.SecretReference{
-									{
-										Name:      secret.Name,
-										Namespace: secret.Namespace,
-									},
-								},
-							},
-						},
-					},
-				},
+		"no override set": {
+			override: "Disabled",
+		},
+		"ipmi": {
+			protocol:    "ipmi",
+			wantBootSet: "none",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resources := redfishBootResources()
+			resources["/redfish/v1/Systems/1"] = fmt.Sprintf(`{"Name":"System","Boot":{"BootSourceOverrideTarget":"Pxe","BootSourceOverrideEnabled":%q}}`, tt.override)
+			redfish := newFakeRedfish(t, resources)
+			secret := createSecret()
+			task := createTask("ClearBootOverride", v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.None}}}, secret)
+			redfish.connect(task)
+			provider := &testProvider{Proto: tt.protocol, BootdeviceOK: true}
+
+			retrieved, err := reconcileTask(t, task, secret, provider)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantBootSet, provider.SetBootDevice); diff != "" {
+				t.Fatalf("unexpected boot device set by the provider: %v", diff)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if retrieved.Status.OneTimeBootOverride != "" {
+				t.Fatalf("expected no one time boot override, got: %q", retrieved.Status.OneTimeBootOverride)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileBootAndPower(t *testing.T) {
+	tests := map[string]struct {
+		action         v1alpha1.BootAndPowerAction
+		errBootDevice  error
+		wantBootSet    string
+		wantEFIBoot    bool
+		wantPowerState string
+		wantFailed     string
+	}{
+		"pxe then cycle": {
+			action:         v1alpha1.BootAndPowerAction{Device: v1alpha1.PXE, EFIBoot: true, PowerAction: v1alpha1.PowerCycle},
+			wantBootSet:    "pxe",
+			wantEFIBoot:    true,
+			wantPowerState: "cycle",
+		},
+		"default power on": {
+			action:         v1alpha1.BootAndPowerAction{Device: v1alpha1.Network},
+			wantBootSet:    "pxe",
+			wantPowerState: "on",
+		},
+		"boot device not set": {
+			action:        v1alpha1.BootAndPowerAction{Device: v1alpha1.PXE, PowerAction: v1alpha1.PowerCycle},
+			errBootDevice: errors.New("boot device rejected"),
+			wantBootSet:   "pxe",
+			wantFailed:    "failed to set the one time boot device, the power state was not changed",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			action := tt.action
+			task := createTask("BootAndPower", v1alpha1.Action{BootAndPowerAction: &action}, secret)
+			provider := &testProvider{Powerstate: "on", PowerSetOK: true, BootdeviceOK: true, ErrBootDeviceSet: tt.errBootDevice}
+
+			retrieved, err := reconcileTask(t, task, secret, provider)
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff([]any{tt.wantBootSet, tt.wantEFIBoot, tt.wantPowerState}, []any{provider.SetBootDevice, provider.SetEFIBoot, provider.PowerSetState}); diff != "" {
+				t.Fatalf("unexpected boot device and power state set by the provider: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileHTTPBoot(t *testing.T) {
+	resources := func(system string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1": system,
+		}
+	}
+	tests := map[string]struct {
+		action   v1alpha1.Action
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources map[string]string
+		// wantRequests are the requests that change the BMC, formatted as "METHOD path body".
+		wantRequests    []string
+		wantPowerState  string
+		wantUnsupported string
+	}{
+		"one time with boot url": {
+			action:    v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.HTTPBoot}, BootURL: "https://boot.example.com/ipxe.efi"}},
+			resources: resources(`{"Name":"System","Boot":{"BootSourceOverrideTarget@Redfish.AllowableValues":["Pxe","Hdd","UefiHttp"]}}`),
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootSourceOverrideEnabled":"Once","BootSourceOverrideMode":"UEFI","BootSourceOverrideTarget":"UefiHttp","HttpBootUri":"https://boot.example.com/ipxe.efi"}}`,
+			},
+		},
+		"persistent from the dhcp url": {
+			action:    v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.HTTPBoot}, Persistent: true}},
+			resources: resources(`{"Name":"System","Boot":{}}`),
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootSourceOverrideEnabled":"Continuous","BootSourceOverrideMode":"UEFI","BootSourceOverrideTarget":"UefiHttp"}}`,
+			},
+		},
+		"boot and power": {
+			action:    v1alpha1.Action{BootAndPowerAction: &v1alpha1.BootAndPowerAction{Device: v1alpha1.HTTPBoot, BootURL: "http://boot.example.com/image.iso", PowerAction: v1alpha1.PowerCycle}},
+			resources: resources(`{"Name":"System","Boot":{}}`),
+			wantRequests: []string{
+				`PATCH /redfish/v1/Systems/1 {"Boot":{"BootSourceOverrideEnabled":"Once","BootSourceOverrideMode":"UEFI","BootSourceOverrideTarget":"UefiHttp","HttpBootUri":"http://boot.example.com/image.iso"}}`,
+			},
+			wantPowerState: "cycle",
+		},
+		"target not accepted": {
+			action:          v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.HTTPBoot}}},
+			resources:       resources(`{"Name":"System","Boot":{"BootSourceOverrideTarget@Redfish.AllowableValues":["Pxe","Hdd"]}}`),
+			wantUnsupported: "UefiHttp is not one of the boot override targets [Pxe, Hdd] of the computer system",
+		},
+		"ipmi only": {
+			action:          v1alpha1.Action{BootAndPowerAction: &v1alpha1.BootAndPowerAction{Device: v1alpha1.HTTPBoot}},
+			protocol:        "ipmi",
+			wantUnsupported: "HTTP boot overrides are not supported by protocols [ipmi], a Redfish capable BMC is required",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("HTTPBoot", tt.action, secret)
+			redfish.connect(task)
+			provider := &testProvider{Proto: tt.protocol, Powerstate: "on", PowerSetOK: true, BootdeviceOK: true}
+
+			retrieved, err := reconcileTask(t, task, secret, provider)
+			if (err != nil) != (tt.wantUnsupported != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if diff := cmp.Diff([]any{"", tt.wantPowerState}, []any{provider.SetBootDevice, provider.PowerSetState}); diff != "" {
+				t.Fatalf("unexpected boot device and power state set by the provider: %v", diff)
+			}
+			if tt.wantUnsupported != "" {
+				if !retrieved.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantUnsupported) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantUnsupported, msg)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff([]v1alpha1.BootDevice{v1alpha1.HTTPBoot}, retrieved.Status.AppliedBootDevices); diff != "" {
+				t.Fatalf("unexpected applied boot devices: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileClientCert(t *testing.T) {
+	malformed := createClientCertSecret(t)
+	malformed.Data[corev1.TLSPrivateKeyKey] = []byte("not a key")
+	tests := map[string]struct {
+		secret  *corev1.Secret
+		wantErr string
+	}{
+		"client certificate only": {
+			secret: createClientCertSecret(t),
+		},
+		"missing secret": {
+			wantErr: "client certificate secret test-namespace/test-bm-client-cert not found",
+		},
+		"malformed secret": {
+			secret:  malformed,
+			wantErr: "invalid client certificate in secret test-namespace/test-bm-client-cert",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			task := createTask("PowerOn", getAction("PowerOn"), &corev1.Secret{})
+			task.Spec.Connection.ClientCertSecretRef = &corev1.SecretReference{Namespace: "test-namespace", Name: "test-bm-client-cert"}
+			builder := newClientBuilder().
+				WithObjects(task).
+				WithStatusSubresource(task)
+			if tt.secret != nil {
+				builder = builder.WithObjects(tt.secret)
+			}
+			cluster := builder.Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{Powerstate: "on", PowerSetOK: true}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected err containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileActionResults(t *testing.T) {
+	sel := [][]string{
+		{"1", "01/01/2024 00:00:00", "Power Supply #0x01", "Failure detected : Asserted"},
+		{"2", "01/02/2024 00:00:00", "OK", "Power restored"},
+		{"3", "01/03/2024 00:00:00", "critical", "Fan failure"},
+	}
+	device := &common.Device{
+		BIOS: &common.BIOS{Common: common.Common{Firmware: &common.Firmware{Installed: "2.1"}}},
+		BMC:  &common.BMC{Common: common.Common{Firmware: &common.Firmware{Installed: "5.10"}}},
+		NICs: []*common.NIC{
+			{Common: common.Common{Model: "BCM57416", Firmware: &common.Firmware{Installed: "221.0"}}},
+			// Components without an installed firmware version are left out.
+			{Common: common.Common{Model: "X710"}},
+		},
+	}
+	biosConfig := map[string]string{}
+	for i := 0; i < 1001; i++ {
+		biosConfig[fmt.Sprintf("attr%04d", i)] = "value"
+	}
+	truncatedBIOSConfig := maps.Clone(biosConfig)
+	delete(truncatedBIOSConfig, "attr1000")
+	bootPXE := v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}}
+	tests := map[string]struct {
+		action   v1alpha1.Action
+		provider *testProvider
+		// wantFailed is contained in the condition message of the failed Task, the Task is completed when it is empty.
+		wantFailed         string
+		wantMessage        string
+		wantBootSet        string
+		wantPersistent     bool
+		wantBootOverride   string
+		wantPowerState     string
+		wantSEL            []v1alpha1.SELEntry
+		wantFirmware       []v1alpha1.FirmwareComponent
+		wantBIOSConfig     map[string]string
+		wantCapabilities   []string
+		wantProviderErrors []v1alpha1.ProviderError
+		// wantUnsupported expects the Task to be Unsupported instead of Failed.
+		wantUnsupported bool
+	}{
+		"one time boot": {
+			action: bootPXE,
+			// SetPersistent starts true to check that the boot device is set for the next boot only.
+			provider:    &testProvider{BootdeviceOK: true, SetPersistent: true},
+			wantBootSet: "pxe",
+		},
+		"one time boot persistent": {
+			action:         v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}, Persistent: true}},
+			provider:       &testProvider{BootdeviceOK: true},
+			wantBootSet:    "pxe",
+			wantPersistent: true,
+		},
+		"boot device alias": {
+			action:      v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.Network}}},
+			provider:    &testProvider{BootdeviceOK: true},
+			wantBootSet: "pxe",
+		},
+		"one time boot override read back": {
+			action:           bootPXE,
+			provider:         &testProvider{BootdeviceOK: true, BootOverride: bmc.BootDeviceOverride{Device: bmc.BootDeviceTypePXE}},
+			wantBootSet:      "pxe",
+			wantBootOverride: "pxe",
+		},
+		"no boot override read back": {
+			action:      bootPXE,
+			provider:    &testProvider{BootdeviceOK: true, BootOverride: bmc.BootDeviceOverride{Device: bmc.BootDeviceTypeNone}},
+			wantBootSet: "pxe",
+		},
+		"persistent boot override read back": {
+			action:      bootPXE,
+			provider:    &testProvider{BootdeviceOK: true, BootOverride: bmc.BootDeviceOverride{Device: bmc.BootDeviceTypeDisk, IsPersistent: true}},
+			wantBootSet: "pxe",
+		},
+		"boot override read back unsupported": {
+			action:      bootPXE,
+			provider:    &testProvider{BootdeviceOK: true, ErrBootOverrideGet: bmclibErrs.ErrProviderImplementation},
+			wantBootSet: "pxe",
+		},
+		"nmi unsupported": {
+			action: v1alpha1.Action{PowerAction: v1alpha1.PowerNMI.Ptr()},
+			// testProvider does not implement sending an NMI.
+			provider:        &testProvider{},
+			wantFailed:      "provider does not support sending a diagnostic interrupt (NMI)",
+			wantUnsupported: true,
+		},
+		"power status": {
+			action:         getAction("PowerStatus"),
+			provider:       &testProvider{Powerstate: "Off", Proto: "ipmi"},
+			wantMessage:    "last boot source is unavailable, it is only reported by Redfish BMCs",
+			wantPowerState: string(v1alpha1.Off),
+		},
+		"get sel": {
+			action:   getAction("GetSEL"),
+			provider: &testProvider{SEL: sel},
+			// MaxEntries is 2, so only the two newest entries are kept.
+			wantSEL: []v1alpha1.SELEntry{
+				{ID: "3", Timestamp: "01/03/2024 00:00:00", Message: "Fan failure", Severity: "Critical"},
+				{ID: "2", Timestamp: "01/02/2024 00:00:00", Message: "Power restored", Severity: "OK"},
+			},
+		},
+		"get firmware inventory": {
+			action:   getAction("GetFirmwareInventory"),
+			provider: &testProvider{Device: device},
+			// The test provider does not support installing firmware, so no component is updateable.
+			wantFirmware: []v1alpha1.FirmwareComponent{
+				{Name: "BIOS", Version: "2.1"},
+				{Name: "BMC", Version: "5.10"},
+				{Name: "NIC BCM57416", Version: "221.0"},
 			},
 		},
+		"get bios config truncated": {
+			action:         getAction("GetBIOSConfig"),
+			provider:       &testProvider{BIOSConfig: biosConfig},
+			wantMessage:    "BIOS configuration truncated to 1000 of 1001 attributes",
+			wantBIOSConfig: truncatedBIOSConfig,
+		},
+		"verify connection": {
+			action:           getAction("VerifyConnection"),
+			provider:         &testProvider{Powerstate: "on"},
+			wantMessage:      "connection verified with providers: tester",
+			wantCapabilities: []string{"power", "bootdevice", "virtualmedia", "biosconfig", "sel", "inventory"},
+		},
+		"provider errors": {
+			action:             getAction("PowerOn"),
+			provider:           &testProvider{PName: "gofish", ErrPowerStateSet: errors.New("power set not permitted")},
+			wantFailed:         "power set not permitted",
+			wantProviderErrors: []v1alpha1.ProviderError{{Provider: "gofish", Error: "power set not permitted"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task, err := reconcileTask(t, createTask("Action", tt.action, secret), secret, tt.provider)
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if tt.wantFailed != "" {
+				cType := v1alpha1.TaskFailed
+				if tt.wantUnsupported {
+					cType = v1alpha1.TaskUnsupported
+				}
+				if !task.HasCondition(cType, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", cType, v1alpha1.ConditionTrue, task.Status.Conditions)
+				}
+				if msg := task.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+			} else {
+				if !task.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, task.Status.Conditions)
+				}
+				if diff := cmp.Diff(tt.wantMessage, task.Status.Conditions[0].Message); diff != "" {
+					t.Fatalf("unexpected condition message: %v", diff)
+				}
+			}
+			if diff := cmp.Diff(tt.wantBootSet, tt.provider.SetBootDevice); diff != "" {
+				t.Fatalf("unexpected boot device set: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPersistent, tt.provider.SetPersistent); diff != "" {
+				t.Fatalf("unexpected boot device persistence: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantBootOverride, task.Status.OneTimeBootOverride); diff != "" {
+				t.Fatalf("unexpected one time boot override: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPowerState, task.Status.PowerState); diff != "" {
+				t.Fatalf("unexpected power state: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantSEL, task.Status.SELEntries); diff != "" {
+				t.Fatalf("unexpected SEL entries: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFirmware, task.Status.Firmware); diff != "" {
+				t.Fatalf("unexpected firmware: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantBIOSConfig, task.Status.BIOSConfig); diff != "" {
+				t.Fatalf("unexpected BIOS config: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantCapabilities, task.Status.Capabilities); diff != "" {
+				t.Fatalf("unexpected capabilities: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantProviderErrors, task.Status.ProviderErrors); diff != "" {
+				t.Fatalf("unexpected provider errors: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileProvider(t *testing.T) {
+	tests := map[string]struct {
+		provider     *testProvider
+		wantProvider string
+	}{
+		"redfish":       {provider: &testProvider{PName: "gofish", PowerSetOK: true, Powerstate: "on"}, wantProvider: "gofish"},
+		"ipmi":          {provider: &testProvider{PName: "ipmitool", Proto: "ipmi", PowerSetOK: true, Powerstate: "on"}, wantProvider: "ipmitool"},
+		"action failed": {provider: &testProvider{PName: "gofish", ErrPowerStateSet: errors.New("power set not permitted")}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task, _ := reconcileTask(t, createTask("PowerOn", getAction("PowerOn"), secret), secret, tt.provider)
+			if diff := cmp.Diff(tt.wantProvider, task.Status.Provider); diff != "" {
+				t.Fatalf("unexpected provider: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileShutdownDrain(t *testing.T) {
+	tests := map[string]struct {
+		drain       time.Duration
+		wantPowerOn bool
+		wantFailed  bool
+	}{
+		"finishes within the drain period": {drain: time.Second, wantPowerOn: true},
+		"drain period expired":             {drain: 10 * time.Millisecond},
+		"no drain period":                  {wantFailed: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			provider := &testProvider{PowerSetOK: true, Powerstate: "on", PowerSetDelay: 200 * time.Millisecond}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+				WithShutdownDrain(tt.drain)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			// The controller shuts down while the power action is in flight.
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			_, _ = reconciler.Reconcile(ctx, request)
+
+			retrieved := &v1alpha1.Task{}
+			if err := cluster.Get(context.Background(), request.NamespacedName, retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantPowerOn, provider.PowerSetState == "on"); diff != "" {
+				t.Fatalf("unexpected power action: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected Failed condition: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileIdentify(t *testing.T) {
+	chassis := func(c string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Chassis":   `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1"}]}`,
+			"/redfish/v1/Chassis/1": c,
+		}
+	}
+	tests := map[string]struct {
+		state    v1alpha1.IdentifyState
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources map[string]string
+		// wantRequests are the requests that change the BMC, formatted as "METHOD path body".
+		wantRequests []string
+		wantMessage  string
+		wantFailed   string
+	}{
+		"on": {
+			state:        v1alpha1.IdentifyOn,
+			resources:    chassis(`{"LocationIndicatorActive":false,"IndicatorLED":"Off"}`),
+			wantRequests: []string{`PATCH /redfish/v1/Chassis/1 {"LocationIndicatorActive":true}`},
+		},
+		"off": {
+			state:        v1alpha1.IdentifyOff,
+			resources:    chassis(`{"LocationIndicatorActive":true,"IndicatorLED":"Lit"}`),
+			wantRequests: []string{`PATCH /redfish/v1/Chassis/1 {"LocationIndicatorActive":false}`},
+		},
+		"on older BMC": {
+			state:        v1alpha1.IdentifyOn,
+			resources:    chassis(`{"IndicatorLED":"Off"}`),
+			wantRequests: []string{`PATCH /redfish/v1/Chassis/1 {"IndicatorLED":"Lit"}`},
+		},
+		"blink": {
+			state:        v1alpha1.IdentifyBlink,
+			resources:    chassis(`{"LocationIndicatorActive":false,"IndicatorLED":"Off"}`),
+			wantRequests: []string{`PATCH /redfish/v1/Chassis/1 {"IndicatorLED":"Blinking"}`},
+		},
+		"blink unsupported": {
+			state:        v1alpha1.IdentifyBlink,
+			resources:    chassis(`{"LocationIndicatorActive":false}`),
+			wantRequests: []string{`PATCH /redfish/v1/Chassis/1 {"LocationIndicatorActive":true}`},
+			wantMessage:  "provider does not support blinking the identify LED, it was turned on instead",
+		},
+		"no identify LED": {
+			state:      v1alpha1.IdentifyOn,
+			resources:  chassis(`{}`),
+			wantFailed: "no chassis of the BMC has an identify LED",
+		},
+		"ipmi only": {
+			state:      v1alpha1.IdentifyOn,
+			protocol:   "ipmi",
+			wantFailed: "identify LEDs are not supported by protocols [ipmi], a Redfish capable BMC is required",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("Identify", v1alpha1.Action{IdentifyAction: &v1alpha1.IdentifyAction{State: tt.state}}, secret)
+			redfish.connect(task)
+
+			retrieved, err := reconcileTask(t, task, secret, &testProvider{Proto: tt.protocol})
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileIdentifyDuration(t *testing.T) {
+	redfish := newFakeRedfish(t, map[string]string{
+		"/redfish/v1/Chassis":   `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1"}]}`,
+		"/redfish/v1/Chassis/1": `{"LocationIndicatorActive":false}`,
+	})
+	secret := createSecret()
+	task := createTask("Identify", v1alpha1.Action{IdentifyAction: &v1alpha1.IdentifyAction{State: v1alpha1.IdentifyOn, DurationSeconds: ptr.To(60)}}, secret)
+	redfish.connect(task)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{}))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	// The first reconcile turns the LED on, the second one waits for the duration to pass.
+	var result ctrl.Result
+	for i := 0; i < 2; i++ {
+		var err error
+		if result, err = reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected nil err, got: %v", err)
+		}
+	}
+	if result.RequeueAfter <= 0 {
+		t.Fatalf("expected the task to be requeued until the duration has passed, got: %v", result)
+	}
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		t.Fatal("expected the task not to be completed before the duration has passed")
+	}
+
+	expired := metav1.NewTime(retrieved.Status.StartTime.Add(-time.Minute))
+	retrieved.Status.StartTime = &expired
+	if err := cluster.Status().Update(context.Background(), &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+	}
+	want := []string{
+		`PATCH /redfish/v1/Chassis/1 {"LocationIndicatorActive":true}`,
+		`PATCH /redfish/v1/Chassis/1 {"LocationIndicatorActive":false}`,
+	}
+	if diff := cmp.Diff(want, redfish.Requests()); diff != "" {
+		t.Fatalf("unexpected requests to the BMC: %v", diff)
+	}
+}
+
+func TestTaskReconcileSensors(t *testing.T) {
+	chassis := func(c string, resources map[string]string) map[string]string {
+		resources["/redfish/v1/Chassis"] = `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1"}]}`
+		resources["/redfish/v1/Chassis/1"] = c
+		return resources
+	}
+	// manyFans has 100 fans and a critical temperature after them, the temperature is kept first when truncated.
+	fans := make([]string, 0, 100)
+	wantTruncated := []v1alpha1.SensorReading{{Name: "CPU1 Temp", Type: "Temperature", Value: 98, Units: "Cel", Health: "Critical"}}
+	for i := 0; i < 100; i++ {
+		fans = append(fans, fmt.Sprintf(`{"Name":"Fan %d","Reading":4200,"ReadingUnits":"RPM","Status":{"Health":"OK"}}`, i))
+		if i < 99 {
+			wantTruncated = append(wantTruncated, v1alpha1.SensorReading{Name: fmt.Sprintf("Fan %d", i), Type: "Fan", Value: 4200, Units: "RPM", Health: "OK"})
+		}
+	}
+	manyFans := `{"Fans":[` + strings.Join(fans, ",") + `],"Temperatures":[{"Name":"CPU1 Temp","ReadingCelsius":98,"Status":{"Health":"Critical"}}]}`
+
+	tests := map[string]struct {
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources   map[string]string
+		wantSensors []v1alpha1.SensorReading
+		wantMessage string
+		wantFailed  string
+	}{
+		"thermal and power": {
+			resources: chassis(`{"Thermal":{"@odata.id":"/redfish/v1/Chassis/1/Thermal"},"Power":{"@odata.id":"/redfish/v1/Chassis/1/Power"}}`, map[string]string{
+				"/redfish/v1/Chassis/1/Thermal": `{"Temperatures":[{"Name":"Inlet Temp","ReadingCelsius":42,"Status":{"Health":"Warning"}},{"Name":"CPU1 Temp","ReadingCelsius":98,"Status":{"Health":"Critical"}},{"Name":"CPU2 Temp","Status":{"State":"Absent"}}],"Fans":[{"Name":"Fan 1","Reading":4200,"ReadingUnits":"RPM","Status":{"Health":"OK"}}]}`,
+				"/redfish/v1/Chassis/1/Power":   `{"Voltages":[{"Name":"PSU1 Voltage","ReadingVolts":12.1,"Status":{"Health":"OK"}}]}`,
+			}),
+			wantSensors: []v1alpha1.SensorReading{
+				{Name: "CPU1 Temp", Type: "Temperature", Value: 98, Units: "Cel", Health: "Critical"},
+				{Name: "Inlet Temp", Type: "Temperature", Value: 42, Units: "Cel", Health: "Warning"},
+				{Name: "Fan 1", Type: "Fan", Value: 4200, Units: "RPM", Health: "OK"},
+				{Name: "PSU1 Voltage", Type: "Voltage", Value: 12.1, Units: "V", Health: "OK"},
+			},
+		},
+		"sensors collection": {
+			resources: chassis(`{"Sensors":{"@odata.id":"/redfish/v1/Chassis/1/Sensors"}}`, map[string]string{
+				"/redfish/v1/Chassis/1/Sensors":      `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1/Sensors/CPU1"},{"@odata.id":"/redfish/v1/Chassis/1/Sensors/PSU1"}]}`,
+				"/redfish/v1/Chassis/1/Sensors/PSU1": `{"Name":"PSU1 Power","ReadingType":"Power","Reading":250,"ReadingUnits":"W"}`,
+				"/redfish/v1/Chassis/1/Sensors/CPU1": `{"Name":"CPU1 Temp","ReadingType":"Temperature","Reading":55,"ReadingUnits":"Cel","Status":{"Health":"OK"}}`,
+			}),
+			wantSensors: []v1alpha1.SensorReading{
+				{Name: "CPU1 Temp", Type: "Temperature", Value: 55, Units: "Cel", Health: "OK"},
+				{Name: "PSU1 Power", Type: "Power", Value: 250, Units: "W"},
+			},
+		},
+		"truncated": {
+			resources: chassis(`{"Thermal":{"@odata.id":"/redfish/v1/Chassis/1/Thermal"}}`, map[string]string{
+				"/redfish/v1/Chassis/1/Thermal": manyFans,
+			}),
+			wantSensors: wantTruncated,
+			wantMessage: "100 of 101 sensor readings stored, critical and warning readings first",
+		},
+		"ipmi only": {
+			protocol:   "ipmi",
+			wantFailed: "sensors are not supported by protocols [ipmi], a Redfish capable BMC is required",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("Sensors", v1alpha1.Action{GetSensorsAction: &v1alpha1.GetSensorsAction{}}, secret)
+			redfish.connect(task)
+
+			retrieved, err := reconcileTask(t, task, secret, &testProvider{Proto: tt.protocol})
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if tt.wantFailed != "" {
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantSensors, retrieved.Status.Sensors); diff != "" {
+				t.Fatalf("unexpected sensor readings: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileDefaultBMCTimeout(t *testing.T) {
+	tests := map[string]struct {
+		timeout     *metav1.Duration
+		shouldFail  bool
+		wantMessage string
+	}{
+		"default timeout exceeded": {
+			shouldFail:  true,
+			wantMessage: "BMC operation exceeded default BMC timeout 50ms",
+		},
+		"task timeout overrides default": {timeout: &metav1.Duration{Duration: time.Minute}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.Timeout = tt.timeout
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			provider := &testProvider{PowerSetOK: true, PowerSetDelay: 200 * time.Millisecond}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+				WithDefaultBMCTimeout(50 * time.Millisecond)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.shouldFail != (err != nil) {
+				t.Fatalf("expected err: %v, got: %v", tt.shouldFail, err)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.shouldFail {
+				if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if !strings.Contains(retrieved.Status.Conditions[0].Message, tt.wantMessage) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantMessage, retrieved.Status.Conditions[0].Message)
+				}
+				return
+			}
+			if diff := cmp.Diff("on", provider.PowerSetState); diff != "" {
+				t.Fatalf("unexpected power state: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileIdempotentPower(t *testing.T) {
+	tests := map[string]struct {
+		action       v1alpha1.PowerAction
+		powerState   string
+		wantSetState string
+		wantMessage  string
+	}{
+		"already on":  {action: v1alpha1.PowerOn, powerState: "on", wantMessage: "already on; no action taken"},
+		"already off": {action: v1alpha1.PowerSoftOff, powerState: "off", wantMessage: "already off; no action taken"},
+		"power on":    {action: v1alpha1.PowerOn, powerState: "off", wantSetState: "on"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("Power", v1alpha1.Action{PowerAction: tt.action.Ptr()}, secret)
+			task.Spec.IdempotentPower = true
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			provider := &testProvider{Powerstate: tt.powerState, PowerSetOK: true}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantSetState, provider.PowerSetState); diff != "" {
+				t.Fatalf("unexpected power set state: %v", diff)
+			}
+			if tt.wantMessage == "" {
+				return
+			}
+
+			// The second reconcile checks the power state and marks the Task completed.
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileLogFields(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{})
+
+	secret := createSecret()
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"}))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+	if _, err := reconciler.Reconcile(ctrl.LoggerInto(context.Background(), logger), request); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+
+	var checked int
+	for _, line := range lines {
+		if strings.Contains(line, `"username"`) || strings.Contains(line, `"password"`) {
+			t.Fatalf("expected no credentials in log line, got: %s", line)
+		}
+		if strings.Contains(line, `"msg"="Reconciling Task"`) {
+			continue
+		}
+		checked++
+		for _, field := range []string{`"task"=`, `"action"="PowerAction(on)"`, `"host"="host"`, `"authSecretRef"="test-namespace/test-bm-auth"`} {
+			if !strings.Contains(line, field) {
+				t.Fatalf("expected log line to contain %s, got: %s", field, line)
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("expected log lines with correlation fields, got none")
+	}
+}
+
+func TestTaskReconcileLastBootSource(t *testing.T) {
+	system := func(target string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1": fmt.Sprintf(`{"Name":"System","Boot":{"BootSourceOverrideEnabled":"Disabled","BootSourceOverrideTarget":%q}}`, target),
+		}
+	}
+	tests := map[string]struct {
+		resources          map[string]string
+		wantLastBootSource string
+		wantMessage        string
+	}{
+		"pxe":               {resources: system("Pxe"), wantLastBootSource: "pxe"},
+		"not a boot device": {resources: system("UefiHttp"), wantLastBootSource: "UefiHttp"},
+		"no boot override":  {resources: system("None")},
+		"unreadable system": {wantMessage: "last boot source is unavailable: reading Redfish resource /redfish/v1/Systems returned 404 Not Found"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("PowerStatus", getAction("PowerStatus"), secret)
+			redfish.connect(task)
+
+			retrieved, err := reconcileTask(t, task, secret, &testProvider{Powerstate: "on"})
+			if err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(string(v1alpha1.On), retrieved.Status.PowerState); diff != "" {
+				t.Fatalf("unexpected power state: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantLastBootSource, retrieved.Status.LastBootSource); diff != "" {
+				t.Fatalf("unexpected last boot source: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileGetBootDevice(t *testing.T) {
+	tests := map[string]struct {
+		// resources are the Redfish resources of the BMC by path.
+		resources       map[string]string
+		protocol        string
+		bootOverride    bmc.BootDeviceOverride
+		errBootOverride error
+		wantBootOrder   []v1alpha1.BootDevice
+		wantMessage     string
+		shouldErr       bool
+	}{
+		"boot order": {
+			resources:     redfishBootResources(),
+			wantBootOrder: []v1alpha1.BootDevice{v1alpha1.Disk, v1alpha1.PXE, v1alpha1.PXE, v1alpha1.BIOS},
+		},
+		"boot option names": {
+			resources: map[string]string{
+				"/redfish/v1/Systems":                 `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+				"/redfish/v1/Systems/1":               `{"Name":"System","Boot":{"BootOrder":["Boot0001","Boot0002","Boot0003"],"BootOptions":{"@odata.id":"/redfish/v1/Systems/1/BootOptions"}}}`,
+				"/redfish/v1/Systems/1/BootOptions":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1/BootOptions/1"},{"@odata.id":"/redfish/v1/Systems/1/BootOptions/2"}]}`,
+				"/redfish/v1/Systems/1/BootOptions/1": `{"BootOptionReference":"Boot0001","DisplayName":"UEFI Shell","Alias":"UefiShell"}`,
+				"/redfish/v1/Systems/1/BootOptions/2": `{"BootOptionReference":"Boot0002","Alias":"Usb"}`,
+			},
+			wantBootOrder: []v1alpha1.BootDevice{"UEFI Shell", "Boot0002", "Boot0003"},
+		},
+		"redfish without boot order": {
+			resources: map[string]string{
+				"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+				"/redfish/v1/Systems/1": `{"Name":"System","Boot":{}}`,
+			},
+			bootOverride:  bmc.BootDeviceOverride{Device: bmc.BootDeviceTypePXE},
+			wantBootOrder: []v1alpha1.BootDevice{v1alpha1.PXE},
+			wantMessage:   "provider does not support reading the boot order, only the boot override device was read (persistent: false, efiBoot: false)",
+		},
+		"boot override only": {
+			protocol:      "ipmi",
+			bootOverride:  bmc.BootDeviceOverride{Device: bmc.BootDeviceTypeDisk, IsPersistent: true},
+			wantBootOrder: []v1alpha1.BootDevice{v1alpha1.Disk},
+			wantMessage:   "provider does not support reading the boot order, only the boot override device was read (persistent: true, efiBoot: false)",
+		},
+		"no boot override": {
+			protocol:     "ipmi",
+			bootOverride: bmc.BootDeviceOverride{Device: bmc.BootDeviceTypeNone},
+			wantMessage:  "provider does not support reading the boot order, no boot override is set",
+		},
+		"unsupported": {protocol: "ipmi", errBootOverride: bmclibErrs.ErrProviderImplementation, shouldErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("GetBootDevice", v1alpha1.Action{GetBootDeviceAction: &v1alpha1.GetBootDeviceAction{}}, secret)
+			redfish.connect(task)
+			provider := &testProvider{Proto: tt.protocol, BootOverride: tt.bootOverride, ErrBootOverrideGet: tt.errBootOverride}
+
+			retrieved, err := reconcileTask(t, task, secret, provider)
+			if tt.shouldErr {
+				if err == nil {
+					t.Fatal("expected err, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantBootOrder, retrieved.Status.BootOrder); diff != "" {
+				t.Fatalf("unexpected boot order: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileSetBMCCredentials(t *testing.T) {
+	tests := map[string]struct {
+		provider    *testProvider
+		noPassword  bool
+		wantUser    string
+		wantErrText string
+	}{
+		"updated":     {provider: &testProvider{UserUpdateOK: true}, wantUser: "operator"},
+		"not found":   {provider: &testProvider{}, wantErrText: "failed to update BMC account \"operator\""},
+		"unsupported": {provider: &testProvider{ErrUserUpdate: bmclibErrs.ErrProviderImplementation}, wantErrText: "provider does not support updating BMC accounts"},
+		"no password": {provider: &testProvider{UserUpdateOK: true}, noPassword: true, wantErrText: "'password' required"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			passwordSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "new-password", Namespace: "default"},
+				Data:       map[string][]byte{"password": []byte("s3cret")},
+			}
+			if tt.noPassword {
+				passwordSecret.Data = nil
+			}
+			action := v1alpha1.Action{SetBMCCredentialsAction: &v1alpha1.SetBMCCredentialsAction{
+				Username:          "operator",
+				PasswordSecretRef: corev1.SecretReference{Name: "new-password", Namespace: "default"},
+				Role:              "Administrator",
+			}}
+			task := createTask("SetBMCCredentials", action, secret)
+			cluster := newClientBuilder().
+				WithObjects(task, secret, passwordSecret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(tt.provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			// A single reconcile completes the Task, the changed account is not used to check its status.
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("expected err containing %q, got: %v", tt.wantErrText, err)
+				}
+				if strings.Contains(err.Error(), "s3cret") {
+					t.Fatalf("error contains the new password: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			got := []string{tt.provider.UpdatedUser, tt.provider.UpdatedPassword, tt.provider.UpdatedRole}
+			if diff := cmp.Diff([]string{tt.wantUser, "s3cret", "Administrator"}, got); diff != "" {
+				t.Fatalf("unexpected user update: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileActions(t *testing.T) {
+	tests := map[string]struct {
+		provider        *testProvider
+		wantFailedIndex *int
+		wantMessage     string
+	}{
+		"all actions succeed": {
+			provider: &testProvider{Powerstate: "on", PowerSetOK: true, BootdeviceOK: true},
+		},
+		"second action fails": {
+			provider:        &testProvider{Powerstate: "on", BootdeviceOK: true, ErrPowerStateSet: errors.New("power set failed")},
+			wantFailedIndex: ptr.To(1),
+			wantMessage:     "action 1 (PowerAction(on)) of 2 failed",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("Actions", v1alpha1.Action{}, secret)
+			task.Spec.Actions = []v1alpha1.Action{
+				{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}},
+				{PowerAction: v1alpha1.PowerOn.Ptr()},
+			}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			opens := 0
+			factory := newTestClient(tt.provider)
+			counting := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				opens++
+				return factory(ctx, log, hostIP, username, password, opts)
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), counting)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			// A single reconcile runs and checks all actions over one BMC connection.
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != (tt.wantFailedIndex != nil) {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailedIndex != nil, err)
+			}
+			if opens != 1 {
+				t.Fatalf("expected 1 BMC connection, got: %d", opens)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantFailedIndex, retrieved.Status.FailedActionIndex); diff != "" {
+				t.Fatalf("unexpected failed action index: %v", diff)
+			}
+			if tt.wantFailedIndex != nil {
+				if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if !strings.Contains(retrieved.Status.Conditions[0].Message, tt.wantMessage) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantMessage, retrieved.Status.Conditions[0].Message)
+				}
+				return
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff("on", tt.provider.PowerSetState); diff != "" {
+				t.Fatalf("unexpected power state set: %v", diff)
+			}
+			if diff := cmp.Diff(1, retrieved.Status.ActionIndex); diff != "" {
+				t.Fatalf("unexpected action index: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileRedfishPassthrough(t *testing.T) {
+	tests := map[string]struct {
+		status       int
+		response     string
+		userAgent    string
+		wantResponse *v1alpha1.RedfishResponse
+		wantErr      bool
+	}{
+		"accepted": {
+			status:       http.StatusAccepted,
+			response:     `{"@odata.id":"/redfish/v1/TaskService/Tasks/JID_1"}`,
+			wantResponse: &v1alpha1.RedfishResponse{StatusCode: http.StatusAccepted, Body: `{"@odata.id":"/redfish/v1/TaskService/Tasks/JID_1"}`},
+		},
+		"user agent": {
+			status:       http.StatusOK,
+			response:     `{}`,
+			userAgent:    "rufio/1.0",
+			wantResponse: &v1alpha1.RedfishResponse{StatusCode: http.StatusOK, Body: `{}`},
+		},
+		"not found": {
+			status:       http.StatusNotFound,
+			response:     `{"error":{}}`,
+			wantResponse: &v1alpha1.RedfishResponse{StatusCode: http.StatusNotFound, Body: `{"error":{}}`},
+			wantErr:      true,
+		},
+		"truncated": {
+			status:       http.StatusOK,
+			response:     strings.Repeat("a", 40<<10),
+			wantResponse: &v1alpha1.RedfishResponse{StatusCode: http.StatusOK, Body: strings.Repeat("a", 32<<10), Truncated: true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotMethod, gotPath, gotBody, gotUser, gotUserAgent string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				gotMethod, gotPath, gotBody = r.Method, r.URL.Path, string(b)
+				gotUser, _, _ = r.BasicAuth()
+				gotUserAgent = r.UserAgent()
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+			host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+			p, _ := strconv.Atoi(port)
+
+			secret := createSecret()
+			action := v1alpha1.Action{RedfishActionPassthroughAction: &v1alpha1.RedfishActionPassthroughAction{
+				Path:   "/redfish/v1/Systems/1/Actions/Oem/ClearForeignConfig",
+				Method: http.MethodPost,
+				Body:   &runtime.RawExtension{Raw: []byte(`{"TargetFQDD":"RAID.1"}`)},
+			}}
+			task := createTask("RedfishPassthrough", action, secret)
+			task.Spec.Connection.Host = host
+			task.Spec.Connection.ProviderOptions.Redfish.Port = p
+			task.Spec.Connection.InsecureTLS = true
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{})).
+				WithHTTPClientConfig(controller.HTTPClientConfig{UserAgent: tt.userAgent})
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected err %v, got: %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff([]string{http.MethodPost, action.RedfishActionPassthroughAction.Path, `{"TargetFQDD":"RAID.1"}`, "test"}, []string{gotMethod, gotPath, gotBody, gotUser}); diff != "" {
+				t.Fatalf("unexpected request: %v", diff)
+			}
+			if tt.userAgent != "" && gotUserAgent != tt.userAgent {
+				t.Fatalf("expected User-Agent %q, got %q", tt.userAgent, gotUserAgent)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantResponse, retrieved.Status.RedfishResponse); diff != "" {
+				t.Fatalf("unexpected response: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileCACert(t *testing.T) {
+	otherCA := createClientCertSecret(t).Data[corev1.TLSCertKey]
+	tests := map[string]struct {
+		ref *v1alpha1.CACertReference
+		// bundle returns the objects holding the CA bundle, given the certificate of the BMC.
+		bundle      func(serverCert []byte) []client.Object
+		wantRequest bool
+		wantErr     string
+	}{
+		"secret": {
+			ref: &v1alpha1.CACertReference{Name: "bmc-ca"},
+			bundle: func(serverCert []byte) []client.Object {
+				return []client.Object{&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "bmc-ca"},
+					Data:       map[string][]byte{"ca.crt": serverCert},
+				}}
+			},
+			wantRequest: true,
+		},
+		"configmap with key": {
+			ref: &v1alpha1.CACertReference{Kind: v1alpha1.CACertKindConfigMap, Name: "bmc-ca", Namespace: "default", Key: "bundle.pem"},
+			bundle: func(serverCert []byte) []client.Object {
+				return []client.Object{&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bmc-ca"},
+					Data:       map[string]string{"bundle.pem": string(otherCA) + string(serverCert)},
+				}}
+			},
+			wantRequest: true,
+		},
+		"certificate of another CA": {
+			ref: &v1alpha1.CACertReference{Name: "bmc-ca"},
+			bundle: func([]byte) []client.Object {
+				return []client.Object{&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "bmc-ca"},
+					Data:       map[string][]byte{"ca.crt": otherCA},
+				}}
+			},
+			wantErr: "certificate",
+		},
+		"missing configmap": {
+			ref:     &v1alpha1.CACertReference{Kind: v1alpha1.CACertKindConfigMap, Name: "bmc-ca"},
+			bundle:  func([]byte) []client.Object { return nil },
+			wantErr: "CA certificate ConfigMap test-namespace/bmc-ca not found",
+		},
+		"no certificate in bundle": {
+			ref: &v1alpha1.CACertReference{Name: "bmc-ca"},
+			bundle: func([]byte) []client.Object {
+				return []client.Object{&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "bmc-ca"},
+					Data:       map[string][]byte{"ca.crt": []byte("not a certificate")},
+				}}
+			},
+			wantErr: "invalid CA certificate in Secret test-namespace/bmc-ca",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			requested := false
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requested = true
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+			host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+			p, _ := strconv.Atoi(port)
+			serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+			secret := createSecret()
+			action := v1alpha1.Action{RedfishActionPassthroughAction: &v1alpha1.RedfishActionPassthroughAction{
+				Path:   "/redfish/v1/Systems/1",
+				Method: http.MethodPatch,
+				Body:   &runtime.RawExtension{Raw: []byte(`{"AssetTag":"rack-1"}`)},
+			}}
+			task := createTask("CACert", action, secret)
+			task.Spec.Connection.Host = host
+			task.Spec.Connection.ProviderOptions.Redfish.Port = p
+			task.Spec.Connection.CACertSecretRef = tt.ref
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithObjects(tt.bundle(serverCert)...).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected err containing %q, got: %v", tt.wantErr, err)
+			}
+			if requested != tt.wantRequest {
+				t.Fatalf("expected the BMC to be requested to be %v", tt.wantRequest)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileUpdateFirmware(t *testing.T) {
+	image := []byte("firmware image")
+	sum := sha256.Sum256(image)
+	tests := map[string]struct {
+		checksum string
+		// monitor are the responses of the task monitor to the status checks after the update started.
+		monitor       []string
+		wantUpdate    bool
+		wantProgress  []int
+		wantCompleted bool
+		wantFailed    string
+	}{
+		"completed": {
+			checksum:      "sha256:" + hex.EncodeToString(sum[:]),
+			monitor:       []string{`{"TaskState":"Running","PercentComplete":40}`, `{"TaskState":"Completed","TaskStatus":"OK","PercentComplete":100}`},
+			wantUpdate:    true,
+			wantProgress:  []int{0, 40, 100},
+			wantCompleted: true,
+		},
+		"failed on the BMC": {
+			monitor:      []string{`{"TaskState":"Exception","TaskStatus":"Critical","PercentComplete":10,"Messages":[{"Message":"image signature invalid"}]}`},
+			wantUpdate:   true,
+			wantProgress: []int{0, 10},
+			wantFailed:   "firmware update exception: image signature invalid",
+		},
+		"checksum mismatch": {
+			checksum:     "sha256:" + strings.Repeat("0", 64),
+			wantProgress: []int{0},
+			wantFailed:   "does not match",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			imageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(image)
+			}))
+			defer imageServer.Close()
+
+			var gotUpdate string
+			var checks int
+			bmc := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate":
+					b, _ := io.ReadAll(r.Body)
+					gotUpdate = string(b)
+					w.Header().Set("Location", "/redfish/v1/TaskService/Tasks/JID_1")
+					w.WriteHeader(http.StatusAccepted)
+				case r.Method == http.MethodGet && r.URL.Path == "/redfish/v1/TaskService/Tasks/JID_1" && checks < len(tt.monitor):
+					_, _ = w.Write([]byte(tt.monitor[checks]))
+					checks++
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer bmc.Close()
+			host, port, _ := net.SplitHostPort(bmc.Listener.Addr().String())
+			p, _ := strconv.Atoi(port)
+
+			secret := createSecret()
+			action := v1alpha1.Action{UpdateFirmwareAction: &v1alpha1.UpdateFirmwareAction{Component: "BIOS", ImageURL: imageServer.URL + "/bios.bin", Checksum: tt.checksum}}
+			task := createTask("UpdateFirmware", action, secret)
+			task.Spec.Connection.Host = host
+			task.Spec.Connection.ProviderOptions.Redfish.Port = p
+			task.Spec.Connection.InsecureTLS = true
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			var retrieved v1alpha1.Task
+			for i, want := range tt.wantProgress {
+				result, err := reconciler.Reconcile(context.Background(), request)
+				last := i == len(tt.wantProgress)-1
+				if (err != nil) != (last && tt.wantFailed != "") {
+					t.Fatalf("reconcile %d: unexpected err: %v", i, err)
+				}
+				if i > 0 && !last && result.RequeueAfter == 0 {
+					t.Fatalf("reconcile %d: expected requeue while the update runs", i)
+				}
+				if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				if diff := cmp.Diff(want, retrieved.Status.FirmwareProgress); diff != "" {
+					t.Fatalf("reconcile %d: unexpected progress: %v", i, diff)
+				}
+			}
+
+			wantUpdate := ""
+			if tt.wantUpdate {
+				wantUpdate = fmt.Sprintf(`{"ImageURI":%q,"Targets":["/redfish/v1/UpdateService/FirmwareInventory/BIOS"]}`, imageServer.URL+"/bios.bin")
+				if diff := cmp.Diff("/redfish/v1/TaskService/Tasks/JID_1", retrieved.Status.FirmwareTaskMonitor); diff != "" {
+					t.Fatalf("unexpected task monitor: %v", diff)
+				}
+			}
+			if diff := cmp.Diff(wantUpdate, gotUpdate); diff != "" {
+				t.Fatalf("unexpected SimpleUpdate request: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+					t.Fatal("expected task failed")
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcilePowerLimit(t *testing.T) {
+	tests := map[string]struct {
+		action v1alpha1.Action
+		// resources are the Redfish resources of the BMC by path.
+		resources      map[string]string
+		protocol       string
+		wantPatchPath  string
+		wantPatch      string
+		wantPowerLimit *v1alpha1.PowerLimit
+		wantFailed     string
+		// wantUnsupported expects the Task to be Unsupported instead of Failed.
+		wantUnsupported bool
+	}{
+		"get from control": {
+			action: v1alpha1.Action{GetPowerLimitAction: &v1alpha1.GetPowerLimitAction{}},
+			resources: map[string]string{
+				"/redfish/v1/Chassis/1":                `{"Controls":{"@odata.id":"/redfish/v1/Chassis/1/Controls"}}`,
+				"/redfish/v1/Chassis/1/Controls":       `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1/Controls/Fan"},{"@odata.id":"/redfish/v1/Chassis/1/Controls/Power"}]}`,
+				"/redfish/v1/Chassis/1/Controls/Fan":   `{"ControlType":"Percent","SetPoint":40}`,
+				"/redfish/v1/Chassis/1/Controls/Power": `{"ControlType":"Power","SetPoint":500,"AllowableMin":200,"AllowableMax":800,"Sensor":{"Reading":312.6}}`,
+			},
+			wantPowerLimit: &v1alpha1.PowerLimit{LimitWatts: 500, ConsumedWatts: 313, AllowableMinWatts: 200, AllowableMaxWatts: 800},
+		},
+		"set with control": {
+			action: v1alpha1.Action{SetPowerLimitAction: &v1alpha1.SetPowerLimitAction{LimitWatts: 400}},
+			resources: map[string]string{
+				"/redfish/v1/Chassis/1":                `{"Controls":{"@odata.id":"/redfish/v1/Chassis/1/Controls"}}`,
+				"/redfish/v1/Chassis/1/Controls":       `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1/Controls/Power"}]}`,
+				"/redfish/v1/Chassis/1/Controls/Power": `{"ControlType":"Power","SetPoint":500,"AllowableMin":200,"AllowableMax":800}`,
+			},
+			wantPatchPath:  "/redfish/v1/Chassis/1/Controls/Power",
+			wantPatch:      `{"SetPoint":400}`,
+			wantPowerLimit: &v1alpha1.PowerLimit{LimitWatts: 500, AllowableMinWatts: 200, AllowableMaxWatts: 800},
+		},
+		"set with power resource": {
+			action: v1alpha1.Action{SetPowerLimitAction: &v1alpha1.SetPowerLimitAction{LimitWatts: 400}},
+			resources: map[string]string{
+				"/redfish/v1/Chassis/1":       `{"Power":{"@odata.id":"/redfish/v1/Chassis/1/Power"}}`,
+				"/redfish/v1/Chassis/1/Power": `{"PowerControl":[{"PowerConsumedWatts":250,"PowerCapacityWatts":750}]}`,
+			},
+			wantPatchPath:  "/redfish/v1/Chassis/1/Power",
+			wantPatch:      `{"PowerControl":[{"PowerLimit":{"LimitInWatts":400}}]}`,
+			wantPowerLimit: &v1alpha1.PowerLimit{ConsumedWatts: 250, AllowableMaxWatts: 750},
+		},
+		"set out of range": {
+			action: v1alpha1.Action{SetPowerLimitAction: &v1alpha1.SetPowerLimitAction{LimitWatts: 100}},
+			resources: map[string]string{
+				"/redfish/v1/Chassis/1":                `{"Controls":{"@odata.id":"/redfish/v1/Chassis/1/Controls"}}`,
+				"/redfish/v1/Chassis/1/Controls":       `{"Members":[{"@odata.id":"/redfish/v1/Chassis/1/Controls/Power"}]}`,
+				"/redfish/v1/Chassis/1/Controls/Power": `{"ControlType":"Power","SetPoint":500,"AllowableMin":200,"AllowableMax":800}`,
+			},
+			wantPowerLimit: &v1alpha1.PowerLimit{LimitWatts: 500, AllowableMinWatts: 200, AllowableMaxWatts: 800},
+			wantFailed:     "power limit 100W is out of the range [200W, 800W] allowed by the BMC",
+		},
+		"ipmi only": {
+			action:          v1alpha1.Action{GetPowerLimitAction: &v1alpha1.GetPowerLimitAction{}},
+			protocol:        "ipmi",
+			wantFailed:      "power limits are not supported by protocols [ipmi], a Redfish capable BMC is required",
+			wantUnsupported: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotPatchPath, gotPatch string
+			bmc := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPatch {
+					b, _ := io.ReadAll(r.Body)
+					gotPatchPath, gotPatch = r.URL.Path, string(b)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				if r.URL.Path == "/redfish/v1/Chassis" {
+					_, _ = w.Write([]byte(`{"Members":[{"@odata.id":"/redfish/v1/Chassis/1"}]}`))
+					return
+				}
+				body, ok := tt.resources[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(body))
+			}))
+			defer bmc.Close()
+			host, port, _ := net.SplitHostPort(bmc.Listener.Addr().String())
+			p, _ := strconv.Atoi(port)
+
+			secret := createSecret()
+			task := createTask("PowerLimit", tt.action, secret)
+			task.Spec.Connection.Host = host
+			task.Spec.Connection.ProviderOptions.Redfish.Port = p
+			task.Spec.Connection.InsecureTLS = true
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{Proto: tt.protocol}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if tt.wantFailed == "" {
+				// The Task is completed by the status check of the next reconcile.
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+			}
+
+			retrieved := &v1alpha1.Task{}
+			if err := cluster.Get(context.Background(), request.NamespacedName, retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantPowerLimit, retrieved.Status.PowerLimit); diff != "" {
+				t.Fatalf("unexpected power limit: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPatchPath, gotPatchPath); diff != "" {
+				t.Fatalf("unexpected patched resource: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPatch, gotPatch); diff != "" {
+				t.Fatalf("unexpected patch: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed == "", retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if diff := cmp.Diff(tt.wantUnsupported, retrieved.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue)); diff != "" {
+					t.Fatalf("unexpected task unsupported: %v", diff)
+				}
+				if diff := cmp.Diff(!tt.wantUnsupported, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+					t.Fatalf("unexpected task failed: %v", diff)
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcileBMCNetwork(t *testing.T) {
+	resources := func(iface string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Managers":                           `{"Members":[{"@odata.id":"/redfish/v1/Managers/1"}]}`,
+			"/redfish/v1/Managers/1":                         `{"ManagerType":"BMC","EthernetInterfaces":{"@odata.id":"/redfish/v1/Managers/1/EthernetInterfaces"},"NetworkProtocol":{"@odata.id":"/redfish/v1/Managers/1/NetworkProtocol"}}`,
+			"/redfish/v1/Managers/1/NetworkProtocol":         `{"HostName":"bmc-01"}`,
+			"/redfish/v1/Managers/1/EthernetInterfaces":      `{"Members":[{"@odata.id":"/redfish/v1/Managers/1/EthernetInterfaces/eth0"}]}`,
+			"/redfish/v1/Managers/1/EthernetInterfaces/eth0": iface,
+		}
+	}
+	dhcpInterface := `{"Id":"eth0","MACAddress":"00:25:90:5b:12:34","DHCPv4":{"DHCPEnabled":true},"IPv4Addresses":[{"Address":"10.0.10.50","SubnetMask":"255.255.255.0","Gateway":"10.0.10.1"}]}`
+	staticInterface := `{"Id":"eth0","MACAddress":"00:25:90:5b:12:34","DHCPv4":{"DHCPEnabled":true},"IPv4Addresses":[{"Address":"10.0.10.50","SubnetMask":"255.255.255.0","Gateway":"10.0.10.1"}],"IPv4StaticAddresses":[]}`
+	wantNetwork := &v1alpha1.BMCNetwork{
+		HostName:   "bmc-01",
+		Interfaces: []v1alpha1.BMCNetworkInterface{{ID: "eth0", MACAddress: "00:25:90:5b:12:34", DHCP: true, Address: "10.0.10.50", SubnetMask: "255.255.255.0", Gateway: "10.0.10.1"}},
+	}
+	tests := map[string]struct {
+		action   v1alpha1.Action
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources map[string]string
+		// wantRequests are the requests that change the BMC, formatted as "METHOD path body".
+		wantRequests []string
+		wantNetwork  *v1alpha1.BMCNetwork
+		wantFailed   string
+	}{
+		"get": {
+			action:      v1alpha1.Action{GetBMCNetworkAction: &v1alpha1.GetBMCNetworkAction{}},
+			resources:   resources(dhcpInterface),
+			wantNetwork: wantNetwork,
+		},
+		"set static": {
+			action:       v1alpha1.Action{SetBMCNetworkAction: &v1alpha1.SetBMCNetworkAction{Interface: "eth0", Address: "10.0.10.21", SubnetMask: "255.255.255.0", Gateway: "10.0.10.1"}},
+			resources:    resources(dhcpInterface),
+			wantRequests: []string{`PATCH /redfish/v1/Managers/1/EthernetInterfaces/eth0 {"DHCPv4":{"DHCPEnabled":false},"IPv4Addresses":[{"Address":"10.0.10.21","SubnetMask":"255.255.255.0","Gateway":"10.0.10.1"}]}`},
+			wantNetwork:  wantNetwork,
+		},
+		"set static addresses": {
+			action:       v1alpha1.Action{SetBMCNetworkAction: &v1alpha1.SetBMCNetworkAction{Address: "10.0.10.21", SubnetMask: "255.255.255.0"}},
+			resources:    resources(staticInterface),
+			wantRequests: []string{`PATCH /redfish/v1/Managers/1/EthernetInterfaces/eth0 {"DHCPv4":{"DHCPEnabled":false},"IPv4StaticAddresses":[{"Address":"10.0.10.21","SubnetMask":"255.255.255.0"}]}`},
+			wantNetwork:  wantNetwork,
+		},
+		"set dhcp": {
+			action:       v1alpha1.Action{SetBMCNetworkAction: &v1alpha1.SetBMCNetworkAction{DHCP: true}},
+			resources:    resources(dhcpInterface),
+			wantRequests: []string{`PATCH /redfish/v1/Managers/1/EthernetInterfaces/eth0 {"DHCPv4":{"DHCPEnabled":true}}`},
+			wantNetwork:  wantNetwork,
+		},
+		"set unknown interface": {
+			action:      v1alpha1.Action{SetBMCNetworkAction: &v1alpha1.SetBMCNetworkAction{Interface: "eth1", DHCP: true}},
+			resources:   resources(dhcpInterface),
+			wantNetwork: wantNetwork,
+			wantFailed:  `the BMC has no Ethernet interface "eth1"`,
+		},
+		"ipmi only": {
+			action:     v1alpha1.Action{GetBMCNetworkAction: &v1alpha1.GetBMCNetworkAction{}},
+			protocol:   "ipmi",
+			wantFailed: "BMC network configurations are not supported by protocols [ipmi], a Redfish capable BMC is required",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("BMCNetwork", tt.action, secret)
+			redfish.connect(task)
+
+			retrieved, err := reconcileTask(t, task, secret, &testProvider{Proto: tt.protocol})
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantNetwork, retrieved.Status.BMCNetwork); diff != "" {
+				t.Fatalf("unexpected BMC network: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed == "", retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcileBootProgress(t *testing.T) {
+	resources := func(system string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1": system,
+		}
+	}
+	tests := map[string]struct {
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources        map[string]string
+		wantBootProgress string
+		wantFailed       string
+	}{
+		"boot progress": {
+			resources:        resources(`{"Name":"System","BootProgress":{"LastState":"MemoryInitializationStarted","LastStateTime":"2026-10-16T08:00:00Z"}}`),
+			wantBootProgress: "MemoryInitializationStarted",
+		},
+		"oem boot progress": {
+			resources:        resources(`{"Name":"System","BootProgress":{"LastState":"OEM","OemLastState":"DIMMTrainingInProgress"}}`),
+			wantBootProgress: "DIMMTrainingInProgress",
+		},
+		"not reported": {
+			resources:  resources(`{"Name":"System"}`),
+			wantFailed: "the BMC does not report the boot progress of the computer system",
+		},
+		"ipmi only": {
+			protocol:   "ipmi",
+			wantFailed: "boot progress states are not supported by protocols [ipmi], a Redfish capable BMC is required",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("BootProgress", v1alpha1.Action{GetBootProgressAction: &v1alpha1.GetBootProgressAction{}}, secret)
+			redfish.connect(task)
+
+			retrieved, err := reconcileTask(t, task, secret, &testProvider{Proto: tt.protocol})
+			if (err != nil) != (tt.wantFailed != "") {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantBootProgress, retrieved.Status.BootProgress); diff != "" {
+				t.Fatalf("unexpected boot progress: %v", diff)
+			}
+			if tt.wantFailed != "" {
+				if !retrieved.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue) {
+					t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+				}
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantFailed) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantFailed, msg)
+				}
+				if diff := cmp.Diff(v1alpha1.FailureReasonUnsupported, retrieved.Status.FailureReason); diff != "" {
+					t.Fatalf("unexpected failure reason: %v", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcileSecretNamespace(t *testing.T) {
+	tests := map[string]struct {
+		secretNamespace string
+		refNamespace    string
+		wantErr         bool
+	}{
+		"same namespace without namespace":  {secretNamespace: "default"},
+		"other namespace":                   {secretNamespace: "bmc-secrets", refNamespace: "bmc-secrets"},
+		"other namespace without namespace": {secretNamespace: "bmc-secrets", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			secret.Namespace = tt.secretNamespace
+			task := createTask("SecretNamespace", getAction("PowerOn"), secret)
+			task.Spec.Connection.AuthSecretRef.Namespace = tt.refNamespace
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected err %v, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "secret default/test-bm-auth not found") {
+				t.Fatalf("expected the Secret to be looked up in the Task namespace, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileMachineRef(t *testing.T) {
+	tests := map[string]struct {
+		machine  bool
+		wantErr  string
+		wantHost string
+	}{
+		"connection of the machine": {machine: true, wantHost: "0.0.0.0"},
+		"missing machine fails":     {wantErr: "the Machine test-namespace/test-bm referenced by machineRef does not exist"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			machine := createMachine()
+			// The Secret reference of the Machine is to a Secret in the namespace of the Machine, not the Task.
+			machine.Spec.Connection.AuthSecretRef.Namespace = ""
+			task := createTask("MachineRef", getAction("PowerOn"), secret)
+			task.Spec.Connection = v1alpha1.Connection{}
+			task.Spec.MachineRef = &v1alpha1.MachineRef{Name: machine.Name, Namespace: machine.Namespace}
+			objs := []client.Object{task, secret}
+			if tt.machine {
+				objs = append(objs, machine)
+			}
+			cluster := newClientBuilder().
+				WithObjects(objs...).
+				WithStatusSubresource(task).
+				Build()
+
+			var host string
+			factory := newTestClient(&testProvider{PowerSetOK: true})
+			recording := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				host = hostIP
+				return factory(ctx, log, hostIP, username, password, opts)
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), recording)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected err containing %q, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if host != tt.wantHost {
+				t.Fatalf("expected a connection to %q, got: %q", tt.wantHost, host)
+			}
+
+			got := &v1alpha1.Task{}
+			if err := cluster.Get(context.Background(), request.NamespacedName, got); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if got.Spec.Connection.Host != "" {
+				t.Fatalf("expected the resolved Connection not to be written to the Task, got host %q", got.Spec.Connection.Host)
+			}
+			if tt.wantErr != "" && !got.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected the Task to be Failed, got conditions: %v", got.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileMachineCapabilities(t *testing.T) {
+	tests := map[string]struct {
+		lastCapabilitiesTime metav1.Time
+		wantErr              string
+		wantOpens            int
+	}{
+		"missing capability fails without connecting": {
+			lastCapabilitiesTime: metav1.Now(),
+			wantErr:              "OneTimeBootDeviceAction requires the bootdevice capability, which the providers [ipmitool] of Machine test-namespace/test-bm don't support",
+		},
+		"stale capabilities are not trusted": {
+			lastCapabilitiesTime: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			wantOpens:            1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			machine := createMachine()
+			machine.Status.Providers = []string{"ipmitool"}
+			machine.Status.Capabilities = []string{"power"}
+			machine.Status.LastCapabilitiesTime = &tt.lastCapabilitiesTime
+			action := v1alpha1.Action{OneTimeBootDeviceAction: &v1alpha1.OneTimeBootDeviceAction{Devices: []v1alpha1.BootDevice{v1alpha1.PXE}}}
+			job := createJob("job", machine, action)
+			task := createTask("job-task-0", action, secret)
+			task.OwnerReferences = []metav1.OwnerReference{{APIVersion: job.APIVersion, Kind: job.Kind, Name: job.Name, Controller: ptr.To(true)}}
+			cluster := newClientBuilder().
+				WithObjects(task, secret, machine, job).
+				WithStatusSubresource(task).
+				Build()
+
+			opens := 0
+			factory := newTestClient(&testProvider{BootdeviceOK: true})
+			counting := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				opens++
+				return factory(ctx, log, hostIP, username, password, opts)
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), counting).WithCapabilitiesInterval(time.Hour)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected err containing %q, got: %v", tt.wantErr, err)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if opens != tt.wantOpens {
+				t.Fatalf("expected %d BMC connections, got: %d", tt.wantOpens, opens)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileReset(t *testing.T) {
+	system := func(actions string) map[string]string {
+		return map[string]string{
+			"/redfish/v1/Systems":   `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+			"/redfish/v1/Systems/1": `{"Name":"System","Actions":` + actions + `}`,
+		}
+	}
+	resetAction := `{"#ComputerSystem.Reset":{"target":"/redfish/v1/Systems/1/Actions/ComputerSystem.Reset","ResetType@Redfish.AllowableValues":["On","ForceOff","ForceRestart","GracefulRestart","Nmi"]}}`
+	tests := map[string]struct {
+		provider string
+		protocol string
+		// resources are the Redfish resources of the BMC by path.
+		resources map[string]string
+		resetType v1alpha1.ResetType
+		// wantRequests are the requests that change the BMC, formatted as "METHOD path body".
+		wantRequests []string
+		wantState    string
+		shouldErr    bool
+	}{
+		"redfish force restart": {
+			provider:     "gofish",
+			resources:    system(resetAction),
+			resetType:    v1alpha1.ResetForceRestart,
+			wantRequests: []string{`POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset {"ResetType":"ForceRestart"}`},
+		},
+		"redfish graceful restart": {
+			provider:     "gofish",
+			resources:    system(resetAction),
+			resetType:    v1alpha1.ResetGracefulRestart,
+			wantRequests: []string{`POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset {"ResetType":"GracefulRestart"}`},
+		},
+		"redfish nmi": {
+			provider:     "gofish",
+			resources:    system(resetAction),
+			resetType:    v1alpha1.ResetNmi,
+			wantRequests: []string{`POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset {"ResetType":"Nmi"}`},
+		},
+		"redfish reset type not allowed": {provider: "gofish", resources: system(resetAction), resetType: v1alpha1.ResetPowerCycle, wantState: "reset"},
+		"redfish without reset action":   {provider: "gofish", resources: system(`{}`), resetType: v1alpha1.ResetForceRestart, wantState: "cycle"},
+		"redfish graceful unsupported":   {provider: "gofish", resources: system(`{}`), resetType: v1alpha1.ResetGracefulRestart, shouldErr: true},
+		"ipmi force restart":             {provider: "ipmitool", protocol: "ipmi", resetType: v1alpha1.ResetForceRestart, wantState: "reset"},
+		"ipmi power cycle":               {provider: "ipmitool", protocol: "ipmi", resetType: v1alpha1.ResetPowerCycle, wantState: "cycle"},
+		"ipmi graceful restart":          {provider: "ipmitool", protocol: "ipmi", resetType: v1alpha1.ResetGracefulRestart, shouldErr: true},
+		"ipmi nmi unsupported":           {provider: "ipmitool", protocol: "ipmi", resetType: v1alpha1.ResetNmi, shouldErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			redfish := newFakeRedfish(t, tt.resources)
+			secret := createSecret()
+			task := createTask("Reset", v1alpha1.Action{ResetAction: &v1alpha1.ResetAction{ResetType: tt.resetType}}, secret)
+			redfish.connect(task)
+			provider := &testProvider{PName: tt.provider, Proto: tt.protocol, PowerSetOK: true}
+
+			_, err := reconcileTask(t, task, secret, provider)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("expected err %v, got: %v", tt.shouldErr, err)
+			}
+			if diff := cmp.Diff(tt.wantRequests, redfish.Requests()); diff != "" {
+				t.Fatalf("unexpected requests to the BMC: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantState, provider.PowerSetState); diff != "" {
+				t.Fatalf("unexpected power state set: %v", diff)
+			}
+			if tt.shouldErr && !strings.Contains(err.Error(), "is not supported by providers") {
+				t.Fatalf("expected unsupported error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileResetBMC(t *testing.T) {
+	tests := map[string]struct {
+		action   v1alpha1.ResetBMCAction
+		provider *testProvider
+		// startedAgo is how long ago the action was run, it is not run yet when zero.
+		startedAgo    time.Duration
+		wantResetType string
+		wantRequeue   bool
+		wantCompleted bool
+		wantFailed    bool
+		wantMessage   string
+		// wantUnsupported expects the Task to be Unsupported instead of Failed.
+		wantUnsupported bool
+	}{
+		"cold reset": {
+			action:        v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetCold},
+			provider:      &testProvider{BMCResetOK: true},
+			wantResetType: "cold",
+			wantCompleted: true,
+		},
+		"warm reset waits for the BMC": {
+			action:        v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetWarm, WaitForReady: true},
+			provider:      &testProvider{BMCResetOK: true},
+			wantResetType: "warm",
+			wantRequeue:   true,
+		},
+		"ready after reset": {
+			action:        v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetCold, WaitForReady: true},
+			provider:      &testProvider{Powerstate: "on"},
+			startedAgo:    time.Minute,
+			wantCompleted: true,
+			wantMessage:   "BMC ready after reset",
+		},
+		"unreachable after reset": {
+			action:      v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetCold, WaitForReady: true},
+			provider:    &testProvider{ErrOpen: errors.New("connection refused")},
+			startedAgo:  time.Minute,
+			wantRequeue: true,
+			wantMessage: "Waiting for the BMC to be ready after reset",
+		},
+		"unreachable after task timeout": {
+			action:      v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetCold, WaitForReady: true},
+			provider:    &testProvider{ErrOpen: errors.New("connection refused")},
+			startedAgo:  20 * time.Minute,
+			wantFailed:  true,
+			wantMessage: "BMC was not ready within 10m0s after reset",
+		},
+		"unsupported": {
+			action:          v1alpha1.ResetBMCAction{Type: v1alpha1.BMCResetWarm},
+			provider:        &testProvider{ErrBMCReset: bmclibErrs.ErrProviderImplementation},
+			wantResetType:   "warm",
+			wantFailed:      true,
+			wantUnsupported: true,
+			wantMessage:     "provider does not support resetting the BMC",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("ResetBMC", v1alpha1.Action{ResetBMCAction: &tt.action}, secret)
+			if tt.startedAgo > 0 {
+				started := metav1.NewTime(time.Now().Add(-tt.startedAgo))
+				task.Status = v1alpha1.TaskStatus{StartTime: &started, Attempts: 1}
+			}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(tt.provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if err == nil && tt.startedAgo == 0 {
+				// The status of the reset is checked by the next reconcile.
+				result, err = reconciler.Reconcile(context.Background(), request)
+			}
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailed, err)
+			}
+			if diff := cmp.Diff(tt.wantRequeue, result.RequeueAfter > 0); diff != "" {
+				t.Fatalf("unexpected requeue: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantResetType, tt.provider.BMCResetType); diff != "" {
+				t.Fatalf("unexpected BMC reset: %v", diff)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed && !tt.wantUnsupported, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task failed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantUnsupported, retrieved.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task unsupported: %v", diff)
+			}
+			if tt.wantMessage != "" {
+				if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantMessage) {
+					t.Fatalf("expected condition message to contain %q, got: %q", tt.wantMessage, msg)
+				}
+			}
+		})
+	}
+}
+
+func createTask(name string, action v1alpha1.Action, secret *corev1.Secret) *v1alpha1.Task {
+	return &v1alpha1.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: v1alpha1.TaskSpec{
+			Task: action,
+			Connection: v1alpha1.Connection{
+				Host: "host",
+				Port: 22,
+				AuthSecretRef: corev1.SecretReference{
+					Name:      secret.Name,
+					Namespace: secret.Namespace,
+				},
+				ProviderOptions: &v1alpha1.ProviderOptions{
+					Redfish: &v1alpha1.RedfishOptions{
+						Port: 443,
+					},
+				},
+			},
+		},
+	}
+}
+
+func createTaskWithRPC(name string, action v1alpha1.Action, secret *corev1.Secret) *v1alpha1.Task {
+	return &v1alpha1.Task{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: v1alpha1.TaskSpec{
+			Task: action,
+			Connection: v1alpha1.Connection{
+				Host: "host",
+				Port: 22,
+				AuthSecretRef: corev1.SecretReference{
+					Name:      secret.Name,
+					Namespace: secret.Namespace,
+				},
+				ProviderOptions: &v1alpha1.ProviderOptions{
+					RPC: &v1alpha1.RPCOptions{
+						ConsumerURL: "http://127.0.0.1:7777",
+						HMAC: &v1alpha1.HMACOpts{
+							Secrets: v1alpha1.HMACSecrets{
+								"sha256": []corev1.SecretReference{
+									{
+										Name:      secret.Name,
+										Namespace: secret.Namespace,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestTaskReconcileTimeout(t *testing.T) {
+	secret := createSecret()
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	task.Spec.Timeout = &metav1.Duration{Duration: 10 * time.Millisecond}
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	// Simulate a BMC that does not respond until the context is done.
+	hangingClient := func(ctx context.Context, _ logr.Logger, _, _, _ string, _ *controller.BMCOptions) (*bmclib.Client, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), hangingClient)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	if _, err := reconciler.Reconcile(context.Background(), request); err == nil {
+		t.Fatal("expected err, got nil")
+	}
+
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+	}
+	want := "task exceeded timeout 10ms: context deadline exceeded"
+	if diff := cmp.Diff(want, retrieved.Status.Conditions[0].Message); diff != "" {
+		t.Fatalf("unexpected condition message: %v", diff)
+	}
+}
+
+func TestTaskReconcileRetry(t *testing.T) {
+	tests := map[string]struct {
+		err          error
+		wantRequeue  []time.Duration
+		wantAttempts int
+		// wantCondition is the condition the Task finishes with.
+		wantCondition v1alpha1.TaskConditionType
+	}{
+		"transient error is retried": {
+			err:           errors.New("503 Service Unavailable"),
+			wantRequeue:   []time.Duration{time.Second, 2 * time.Second},
+			wantAttempts:  3,
+			wantCondition: v1alpha1.TaskFailed,
+		},
+		"auth error fails immediately": {
+			err:           bmclibErrs.ErrLoginFailed,
+			wantAttempts:  1,
+			wantCondition: v1alpha1.TaskFailed,
+		},
+		"unsupported error fails immediately": {
+			err:           bmclibErrs.ErrProviderImplementation,
+			wantAttempts:  1,
+			wantCondition: v1alpha1.TaskUnsupported,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.RetryPolicy = &v1alpha1.RetryPolicy{MaxRetries: 2, BackoffBase: &metav1.Duration{Duration: time.Second}}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{ErrPowerStateSet: tt.err}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			for _, want := range tt.wantRequeue {
+				result, err := reconciler.Reconcile(context.Background(), request)
+				if err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				if diff := cmp.Diff(want, result.RequeueAfter); diff != "" {
+					t.Fatalf("unexpected requeue: %v", diff)
+				}
+			}
+			if _, err := reconciler.Reconcile(context.Background(), request); err == nil {
+				t.Fatal("expected err, got nil")
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(tt.wantCondition, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", tt.wantCondition, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantAttempts, retrieved.Status.Attempts); diff != "" {
+				t.Fatalf("unexpected attempts: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileRetryBudget(t *testing.T) {
+	tests := map[string]struct {
+		policy      v1alpha1.RetryPolicy
+		wantRequeue []time.Duration
+		wantMessage string
+	}{
+		"backoff capped": {
+			policy:      v1alpha1.RetryPolicy{MaxRetries: 3, BackoffBase: &metav1.Duration{Duration: time.Second}, MaxBackoff: &metav1.Duration{Duration: 1500 * time.Millisecond}},
+			wantRequeue: []time.Duration{time.Second, 1500 * time.Millisecond, 1500 * time.Millisecond},
+			wantMessage: "failed to perform PowerAction",
+		},
+		"retry budget exhausted": {
+			policy:      v1alpha1.RetryPolicy{MaxRetries: 5, BackoffBase: &metav1.Duration{Duration: time.Second}, MaxElapsedTime: &metav1.Duration{Duration: 1500 * time.Millisecond}},
+			wantRequeue: []time.Duration{time.Second},
+			wantMessage: "retry budget of 1.5s exhausted after 2 attempts: failed to perform PowerAction",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.RetryPolicy = &tt.policy
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{ErrPowerStateSet: errors.New("503 Service Unavailable")}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			for _, want := range tt.wantRequeue {
+				result, err := reconciler.Reconcile(context.Background(), request)
+				if err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				if diff := cmp.Diff(want, result.RequeueAfter); diff != "" {
+					t.Fatalf("unexpected requeue: %v", diff)
+				}
+			}
+			if _, err := reconciler.Reconcile(context.Background(), request); err == nil {
+				t.Fatal("expected err, got nil")
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskFailed, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if msg := retrieved.Status.Conditions[0].Message; !strings.Contains(msg, tt.wantMessage) {
+				t.Fatalf("expected condition message to contain %q, got: %q", tt.wantMessage, msg)
+			}
+			if retrieved.Status.FirstAttemptTime.IsZero() {
+				t.Fatal("expected the first attempt time to be set")
+			}
+		})
+	}
+}
+
+func TestTaskReconcileDryRun(t *testing.T) {
+	tests := map[string]struct {
+		provider    *testProvider
+		wantFailed  bool
+		wantMessage string
+		wantReason  v1alpha1.FailureReason
+	}{
+		"feasible task is not run": {
+			provider:    &testProvider{Powerstate: "off", ErrPowerStateSet: errors.New("power set must not be called")},
+			wantMessage: "dry run: connection verified with providers: tester, no action was run",
+		},
+		"missing capability is infeasible": {
+			provider:    &testProvider{Powerstate: "off", PFeatures: registrar.Features{providers.FeaturePowerState}},
+			wantFailed:  true,
+			wantMessage: "dry run: PowerAction(on) requires the power capability, which the providers [tester] don't support",
+			wantReason:  v1alpha1.FailureReasonUnsupported,
+		},
+		"failing BMC is infeasible": {
+			provider:    &testProvider{ErrPowerStateGet: errors.New("500 Internal Server Error")},
+			wantFailed:  true,
+			wantMessage: "dry run: failed to read power state with the opened connection",
+			wantReason:  v1alpha1.FailureReasonProvider,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.DryRun = true
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(tt.provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+			if _, err := reconciler.Reconcile(context.Background(), request); (err != nil) != tt.wantFailed {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailed, err)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			cType := v1alpha1.TaskCompleted
+			switch {
+			case tt.wantReason == v1alpha1.FailureReasonUnsupported:
+				cType = v1alpha1.TaskUnsupported
+			case tt.wantFailed:
+				cType = v1alpha1.TaskFailed
+			}
+			if !retrieved.HasCondition(cType, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", cType, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			var message string
+			for _, c := range retrieved.Status.Conditions {
+				if c.Type == cType {
+					message = c.Message
+				}
+			}
+			if !strings.Contains(message, tt.wantMessage) {
+				t.Fatalf("expected condition message to contain %q, got: %q", tt.wantMessage, message)
+			}
+			if diff := cmp.Diff(tt.wantReason, retrieved.Status.FailureReason); diff != "" {
+				t.Fatalf("unexpected failure reason: %v", diff)
+			}
+			if retrieved.Status.PowerState != "" {
+				t.Fatalf("expected no power state from a dry run, got: %q", retrieved.Status.PowerState)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileFailureReason(t *testing.T) {
+	tests := map[string]struct {
+		// openErr fails opening the BMC connection.
+		openErr    error
+		provider   *testProvider
+		wantReason v1alpha1.FailureReason
+		// wantConditionReason is the Reason of the Failed condition.
+		wantConditionReason string
+		wantMessage         string
+	}{
+		"auth on connect": {
+			openErr:             fmt.Errorf("failed to open connection to BMC: %w", bmclibErrs.ErrLoginFailed),
+			wantReason:          v1alpha1.FailureReasonAuth,
+			wantConditionReason: v1alpha1.TaskReasonAuthFailed,
+			wantMessage:         "Authentication failed, the BMC rejected the credentials",
+		},
+		"network on connect": {
+			openErr:             fmt.Errorf("failed to open connection to BMC: %w", syscall.EHOSTUNREACH),
+			wantReason:          v1alpha1.FailureReasonNetwork,
+			wantConditionReason: v1alpha1.TaskReasonConnectionFailed,
+			wantMessage:         "Failed to connect to BMC",
+		},
+		"auth on action": {
+			provider:            &testProvider{ErrPowerStateSet: bmclibErrs.ErrNotAuthenticated},
+			wantReason:          v1alpha1.FailureReasonAuth,
+			wantConditionReason: v1alpha1.TaskReasonAuthFailed,
+			wantMessage:         "Authentication failed: failed to perform PowerAction",
+		},
+		"unsupported action": {
+			provider:            &testProvider{ErrPowerStateSet: bmclibErrs.ErrProviderImplementation},
+			wantReason:          v1alpha1.FailureReasonUnsupported,
+			wantConditionReason: v1alpha1.TaskReasonUnsupported,
+		},
+		"timeout": {
+			provider:            &testProvider{ErrPowerStateSet: context.DeadlineExceeded},
+			wantReason:          v1alpha1.FailureReasonTimeout,
+			wantConditionReason: v1alpha1.TaskReasonTimeout,
+		},
+		"provider error": {
+			provider:            &testProvider{ErrPowerStateSet: errors.New("internal error")},
+			wantReason:          v1alpha1.FailureReasonProvider,
+			wantConditionReason: v1alpha1.TaskReasonActionFailed,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			// Auth errors are not retried even with a RetryPolicy.
+			task.Spec.RetryPolicy = &v1alpha1.RetryPolicy{MaxRetries: 3}
+			if tt.wantReason != v1alpha1.FailureReasonAuth {
+				task.Spec.RetryPolicy = nil
+			}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			opens := 0
+			open := newTestClient(tt.provider)
+			bmc := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				opens++
+				if tt.openErr != nil {
+					return nil, tt.openErr
+				}
+				return open(ctx, log, hostIP, username, password, opts)
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), bmc)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			// The failed Task is not run again.
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if opens != 1 {
+				t.Fatalf("expected the BMC connection to be opened once, got %d", opens)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			// A Task whose action is not supported is Unsupported instead of Failed.
+			cType := v1alpha1.TaskFailed
+			if tt.wantReason == v1alpha1.FailureReasonUnsupported {
+				cType = v1alpha1.TaskUnsupported
+			}
+			if !retrieved.HasCondition(cType, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", cType, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) && retrieved.HasCondition(v1alpha1.TaskUnsupported, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected only one of the conditions %s and %s, got: %v", v1alpha1.TaskFailed, v1alpha1.TaskUnsupported, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantReason, retrieved.Status.FailureReason); diff != "" {
+				t.Fatalf("unexpected failure reason: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantConditionReason, retrieved.Status.Conditions[0].Reason); diff != "" {
+				t.Fatalf("unexpected condition reason: %v", diff)
+			}
+			if msg := retrieved.Status.Conditions[0].Message; !strings.HasPrefix(msg, tt.wantMessage) {
+				t.Fatalf("expected condition message to start with %q, got: %q", tt.wantMessage, msg)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileFailureRequeue(t *testing.T) {
+	tests := map[string]struct {
+		err         error
+		created     time.Duration
+		wantRequeue bool
+	}{
+		"transient error within window is requeued": {
+			err:         errors.New("503 Service Unavailable"),
+			created:     time.Minute,
+			wantRequeue: true,
+		},
+		"transient error after window fails": {
+			err:     errors.New("503 Service Unavailable"),
+			created: time.Hour,
+		},
+		"non transient error fails": {
+			err:     bmclibErrs.ErrProviderImplementation,
+			created: time.Minute,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.CreationTimestamp = metav1.NewTime(time.Now().Add(-tt.created))
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{ErrPowerStateSet: tt.err})).
+				WithFailureRequeue(time.Minute, 30*time.Minute)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if tt.wantRequeue && err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !tt.wantRequeue && err == nil {
+				t.Fatal("expected err, got nil")
+			}
+			wantRequeueAfter := time.Duration(0)
+			if tt.wantRequeue {
+				wantRequeueAfter = time.Minute
+			}
+			if diff := cmp.Diff(wantRequeueAfter, result.RequeueAfter); diff != "" {
+				t.Fatalf("unexpected requeue: %v", diff)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(!tt.wantRequeue, retrieved.Finished()); diff != "" {
+				t.Fatalf("unexpected task finished: %v", diff)
+			}
+			if tt.wantRequeue && retrieved.Status.StartTime != nil {
+				t.Fatalf("expected start time to be reset, got: %v", retrieved.Status.StartTime)
+			}
+			if retrieved.HasCondition(v1alpha1.TaskRunning, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be removed, got: %v", v1alpha1.TaskRunning, retrieved.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileTTLAfterFinished(t *testing.T) {
+	tenSecondsAgo := metav1.NewTime(time.Now().Add(-10 * time.Second))
+	now := metav1.Now()
+	tests := map[string]struct {
+		ttl         *int32
+		status      v1alpha1.TaskStatus
+		wantDeleted bool
+		wantRequeue bool
+	}{
+		"completed task past its ttl is deleted": {
+			ttl: ptr.To[int32](5),
+			status: v1alpha1.TaskStatus{
+				CompletionTime: &tenSecondsAgo,
+				Conditions:     []v1alpha1.TaskCondition{{Type: v1alpha1.TaskCompleted, Status: v1alpha1.ConditionTrue}},
+			},
+			wantDeleted: true,
+		},
+		"failed task past its ttl is deleted": {
+			ttl: ptr.To[int32](0),
+			status: v1alpha1.TaskStatus{
+				FailureTime: &tenSecondsAgo,
+				Conditions:  []v1alpha1.TaskCondition{{Type: v1alpha1.TaskFailed, Status: v1alpha1.ConditionTrue}},
+			},
+			wantDeleted: true,
+		},
+		"completed task within its ttl is requeued": {
+			ttl: ptr.To[int32](60),
+			status: v1alpha1.TaskStatus{
+				CompletionTime: &now,
+				Conditions:     []v1alpha1.TaskCondition{{Type: v1alpha1.TaskCompleted, Status: v1alpha1.ConditionTrue}},
+			},
+			wantRequeue: true,
+		},
+		"completed task without ttl is kept": {
+			status: v1alpha1.TaskStatus{
+				CompletionTime: &tenSecondsAgo,
+				Conditions:     []v1alpha1.TaskCondition{{Type: v1alpha1.TaskCompleted, Status: v1alpha1.ConditionTrue}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.TTLSecondsAfterFinished = tt.ttl
+			task.Status = tt.status
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.wantRequeue && (result.RequeueAfter <= 55*time.Second || result.RequeueAfter > 60*time.Second) {
+				t.Fatalf("expected requeue after about 60s, got: %v", result.RequeueAfter)
+			}
+			if !tt.wantRequeue && !result.IsZero() {
+				t.Fatalf("expected no requeue, got: %v", result)
+			}
+
+			err = cluster.Get(context.Background(), request.NamespacedName, &v1alpha1.Task{})
+			if tt.wantDeleted && !apierrors.IsNotFound(err) {
+				t.Fatalf("expected Task to be deleted, got: %v", err)
+			}
+			if !tt.wantDeleted && err != nil {
+				t.Fatalf("expected Task to exist, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileEvents(t *testing.T) {
+	tests := map[string]struct {
+		provider   *testProvider
+		wantEvents []string
+	}{
+		"completed": {
+			provider: &testProvider{Powerstate: "on", PowerSetOK: true},
+			wantEvents: []string{
+				"Normal Started PowerAction(on) on host: task started, attempt 1",
+				"Normal Completed PowerAction(on) on host: task completed",
+			},
+		},
+		"failed": {
+			provider: &testProvider{ErrPowerStateSet: errors.New("power set failed")},
+			wantEvents: []string{
+				"Normal Started PowerAction(on) on host: task started, attempt 1",
+				"Warning Failed PowerAction(on) on host: failed to perform PowerAction: ",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			recorder := record.NewFakeRecorder(10)
+			reconciler := controller.NewTaskReconciler(cluster, recorder, newTestClient(tt.provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			// The first reconcile runs the action, the second one marks the Task completed.
+			for i := 0; i < 2; i++ {
+				_, _ = reconciler.Reconcile(context.Background(), request)
+			}
+
+			close(recorder.Events)
+			var got []string
+			for e := range recorder.Events {
+				got = append(got, e)
+			}
+			if len(got) != len(tt.wantEvents) {
+				t.Fatalf("expected %d events, got: %v", len(tt.wantEvents), got)
+			}
+			for i, want := range tt.wantEvents {
+				if !strings.HasPrefix(got[i], want) {
+					t.Fatalf("expected event %d to start with %q, got: %q", i, want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcileCircuitBreaker(t *testing.T) {
+	secret := createSecret()
+	provider := &testProvider{PowerSetOK: true, Powerstate: "on", ErrOpen: errors.New("connection refused")}
+	breaker := controller.NewHostBreaker(2, 100*time.Millisecond)
+	cluster := newClientBuilder().WithObjects(secret).WithStatusSubresource(&v1alpha1.Task{}).Build()
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+		WithHostBreaker(breaker)
+
+	// run reconciles a new Task and returns the message of its Failed condition, empty when it did not fail.
+	run := func(name string) string {
+		t.Helper()
+		task := createTask(name, getAction("PowerOn"), secret)
+		if err := cluster.Create(context.Background(), task); err != nil {
+			t.Fatalf("expected nil err, got: %v", err)
+		}
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+		_, _ = reconciler.Reconcile(context.Background(), request)
+
+		var retrieved v1alpha1.Task
+		if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+			t.Fatalf("expected nil err, got: %v", err)
+		}
+		for _, c := range retrieved.Status.Conditions {
+			if c.Type == v1alpha1.TaskFailed && c.Status == v1alpha1.ConditionTrue {
+				return c.Message
+			}
+		}
+		return ""
+	}
+
+	for _, name := range []string{"failure-1", "failure-2"} {
+		if msg := run(name); !strings.Contains(msg, "Failed to connect to BMC") {
+			t.Fatalf("%s: expected a connection failure, got: %q", name, msg)
+		}
+	}
+	// The circuit is open after 2 consecutive failures, the BMC is not attempted.
+	provider.ErrOpen = nil
+	if msg := run("open"); !strings.Contains(msg, "circuit open for host") {
+		t.Fatalf("expected the circuit to be open, got: %q", msg)
+	}
+
+	// After the cooldown, the first success closes the circuit.
+	time.Sleep(150 * time.Millisecond)
+	if msg := run("success"); msg != "" {
+		t.Fatalf("expected the task not to fail, got: %q", msg)
+	}
+	provider.ErrOpen = errors.New("connection refused")
+	if msg := run("failure-3"); !strings.Contains(msg, "Failed to connect to BMC") {
+		t.Fatalf("expected a connection failure with the circuit closed, got: %q", msg)
+	}
+}
+
+func TestTaskReconcileHostLimit(t *testing.T) {
+	secret := createSecret()
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	// Another operation against the same BMC is in progress.
+	limiter := controller.NewHostLimiter(1)
+	if !limiter.TryAcquire(task.Spec.Connection.Host) {
+		t.Fatal("expected to acquire the host")
+	}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"})).
+		WithHostLimiter(limiter)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	result, err := reconciler.Reconcile(context.Background(), request)
+	if err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Fatal("expected the Task to be requeued")
+	}
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.Status.StartTime != nil {
+		t.Fatal("expected the Task not to be started")
+	}
+
+	// Once the other operation finishes, the Task runs.
+	limiter.Release(task.Spec.Connection.Host)
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.Status.StartTime == nil {
+		t.Fatal("expected the Task to be started")
+	}
+	if !limiter.TryAcquire(task.Spec.Connection.Host) {
+		t.Fatal("expected the host to be released after the reconcile")
+	}
+}
+
+func TestTaskReconcileInflightLimit(t *testing.T) {
+	secret := createSecret()
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	// Another Task, against any BMC, is in flight.
+	limiter := controller.NewInflightLimiter(1)
+	if !limiter.TryAcquire() {
+		t.Fatal("expected to acquire an in-flight slot")
+	}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"})).
+		WithInflightLimiter(limiter)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+	before := requeueTotal(t, "concurrency_limited")
+
+	result, err := reconciler.Reconcile(context.Background(), request)
+	if err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Fatal("expected the Task to be requeued")
+	}
+	if diff := cmp.Diff(before+1, requeueTotal(t, "concurrency_limited")); diff != "" {
+		t.Fatalf("unexpected rufio_reconcile_requeue_total: %v", diff)
+	}
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.Status.StartTime != nil {
+		t.Fatal("expected the Task not to be started")
+	}
+	if !retrieved.HasCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskPending, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+	}
+
+	// Once the other Task finishes, the Task runs and is no longer Pending.
+	limiter.Release()
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.Status.StartTime == nil {
+		t.Fatal("expected the Task to be started")
+	}
+	if !retrieved.HasCondition(v1alpha1.TaskPending, v1alpha1.ConditionFalse) {
+		t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskPending, v1alpha1.ConditionFalse, retrieved.Status.Conditions)
+	}
+	if !limiter.TryAcquire() {
+		t.Fatal("expected the in-flight slot to be released after the reconcile")
+	}
+}
+
+func TestTaskReconcilePriority(t *testing.T) {
+	tests := map[string]struct {
+		// limit returns the reconciler limited by a limiter whose only slot is taken, and the func that frees it.
+		limit func(r *controller.TaskReconciler, host string) (*controller.TaskReconciler, func())
+	}{
+		"in-flight limit": {
+			limit: func(r *controller.TaskReconciler, _ string) (*controller.TaskReconciler, func()) {
+				limiter := controller.NewInflightLimiter(1)
+				limiter.TryAcquire()
+				return r.WithInflightLimiter(limiter), limiter.Release
+			},
+		},
+		"host limit": {
+			limit: func(r *controller.TaskReconciler, host string) (*controller.TaskReconciler, func()) {
+				limiter := controller.NewHostLimiter(1)
+				limiter.TryAcquire(host)
+				return r.WithHostLimiter(limiter), func() { limiter.Release(host) }
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			routine := createTask("routine", getAction("PowerOn"), secret)
+			recovery := createTask("recovery", getAction("PowerOn"), secret)
+			recovery.Spec.Priority = 10
+			cluster := newClientBuilder().
+				WithObjects(routine, recovery, secret).
+				WithStatusSubresource(routine, recovery).
+				Build()
+
+			reconciler, release := tt.limit(controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"})), routine.Spec.Connection.Host)
+			// started reconciles task and reports whether it started.
+			started := func(task *v1alpha1.Task) bool {
+				t.Helper()
+				request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				var retrieved v1alpha1.Task
+				if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				return retrieved.Status.StartTime != nil
+			}
+
+			// Both Tasks wait while the limit is reached.
+			if started(routine) || started(recovery) {
+				t.Fatal("expected the Tasks to wait for the limit")
+			}
+
+			// Once a slot frees up, it is kept for the Task with the higher priority.
+			release()
+			if started(routine) {
+				t.Fatal("expected the routine Task to wait for the recovery Task")
+			}
+			if !started(recovery) {
+				t.Fatal("expected the recovery Task to start")
+			}
+			if !started(routine) {
+				t.Fatal("expected the routine Task to start after the recovery Task")
+			}
+		})
+	}
+}
+
+func TestTaskReconcileFinalizer(t *testing.T) {
+	tests := map[string]struct {
+		policy v1alpha1.DeletePolicy
+		// powerState is the power state the BMC reports, the PowerOn action has finished when it is on.
+		powerState string
+		// wantRemoved is whether the deleted Task is removed after the reconciles following its deletion.
+		wantRemoved bool
+	}{
+		"wait for the running action": {powerState: "off"},
+		"wait until the action finished": {
+			powerState:  "on",
+			wantRemoved: true,
+		},
+		"abandon the running action": {
+			policy:      v1alpha1.DeletePolicyAbandon,
+			powerState:  "off",
+			wantRemoved: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			task.Spec.DeletePolicy = tt.policy
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: tt.powerState}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff([]string{v1alpha1.TaskFinalizer}, retrieved.Finalizers); diff != "" {
+				t.Fatalf("unexpected finalizers of the running Task: %v", diff)
+			}
+
+			// Deleting the running Task waits for the finalizer. With the wait policy, the next reconcile checks
+			// the status of the action and the one after removes the finalizer once the action has finished.
+			if err := cluster.Delete(context.Background(), &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected the Task to wait for the finalizer, got: %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+			}
+			err := cluster.Get(context.Background(), request.NamespacedName, &retrieved)
+			if tt.wantRemoved && !apierrors.IsNotFound(err) {
+				t.Fatalf("expected the Task to be removed, got: %v", err)
+			}
+			if !tt.wantRemoved && err != nil {
+				t.Fatalf("expected the Task to wait for the running action, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileFinishedRemovesFinalizer(t *testing.T) {
+	secret := createSecret()
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"}))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	// The first reconcile runs the action, the second one marks the Task completed and the third one cleans it up.
+	for i := 0; i < 3; i++ {
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected nil err, got: %v", err)
+		}
+	}
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+	}
+	if len(retrieved.Finalizers) != 0 {
+		t.Fatalf("expected no finalizers on the finished Task, got: %v", retrieved.Finalizers)
+	}
+}
+
+func TestTaskReconcileInlineUsername(t *testing.T) {
+	secret := createSecret()
+	// The Secret only needs the password when the username is set inline.
+	delete(secret.Data, "username")
+	task := createTask("PowerOn", getAction("PowerOn"), secret)
+	task.Spec.Connection.Username = "admin"
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+
+	var gotUsername, gotPassword string
+	open := newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"})
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+		gotUsername, gotPassword = username, password
+		return open(ctx, log, hostIP, username, password, opts)
+	})
+
+	if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if gotUsername != "admin" || gotPassword != "test" {
+		t.Fatalf("expected the inline username and the password of the Secret, got: %q, %q", gotUsername, gotPassword)
+	}
+}
+
+func TestTaskReconcileLoginRateLimit(t *testing.T) {
+	secret := createSecret()
+	first := createTask("first", getAction("PowerOn"), secret)
+	second := createTask("second", getAction("PowerOn"), secret)
+	second.Spec.Connection.Host = "other-host"
+	cluster := newClientBuilder().
+		WithObjects(first, second, secret).
+		WithStatusSubresource(first, second).
+		Build()
+
+	// A single login is allowed, the next one only after about 1000s.
+	limiter := controller.NewLoginLimiter(0.001, 1)
+	open := limiter.Wrap(newTestClient(&testProvider{PowerSetOK: true, Powerstate: "on"}))
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), open)
+	before := requeueTotal(t, "rate_limited")
+
+	if _, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: first.Namespace, Name: first.Name}}); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: second.Namespace, Name: second.Name}})
+	if err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if result.RequeueAfter < 900*time.Second {
+		t.Fatalf("expected the Task to be requeued until a login is allowed, got: %v", result)
+	}
+	if diff := cmp.Diff(before+1, requeueTotal(t, "rate_limited")); diff != "" {
+		t.Fatalf("unexpected rufio_reconcile_requeue_total: %v", diff)
+	}
+	var retrieved v1alpha1.Task
+	if err := cluster.Get(context.Background(), types.NamespacedName{Namespace: second.Namespace, Name: second.Name}, &retrieved); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	if retrieved.Status.StartTime != nil || retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the rate limited Task not to run or fail, got: %v", retrieved.Status)
+	}
+}
+
+// taskTotal returns the value of the rufio_task_total counter for action and result.
+func taskTotal(t *testing.T, action, result string) float64 {
+	t.Helper()
+	return counterValue(t, "rufio_task_total", map[string]string{"action": action, "result": result})
+}
+
+// requeueTotal returns the value of the rufio_reconcile_requeue_total counter for reason.
+func requeueTotal(t *testing.T, reason string) float64 {
+	t.Helper()
+	return counterValue(t, "rufio_reconcile_requeue_total", map[string]string{"reason": reason})
+}
+
+// counterValue returns the value of the counter name with labels, or 0 when it was never incremented.
+func counterValue(t *testing.T, name string, want map[string]string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if maps.Equal(labels, want) {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestTaskReconcileMetrics(t *testing.T) {
+	tests := map[string]struct {
+		provider   *testProvider
+		wantResult string
+	}{
+		"completed": {
+			provider:   &testProvider{},
+			wantResult: "completed",
+		},
+		"failed": {
+			provider:   &testProvider{ErrSELGet: errors.New("SEL not available")},
+			wantResult: "failed",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("GetSEL", getAction("GetSEL"), secret)
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			before := taskTotal(t, "GetSELAction", tt.wantResult)
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(tt.provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			// The first reconcile runs the action, the second checks that it completed.
+			for i := 0; i < 2; i++ {
+				_, _ = reconciler.Reconcile(context.Background(), request)
+			}
+
+			if diff := cmp.Diff(before+1, taskTotal(t, "GetSELAction", tt.wantResult)); diff != "" {
+				t.Fatalf("unexpected rufio_task_total: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileSoftPowerOff(t *testing.T) {
+	tests := map[string]struct {
+		startedAgo   time.Duration
+		powerState   string
+		wantFallback bool
+		wantMessage  string
+	}{
+		"soft power off completes within grace period": {
+			startedAgo: 10 * time.Second, powerState: "off", wantMessage: "soft power off completed",
+		},
+		"hard power off after grace period": {
+			startedAgo: 2 * time.Minute, powerState: "on", wantFallback: true,
+			wantMessage: "soft power off did not complete within 1m0s, hard power off issued",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("SoftPowerOff", getAction("SoftPowerOff"), secret)
+			started := metav1.NewTime(time.Now().Add(-tt.startedAgo))
+			task.Status = v1alpha1.TaskStatus{StartTime: &started, Attempts: 1}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			provider := &testProvider{Powerstate: tt.powerState, PowerSetOK: true}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.wantFallback {
+				var retrieved v1alpha1.Task
+				if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+				if !retrieved.Status.HardPowerOffFallback {
+					t.Fatal("expected the hard power off fallback to be recorded")
+				}
+				if retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+					t.Fatal("expected the Task not to be completed before the Machine is off")
+				}
+				// The hard power off took effect.
+				provider.Powerstate = "off"
+				if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+					t.Fatalf("expected nil err, got: %v", err)
+				}
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if !retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+				t.Fatalf("expected condition %s to be %s, got: %v", v1alpha1.TaskCompleted, v1alpha1.ConditionTrue, retrieved.Status.Conditions)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFallback, retrieved.Status.HardPowerOffFallback); diff != "" {
+				t.Fatalf("unexpected hard power off fallback: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileCredentialRotation(t *testing.T) {
+	tests := map[string]struct {
+		rotated     bool
+		wantStarted bool
+		wantFailed  bool
+	}{
+		"rotated secret is re-read after authentication error": {
+			rotated:     true,
+			wantStarted: true,
+		},
+		"unchanged secret fails the task": {
+			wantFailed: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			// The cached client still has the old credentials.
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			current := secret.DeepCopy()
+			if tt.rotated {
+				current.Data["password"] = []byte("rotated")
+			}
+			apiReader := newClientBuilder().WithObjects(current).Build()
+
+			// Only the current password is accepted by the BMC.
+			open := newTestClient(&testProvider{PowerSetOK: true})
+			bmc := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				if password != string(current.Data["password"]) || !tt.rotated {
+					return nil, fmt.Errorf("failed to open connection to BMC: %w", bmclibErrs.ErrLoginFailed)
+				}
+				return open(ctx, log, hostIP, username, password, opts)
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), bmc).WithAPIReader(apiReader)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailed, err)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantStarted, retrieved.Status.StartTime != nil); diff != "" {
+				t.Fatalf("unexpected task started: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task failed: %v", diff)
+			}
+		})
+	}
+}
+
+// staticCredentials is a CredentialProvider returning fixed credentials for a single path.
+type staticCredentials struct {
+	path, username, password string
+}
+
+func (s staticCredentials) Credentials(_ context.Context, ref v1alpha1.AuthProviderRef) (string, string, error) {
+	if ref.Path != s.path {
+		return "", "", fmt.Errorf("credentials %s not found", ref.Path)
+	}
+	return s.username, s.password, nil
+}
+
+func TestTaskReconcileAuthProviderRef(t *testing.T) {
+	tests := map[string]struct {
+		ref        v1alpha1.AuthProviderRef
+		wantErr    bool
+		wantOpened bool
+	}{
+		"credentials from provider": {
+			ref:        v1alpha1.AuthProviderRef{Name: "static", Path: "bmc/node1"},
+			wantOpened: true,
+		},
+		"provider not configured": {
+			ref:     v1alpha1.AuthProviderRef{Name: "vault", Path: "bmc/node1"},
+			wantErr: true,
+		},
+		"credentials not found": {
+			ref:     v1alpha1.AuthProviderRef{Name: "static", Path: "bmc/node2"},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			task := createTask("PowerOn", getAction("PowerOn"), createSecret())
+			task.Spec.Connection.AuthSecretRef = corev1.SecretReference{}
+			task.Spec.Connection.AuthProviderRef = &tt.ref
+			cluster := newClientBuilder().
+				WithObjects(task).
+				WithStatusSubresource(task).
+				Build()
+
+			opened := false
+			open := newTestClient(&testProvider{PowerSetOK: true})
+			bmc := func(ctx context.Context, log logr.Logger, hostIP, username, password string, opts *controller.BMCOptions) (*bmclib.Client, error) {
+				if username != "admin" || password != "s3cret" {
+					return nil, fmt.Errorf("unexpected credentials %s/%s", username, password)
+				}
+				opened = true
+				return open(ctx, log, hostIP, username, password, opts)
+			}
+			providers := map[string]controller.CredentialProvider{
+				"static": staticCredentials{path: "bmc/node1", username: "admin", password: "s3cret"},
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), bmc).WithCredentialProviders(providers)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			_, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected err %v, got: %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.wantOpened, opened); diff != "" {
+				t.Fatalf("unexpected BMC connection opened: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcilePowerCycleAction(t *testing.T) {
+	tests := map[string]struct {
+		startedAgo    time.Duration
+		powerState    string
+		wantRequeue   bool
+		wantCompleted bool
+		wantFailed    bool
+		wantMessage   string
+	}{
+		"powered back on": {
+			startedAgo: 10 * time.Second, powerState: "on", wantCompleted: true,
+			wantMessage: "machine powered back on after power cycle",
+		},
+		"still off within wait timeout": {
+			startedAgo: 10 * time.Second, powerState: "off", wantRequeue: true,
+		},
+		"still off after wait timeout": {
+			startedAgo: 2 * time.Minute, powerState: "off", wantFailed: true,
+			wantMessage: "machine did not return to power on within 1m0s after power cycle, power state is off",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerCycleWait", getAction("PowerCycleWait"), secret)
+			started := metav1.NewTime(time.Now().Add(-tt.startedAgo))
+			task.Status = v1alpha1.TaskStatus{StartTime: &started, Attempts: 1}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{Powerstate: tt.powerState}))
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailed, err)
+			}
+			if diff := cmp.Diff(tt.wantRequeue, result.RequeueAfter > 0); diff != "" {
+				t.Fatalf("unexpected requeue: %v", diff)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task failed: %v", diff)
+			}
+			if tt.wantMessage != "" {
+				if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+					t.Fatalf("unexpected condition message: %v", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcilePowerVerification(t *testing.T) {
+	tests := map[string]struct {
+		action         string
+		window         time.Duration
+		startedAgo     time.Duration
+		powerState     string
+		wantRequeue    bool
+		wantCompleted  bool
+		wantFailed     bool
+		wantPowerState string
+		wantMessage    string
+	}{
+		"verified on": {
+			action: "PowerOn", window: 30 * time.Second, startedAgo: 5 * time.Second, powerState: "on",
+			wantCompleted: true, wantPowerState: "on",
+		},
+		"not yet on within window": {
+			action: "PowerOn", window: 30 * time.Second, startedAgo: 5 * time.Second, powerState: "off",
+			wantRequeue: true, wantPowerState: "off",
+		},
+		"not on after window": {
+			action: "PowerOn", window: 30 * time.Second, startedAgo: time.Minute, powerState: "off",
+			wantFailed: true, wantPowerState: "off",
+			wantMessage: "power state is off after PowerAction on, expected on within 30s",
+		},
+		"verified off": {
+			action: "HardOff", window: 30 * time.Second, startedAgo: 5 * time.Second, powerState: "off",
+			wantCompleted: true, wantPowerState: "off",
+		},
+		"disabled": {
+			action: "PowerOn", startedAgo: 5 * time.Second, powerState: "on",
+			wantCompleted: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerVerification", getAction(tt.action), secret)
+			started := metav1.NewTime(time.Now().Add(-tt.startedAgo))
+			task.Status = v1alpha1.TaskStatus{StartTime: &started, Attempts: 1}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(&testProvider{Powerstate: tt.powerState})).
+				WithPowerVerificationWindow(tt.window)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("expected err %v, got: %v", tt.wantFailed, err)
+			}
+			if diff := cmp.Diff(tt.wantRequeue, result.RequeueAfter > 0); diff != "" {
+				t.Fatalf("unexpected requeue: %v", diff)
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task completed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantFailed, retrieved.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected task failed: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPowerState, retrieved.Status.PowerState); diff != "" {
+				t.Fatalf("unexpected power state: %v", diff)
+			}
+			if tt.wantMessage != "" {
+				if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+					t.Fatalf("unexpected condition message: %v", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskReconcileSessionKeepalive(t *testing.T) {
+	tests := map[string]struct {
+		interval  time.Duration
+		wantPings bool
+	}{
+		"pings during long running action": {interval: 10 * time.Millisecond, wantPings: true},
+		"disabled":                         {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("SessionKeepalive", getAction("VirtualMedia"), secret)
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			provider := &testProvider{VirtualMediaOK: true, VirtualMediaDelay: 200 * time.Millisecond}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+				WithSessionKeepalive(tt.interval)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			pings := provider.PowerStateGets.Load()
+			if diff := cmp.Diff(tt.wantPings, pings > 0); diff != "" {
+				t.Fatalf("unexpected keepalive pings (%d): %v", pings, diff)
+			}
+
+			// The keepalive stops as soon as the action returns.
+			time.Sleep(5 * tt.interval)
+			if got := provider.PowerStateGets.Load(); got != pings {
+				t.Fatalf("expected no keepalive pings after the action, got %d more", got-pings)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileDuplicatePolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy        controller.DuplicateTaskPolicy
+		runningAction v1alpha1.Action
+		wantPending   bool
+		wantCompleted bool
+		wantPowerOn   bool
+	}{
+		"defer":            {policy: controller.DuplicateTaskPolicyDefer, runningAction: getAction("PowerOn"), wantPending: true},
+		"skip":             {policy: controller.DuplicateTaskPolicySkip, runningAction: getAction("PowerOn"), wantCompleted: true},
+		"different action": {policy: controller.DuplicateTaskPolicySkip, runningAction: getAction("PowerHardOff"), wantPowerOn: true},
+		"policy none":      {policy: controller.DuplicateTaskPolicyNone, runningAction: getAction("PowerOn"), wantPowerOn: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			running := createTask("running", tt.runningAction, secret)
+			started := metav1.Now()
+			running.Status = v1alpha1.TaskStatus{StartTime: &started, Attempts: 1}
+			task := createTask("queued", getAction("PowerOn"), secret)
+			cluster := newClientBuilder().
+				WithObjects(running, task, secret).
+				WithStatusSubresource(running, task).
+				WithIndex(&v1alpha1.Task{}, ".spec.hostAction", controller.TaskHostActionIndexFunc).
+				Build()
+
+			provider := &testProvider{Powerstate: "on", PowerSetOK: true}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider)).
+				WithDuplicatePolicy(tt.policy)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			result, err := reconciler.Reconcile(context.Background(), request)
+			if err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if tt.wantPending && result.RequeueAfter == 0 {
+				t.Fatal("expected the deferred task to be requeued")
+			}
+
+			retrieved := &v1alpha1.Task{}
+			if err := cluster.Get(context.Background(), request.NamespacedName, retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantPending, retrieved.HasCondition(v1alpha1.TaskPending, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected Pending condition: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantCompleted, retrieved.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue)); diff != "" {
+				t.Fatalf("unexpected Completed condition: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPowerOn, provider.PowerSetState == "on"); diff != "" {
+				t.Fatalf("unexpected power action: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcileCipherSuiteMismatch(t *testing.T) {
+	tests := map[string]struct {
+		cipherSuite *int
+		wantMessage string
+	}{
+		"cipher suite set": {
+			cipherSuite: ptr.To(3),
+			wantMessage: "Failed to connect to BMC: failed to open connection to BMC: Error in open session response message : no matching cipher suite (IPMI cipher suite mismatch: the BMC does not accept cipher suite 3, try another ipmiOptions.cipherSuite)",
+		},
+		"cipher suite negotiated": {
+			wantMessage: "Failed to connect to BMC: failed to open connection to BMC: Error in open session response message : no matching cipher suite (IPMI cipher suite mismatch: the BMC may require a specific cipher suite, set ipmiOptions.cipherSuite)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			secret := createSecret()
+			task := createTask("PowerOn", getAction("PowerOn"), secret)
+			if tt.cipherSuite != nil {
+				task.Spec.Connection.IPMIOptions = &v1alpha1.IPMIOptions{CipherSuite: tt.cipherSuite}
+			}
+			cluster := newClientBuilder().
+				WithObjects(task, secret).
+				WithStatusSubresource(task).
+				Build()
+
+			bmc := func(_ context.Context, _ logr.Logger, _, _, _ string, _ *controller.BMCOptions) (*bmclib.Client, error) {
+				return nil, errors.New("failed to open connection to BMC: Error in open session response message : no matching cipher suite")
+			}
+			reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), bmc)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+			if _, err := reconciler.Reconcile(context.Background(), request); err == nil {
+				t.Fatal("expected err, got nil")
+			}
+
+			var retrieved v1alpha1.Task
+			if err := cluster.Get(context.Background(), request.NamespacedName, &retrieved); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantMessage, retrieved.Status.Conditions[0].Message); diff != "" {
+				t.Fatalf("unexpected condition message: %v", diff)
+			}
+		})
+	}
+}
+
+func TestTaskReconcilePanic(t *testing.T) {
+	secret := createSecret()
+	task := createTask("Panic", getAction("PowerOn"), secret)
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+	provider := &testProvider{PowerSetOK: true, PowerSetPanic: "nil pointer dereference"}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	_, err := reconciler.Reconcile(context.Background(), request)
+	if err == nil || !strings.Contains(err.Error(), "reconcile panicked: nil pointer dereference") {
+		t.Fatalf("expected err of the panic, got: %v", err)
+	}
+
+	got := &v1alpha1.Task{}
+	if err := cluster.Get(context.Background(), request.NamespacedName, got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !got.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the Task to be Failed, got conditions: %v", got.Status.Conditions)
+	}
+	if msg := got.Status.Conditions[len(got.Status.Conditions)-1].Message; !strings.Contains(msg, "panicked") {
+		t.Fatalf("expected the Failed condition message to mention the panic, got: %q", msg)
+	}
+
+	// The Task is finished, the next reconcile only cleans it up.
+	if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("expected nil err, got: %v", err)
+	}
+}
+
+func TestTaskReconcileSuspend(t *testing.T) {
+	secret := createSecret()
+	task := createTask("Suspend", getAction("PowerOn"), secret)
+	task.Spec.Suspend = true
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+	provider := &testProvider{PowerSetOK: true, Powerstate: "on"}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	// reconcileTwice runs the two reconciles that run and check the action of the Task, and returns it.
+	reconcileTwice := func() *v1alpha1.Task {
+		t.Helper()
+		for range 2 {
+			if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+		}
+		got := &v1alpha1.Task{}
+		if err := cluster.Get(context.Background(), request.NamespacedName, got); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return got
+	}
+
+	got := reconcileTwice()
+	if !got.HasCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the Task to be Suspended, got conditions: %v", got.Status.Conditions)
+	}
+	if provider.PowerSetState != "" || got.Finished() {
+		t.Fatalf("expected the suspended Task not to run, got power set to %q and conditions: %v", provider.PowerSetState, got.Status.Conditions)
+	}
+
+	got.Spec.Suspend = false
+	if err := cluster.Update(context.Background(), got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	got = reconcileTwice()
+	if !got.HasCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionFalse) {
+		t.Fatalf("expected the Task to be resumed, got conditions: %v", got.Status.Conditions)
+	}
+	if provider.PowerSetState != "on" || !got.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the resumed Task to complete, got power set to %q and conditions: %v", provider.PowerSetState, got.Status.Conditions)
+	}
+}
+
+func TestTaskReconcileSuspendTimeout(t *testing.T) {
+	secret := createSecret()
+	task := createTask("SuspendTimeout", getAction("PowerOn"), secret)
+	task.Spec.Timeout = &metav1.Duration{Duration: time.Minute}
+	cluster := newClientBuilder().
+		WithObjects(task, secret).
+		WithStatusSubresource(task).
+		Build()
+	// The machine stays off, so the started Task waits for it to power on.
+	provider := &testProvider{PowerSetOK: true, Powerstate: "off"}
+	reconciler := controller.NewTaskReconciler(cluster, record.NewFakeRecorder(10), newTestClient(provider))
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: task.Namespace, Name: task.Name}}
+
+	reconcileOnce := func() *v1alpha1.Task {
+		t.Helper()
+		if _, err := reconciler.Reconcile(context.Background(), request); err != nil {
+			t.Fatalf("expected nil err, got: %v", err)
+		}
+		got := &v1alpha1.Task{}
+		if err := cluster.Get(context.Background(), request.NamespacedName, got); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return got
+	}
+
+	got := reconcileOnce()
+	if got.Status.StartTime.IsZero() {
+		t.Fatal("expected the Task to be started")
+	}
+	got.Spec.Suspend = true
+	if err := cluster.Update(context.Background(), got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	got = reconcileOnce()
+	if !got.HasCondition(v1alpha1.TaskSuspended, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the Task to be Suspended, got conditions: %v", got.Status.Conditions)
+	}
+
+	// The Task was suspended for longer than its timeout, right after it started.
+	for i, c := range got.Status.Conditions {
+		if c.Type == v1alpha1.TaskSuspended {
+			suspended := metav1.NewTime(c.LastTransitionTime.Add(-2 * time.Minute))
+			got.Status.Conditions[i].LastTransitionTime = &suspended
+		}
+	}
+	started := metav1.NewTime(got.Status.StartTime.Add(-2 * time.Minute))
+	got.Status.StartTime = &started
+	if err := cluster.Status().Update(context.Background(), got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	provider.Powerstate = "on"
+	got.Spec.Suspend = false
+	if err := cluster.Update(context.Background(), got); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	got = reconcileOnce()
+	if got.HasCondition(v1alpha1.TaskFailed, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the suspension not to count toward the timeout, got conditions: %v", got.Status.Conditions)
+	}
+	if !got.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		t.Fatalf("expected the resumed Task to complete, got conditions: %v", got.Status.Conditions)
+	}
+	if time.Since(got.Status.StartTime.Time) >= time.Minute {
+		t.Fatalf("expected the StartTime to be moved forward by the suspension, got: %v", got.Status.StartTime)
 	}
 }
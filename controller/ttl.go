@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// cleanupFinished deletes a finished Task once its TTLSecondsAfterFinished has elapsed, mirroring the
+// Kubernetes Job TTL semantics. Until then the Task is requeued for when the TTL expires.
+func (r *TaskReconciler) cleanupFinished(ctx context.Context, logger logr.Logger, task *v1alpha1.Task) (ctrl.Result, error) {
+	if task.Spec.TTLSecondsAfterFinished == nil {
+		return ctrl.Result{}, nil
+	}
+
+	finished := task.Status.CompletionTime
+	if !task.HasCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionTrue) {
+		finished = task.Status.FailureTime
+	}
+	// Tasks that finished before FailureTime was recorded have no time to count the TTL from.
+	if finished == nil {
+		return ctrl.Result{}, nil
+	}
+
+	ttl := time.Duration(*task.Spec.TTLSecondsAfterFinished) * time.Second
+	if remaining := time.Until(finished.Add(ttl)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	logger.Info("deleting finished task", "ttlSecondsAfterFinished", *task.Spec.TTLSecondsAfterFinished)
+	// The UID precondition makes sure a Task that was recreated with the same name is not deleted.
+	if err := r.client.Delete(ctx, task, client.Preconditions{UID: &task.UID}); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to delete finished Task %s/%s: %w", task.Namespace, task.Name, err)
+	}
+
+	return ctrl.Result{}, nil
+}
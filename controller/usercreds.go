@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// setBMCCredentials changes the password, and optionally the role, of the BMC account in action.
+// The new password is read from the "password" key of action.PasswordSecretRef. It is never logged
+// or included in errors. A PasswordSecretRef without a namespace is to a Secret in namespace.
+func setBMCCredentials(ctx context.Context, reader client.Reader, bmcClient *bmclib.Client, action v1alpha1.SetBMCCredentialsAction, namespace string) error {
+	password, err := resolvePasswordSecretRef(ctx, reader, action.PasswordSecretRef, namespace)
+	if err != nil {
+		return err
+	}
+
+	ok, err := bmcClient.UpdateUser(ctx, action.Username, password, action.Role)
+	if err != nil {
+		if isUnsupported(err) {
+			return fmt.Errorf("provider does not support updating BMC accounts: %w", err)
+		}
+		return fmt.Errorf("failed to update BMC account %q: %w", action.Username, err)
+	}
+	if !ok {
+		return fmt.Errorf("BMC did not confirm the update of account %q", action.Username)
+	}
+
+	return nil
+}
+
+// resolvePasswordSecretRef returns the "password" key of the Secret secretRef, defaulting its namespace to namespace.
+func resolvePasswordSecretRef(ctx context.Context, reader client.Reader, secretRef corev1.SecretReference, namespace string) (string, error) {
+	secret := &corev1.Secret{}
+	key := secretKey(secretRef, namespace)
+	if err := reader.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("secret %s not found: %w", key, err)
+		}
+		return "", fmt.Errorf("failed to retrieve secret %s: %w", key, err)
+	}
+
+	password, ok := secret.Data["password"]
+	if !ok || len(password) == 0 {
+		return "", fmt.Errorf("'password' required in secret %s", key)
+	}
+
+	return string(password), nil
+}
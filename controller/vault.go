@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// VaultProvider is a CredentialProvider that reads credentials from HashiCorp Vault over its HTTP API.
+// Both KV version 1 and version 2 secrets engines are supported. For KV version 2, the path must include
+// the "data" segment, for example "secret/data/bmc/node1".
+type VaultProvider struct {
+	address    string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// NewVaultProvider returns a VaultProvider for the Vault server at address. The Vault token is read from
+// tokenFile on every request, so a token renewed by, for example, the Vault Agent is picked up.
+// When httpClient is nil, http.DefaultClient is used.
+func NewVaultProvider(address, tokenFile string, httpClient *http.Client) *VaultProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &VaultProvider{
+		address:    strings.TrimSuffix(address, "/"),
+		tokenFile:  tokenFile,
+		httpClient: httpClient,
+	}
+}
+
+// Credentials implements CredentialProvider.
+func (v *VaultProvider) Credentials(ctx context.Context, ref v1alpha1.AuthProviderRef) (string, string, error) {
+	token, err := os.ReadFile(v.tokenFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read vault token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.address+"/v1/"+strings.TrimPrefix(ref.Path, "/"), nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("X-Vault-Token", strings.TrimSpace(string(token)))
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to read vault secret: unexpected status %s", resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", "", fmt.Errorf("failed to decode vault secret: %w", err)
+	}
+	data := secret.Data
+	// KV version 2 nests the secret data with its metadata.
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+
+	username, ok := data["username"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("'username' required in vault secret")
+	}
+	password, ok := data["password"].(string)
+	if !ok {
+		return "", "", fmt.Errorf("'password' required in vault secret")
+	}
+
+	return username, password, nil
+}
@@ -0,0 +1,83 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+	"github.com/tinkerbell/rufio/controller"
+)
+
+func TestVaultProviderCredentials(t *testing.T) {
+	tests := map[string]struct {
+		path         string
+		body         string
+		status       int
+		wantUsername string
+		wantPassword string
+		shouldErr    bool
+	}{
+		"kv version 2": {
+			path:         "secret/data/bmc/node1",
+			body:         `{"data": {"data": {"username": "admin", "password": "s3cret"}, "metadata": {"version": 3}}}`,
+			status:       http.StatusOK,
+			wantUsername: "admin",
+			wantPassword: "s3cret",
+		},
+		"kv version 1": {
+			path:         "kv/bmc/node1",
+			body:         `{"data": {"username": "admin", "password": "s3cret"}}`,
+			status:       http.StatusOK,
+			wantUsername: "admin",
+			wantPassword: "s3cret",
+		},
+		"missing password": {
+			path:      "kv/bmc/node1",
+			body:      `{"data": {"username": "admin"}}`,
+			status:    http.StatusOK,
+			shouldErr: true,
+		},
+		"permission denied": {
+			path:      "kv/bmc/node1",
+			body:      `{"errors": ["permission denied"]}`,
+			status:    http.StatusForbidden,
+			shouldErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/"+tt.path || r.Header.Get("X-Vault-Token") != "token" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(tokenFile, []byte("token\n"), 0o600); err != nil {
+				t.Fatalf("expected nil err, got: %v", err)
+			}
+
+			provider := controller.NewVaultProvider(server.URL, tokenFile, server.Client())
+			username, password, err := provider.Credentials(context.Background(), v1alpha1.AuthProviderRef{Name: "vault", Path: tt.path})
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("expected err %v, got: %v", tt.shouldErr, err)
+			}
+			if diff := cmp.Diff(tt.wantUsername, username); diff != "" {
+				t.Fatalf("unexpected username: %v", diff)
+			}
+			if diff := cmp.Diff(tt.wantPassword, password); diff != "" {
+				t.Fatalf("unexpected password: %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bmclib "github.com/bmc-toolbox/bmclib/v2"
+	"github.com/bmc-toolbox/bmclib/v2/providers"
+	"github.com/jacobweinstock/registrar"
+	"github.com/tinkerbell/rufio/api/v1alpha1"
+)
+
+// capability is a capability reported by a VerifyConnectionAction and the provider features that provide it.
+type capability struct {
+	name     string
+	features []registrar.Feature
+}
+
+// capabilities are the capabilities reported by a VerifyConnectionAction, in the order they are reported.
+var capabilities = []capability{
+	{name: "power", features: []registrar.Feature{providers.FeaturePowerSet}},
+	{name: "bootdevice", features: []registrar.Feature{providers.FeatureBootDeviceSet}},
+	{name: "virtualmedia", features: []registrar.Feature{providers.FeatureVirtualMedia}},
+	{name: "biosconfig", features: []registrar.Feature{providers.FeatureGetBiosConfiguration, providers.FeatureSetBiosConfiguration}},
+	{name: "sel", features: []registrar.Feature{providers.FeatureGetSystemEventLog, providers.FeatureClearSystemEventLog}},
+	{name: "inventory", features: []registrar.Feature{providers.FeatureInventoryRead}},
+	{name: "firmwareinstall", features: firmwareInstallFeatures},
+	{name: "bmcreset", features: []registrar.Feature{providers.FeatureBmcReset}},
+}
+
+// verifyConnection confirms that the opened BMC connection is authenticated by reading the power state,
+// then stores the capabilities of the opened providers in the Task status. The providers are reported
+// in the Task Completed condition message. Nothing on the Machine is changed.
+func verifyConnection(ctx context.Context, task *v1alpha1.Task, bmcClient *bmclib.Client) error {
+	if _, err := bmcClient.GetPowerState(ctx); err != nil {
+		return fmt.Errorf("failed to read power state with the opened connection: %w", err)
+	}
+
+	task.Status.Capabilities = detectCapabilities(bmcClient.Registry.Drivers)
+
+	names := make([]string, 0, len(bmcClient.Registry.Drivers))
+	for _, d := range bmcClient.Registry.Drivers {
+		names = append(names, d.Name)
+	}
+	msg := fmt.Sprintf("connection verified with providers: %s", strings.Join(names, ", "))
+	task.SetCondition(v1alpha1.TaskCompleted, v1alpha1.ConditionFalse, v1alpha1.WithTaskConditionMessage(msg))
+
+	return nil
+}
+
+// detectCapabilities returns the names of the capabilities that any of drivers provides.
+func detectCapabilities(drivers registrar.Drivers) []string {
+	var detected []string
+	for _, c := range capabilities {
+		if supportsAny(drivers, c.features) {
+			detected = append(detected, c.name)
+		}
+	}
+
+	return detected
+}
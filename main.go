@@ -20,6 +20,7 @@ import (
 	"context"
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -33,15 +34,19 @@ import (
 	"github.com/peterbourgon/ff/v3"
 	"github.com/peterbourgon/ff/v3/ffcli"
 	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/tinkerbell/rufio/api/v1alpha1"
+	"github.com/tinkerbell/rufio/api/v1alpha2"
 	"github.com/tinkerbell/rufio/controller"
 	//+kubebuilder:scaffold:imports
 )
@@ -59,6 +64,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -85,7 +91,37 @@ func main() {
 	var kubeAPIServer string
 	var kubeconfig string
 	var kubeNamespace string
+	var secretNamespaces string
 	var bmcConnectTimeout time.Duration
+	var enableWebhooks bool
+	var bmcConnectionCacheSize int
+	var bmcConnectionCacheTTL time.Duration
+	var maxConcurrentPerBMC int
+	var vaultAddress string
+	var vaultTokenFile string
+	var taskFailureRequeueInterval time.Duration
+	var taskFailureRequeueWindow time.Duration
+	var machineInventoryInterval time.Duration
+	var machineCapabilitiesInterval time.Duration
+	var machinePowerCheckInterval time.Duration
+	var defaultBMCTimeout time.Duration
+	var maxInflightTasks int
+	var requeueJitter float64
+	var powerVerificationWindow time.Duration
+	var bmcSessionKeepalive time.Duration
+	var duplicateTaskPolicy string
+	var circuitBreakerThreshold int
+	var circuitBreakerCooldown time.Duration
+	var shutdownDrainPeriod time.Duration
+	var bmcLoginRate float64
+	var bmcLoginBurst int
+	var defaultEFIBoot bool
+	var bmcLeaseDuration time.Duration
+	var bmcLeaseRenewInterval time.Duration
+	var bmcLeaseNamespace string
+	var bmcHTTPUserAgent string
+	var bmcHTTPTimeout time.Duration
+	var enabledProviders string
 	fs := flag.NewFlagSet(appName, flag.ExitOnError)
 	fs.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -95,7 +131,37 @@ func main() {
 	fs.StringVar(&kubeAPIServer, "kubernetes", "", "The Kubernetes API URL, used for in-cluster client construction.")
 	fs.StringVar(&kubeconfig, "kubeconfig", "", "Absolute path to the kubeconfig file.")
 	fs.StringVar(&kubeNamespace, "kube-namespace", "", "Namespace that the controller watches to reconcile objects.")
+	fs.StringVar(&secretNamespaces, "secret-namespaces", "", "Comma separated namespaces, in addition to --kube-namespace, that Connections may reference Secrets and CA bundle ConfigMaps in. Only used with --kube-namespace, otherwise those of all namespaces can be referenced.")
 	fs.DurationVar(&bmcConnectTimeout, "bmc-connect-timeout", 60*time.Second, "Timeout for establishing a connection to BMCs.")
+	fs.BoolVar(&enableWebhooks, "enable-webhooks", false, "Enable the admission webhooks and the conversion webhook of the Task versions. Requires serving certificates to be mounted.")
+	fs.DurationVar(&bmcLeaseDuration, "bmc-lease-duration", 0, "Duration of the Lease a controller replica holds on a BMC host while operating on it, serializing operations against a BMC across replicas. A Lease not renewed within its duration is taken over. 0 disables the Leases.")
+	fs.DurationVar(&bmcLeaseRenewInterval, "bmc-lease-renew-interval", 5*time.Second, "How often a held BMC Lease is renewed. Must be shorter than --bmc-lease-duration.")
+	fs.StringVar(&bmcLeaseNamespace, "bmc-lease-namespace", "rufio-system", "Namespace of the BMC Leases. All replicas must use the same namespace.")
+	fs.BoolVar(&defaultEFIBoot, "default-efi-boot", false, "Default efiBoot of the oneTimeBootDeviceActions of new Jobs and Tasks to true when their Machine has the uefi-only capability. Requires --enable-webhooks.")
+	fs.IntVar(&bmcConnectionCacheSize, "bmc-connection-cache-size", 0, "Maximum number of idle BMC connections kept open for reuse. 0 disables connection reuse.")
+	fs.DurationVar(&bmcConnectionCacheTTL, "bmc-connection-cache-ttl", 2*time.Minute, "Time after which a cached BMC connection is closed instead of reused.")
+	fs.IntVar(&maxConcurrentPerBMC, "max-concurrent-per-bmc", 1, "Maximum number of concurrent operations against a single BMC host. Others are requeued.")
+	fs.StringVar(&vaultAddress, "vault-address", "", "Address of the Vault server for Connections with an authProviderRef named \"vault\". Vault is disabled when empty.")
+	fs.StringVar(&vaultTokenFile, "vault-token-file", "/var/run/secrets/vault/token", "Path of the file containing the Vault token.")
+	fs.DurationVar(&taskFailureRequeueInterval, "task-failure-requeue-interval", 0, "Wait before a Task that failed with a transient error is run again. 0 fails Tasks on the first error.")
+	fs.DurationVar(&taskFailureRequeueWindow, "task-failure-requeue-window", 30*time.Minute, "Time after the creation of a Task during which transient errors are requeued, after which the Task fails permanently.")
+	fs.DurationVar(&machineInventoryInterval, "machine-inventory-interval", 24*time.Hour, "How often the manufacturer, model and serial number of Machines are read from the BMC. 0 disables reading them.")
+	fs.DurationVar(&machineCapabilitiesInterval, "machine-capabilities-interval", 24*time.Hour, "How often the providers and capabilities of Machines are detected. Tasks of Jobs fail right away when their action requires a capability the Machine lacks. 0 disables detecting them.")
+	fs.DurationVar(&machinePowerCheckInterval, "machine-power-check-interval", 3*time.Minute, "How often the power state of Machines without a spec.powerCheckInterval is read from the BMC. Requeues are jittered by --requeue-jitter.")
+	fs.DurationVar(&defaultBMCTimeout, "default-bmc-timeout", 2*time.Minute, "Timeout of each BMC operation of a Task without its own timeout: opening the connection, the action and closing the connection. 0 disables it.")
+	fs.IntVar(&maxInflightTasks, "max-inflight-tasks", 0, "Maximum number of Tasks executing BMC operations at a time, across all BMCs. Others are set Pending and requeued. 0 disables the limit.")
+	fs.Float64Var(&requeueJitter, "requeue-jitter", 0.1, "Fraction of the requeue interval added at random to Machine power state polls and Task status polls, to spread out reconciles. 0 disables jitter.")
+	fs.DurationVar(&powerVerificationWindow, "power-verification-window", 0, "Time after a Task power action of on, off, soft or cycle within which the observed power state must match the requested one, else the Task fails. The observed state is stored in the Task status. 0 disables verification.")
+	fs.DurationVar(&bmcSessionKeepalive, "bmc-session-keepalive-interval", 0, "How often the BMC is pinged while a long running Task action, like a virtual media or BIOS configuration change, is in progress, to keep the BMC session from timing out. 0 disables the keepalive.")
+	fs.StringVar(&duplicateTaskPolicy, "duplicate-task-policy", string(controller.DuplicateTaskPolicyNone), "What to do with a new Task identical to a running Task, with the same actions against the same BMC host: none runs it anyway, defer waits for the running Task to finish, skip completes it without running its actions.")
+	fs.IntVar(&circuitBreakerThreshold, "bmc-circuit-breaker-threshold", 0, "Number of consecutive connection failures to a BMC host after which Tasks against it fail without connecting, for the circuit breaker cooldown. 0 disables the circuit breaker.")
+	fs.DurationVar(&circuitBreakerCooldown, "bmc-circuit-breaker-cooldown", time.Minute, "How long Tasks against a BMC host fail without connecting once its circuit breaker opened.")
+	fs.DurationVar(&shutdownDrainPeriod, "shutdown-drain-period", 0, "How long Task reconciles in flight may continue to finish their BMC operations once the controller received SIGTERM. New reconciles are not started. Tasks that don't finish in time are reconciled again after the restart. 0 cancels them right away.")
+	fs.Float64Var(&bmcLoginRate, "bmc-login-rate", 0, "Maximum number of new BMC sessions opened per second, across all BMCs, to protect shared authentication services. Reconciles over the limit are requeued. Reused cached connections are not limited. 0 disables the limit.")
+	fs.IntVar(&bmcLoginBurst, "bmc-login-burst", 1, "Number of new BMC sessions that may be opened at once above --bmc-login-rate.")
+	fs.StringVar(&bmcHTTPUserAgent, "bmc-http-user-agent", "", "User-Agent header of the Redfish requests the controller sends itself, for example for BMCs behind a firewall that blocks the Go default. The bmclib providers send their own User-Agent. Empty keeps the Go default.")
+	fs.DurationVar(&bmcHTTPTimeout, "bmc-http-timeout", 0, "Timeout of each HTTP request to a BMC, of the HTTP based providers, like Redfish, and of the Redfish requests the controller sends itself. 0 keeps the bmclib default of 2 minutes.")
+	fs.StringVar(&enabledProviders, "enabled-providers", "", "Comma separated bmclib providers, by name or protocol, for example gofish or redfish, that are used to connect to BMCs. Other providers are never used, whatever the providerOptions of a Connection prefer, and Tasks that only they could run fail right away. Empty enables all providers.")
 	cli := &ffcli.Command{
 		Name:    appName,
 		FlagSet: fs,
@@ -125,11 +191,29 @@ func main() {
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "e74dec1a.tinkerbell.org",
 	}
+	if shutdownDrainPeriod > 0 {
+		// The manager waits for the reconciles in flight to drain, with some time left to stop the other runnables.
+		gracefulShutdownTimeout := shutdownDrainPeriod + 10*time.Second
+		opts.GracefulShutdownTimeout = &gracefulShutdownTimeout
+	}
 	// If a namespace is specified, only watch that namespace. Otherwise, watch all namespaces.
 	if kubeNamespace != "" {
 		opts.Cache = cache.Options{
 			DefaultNamespaces: map[string]cache.Config{kubeNamespace: {}},
 		}
+		// Secrets, and the ConfigMaps of CA bundles, referenced across namespaces are read from the --secret-namespaces too.
+		if secretNamespaces != "" {
+			namespaces := map[string]cache.Config{kubeNamespace: {}}
+			for _, ns := range strings.Split(secretNamespaces, ",") {
+				if ns = strings.TrimSpace(ns); ns != "" {
+					namespaces[ns] = cache.Config{}
+				}
+			}
+			opts.Cache.ByObject = map[client.Object]cache.ByObject{
+				&corev1.Secret{}:    {Namespaces: namespaces},
+				&corev1.ConfigMap{}: {Namespaces: namespaces},
+			}
+		}
 	}
 
 	mgr, err := ctrl.NewManager(cfg, opts)
@@ -141,10 +225,108 @@ func main() {
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
-	bmcClientFactory := controller.NewClientFunc(bmcConnectTimeout)
+	if bmcLoginRate < 0 || (bmcLoginRate > 0 && bmcLoginBurst < 1) {
+		setupLog.Error(nil, "bmc-login-rate must not be negative and bmc-login-burst must be at least 1", "rate", bmcLoginRate, "burst", bmcLoginBurst)
+		os.Exit(1)
+	}
+	var loginLimiter *controller.LoginLimiter
+	if bmcLoginRate > 0 {
+		loginLimiter = controller.NewLoginLimiter(bmcLoginRate, bmcLoginBurst)
+	}
+	// Only new sessions are rate limited, so the limit applies below the connection cache.
+	var enabled []string
+	for _, p := range strings.Split(enabledProviders, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			enabled = append(enabled, p)
+		}
+	}
+	bmcClientFactory := loginLimiter.Wrap(controller.NewClientFunc(bmcConnectTimeout, enabled))
+
+	var clientCache *controller.ClientCache
+	if bmcConnectionCacheSize > 0 {
+		clientCache = controller.NewClientCache(bmcClientFactory, bmcConnectionCacheSize, bmcConnectionCacheTTL, ctrl.Log.WithName("bmc-client-cache"))
+		if err := mgr.Add(clientCache); err != nil {
+			setupLog.Error(err, "unable to add BMC connection cache")
+			os.Exit(1)
+		}
+	}
+
+	if bmcHTTPTimeout < 0 {
+		setupLog.Error(nil, "bmc-http-timeout must not be negative", "value", bmcHTTPTimeout)
+		os.Exit(1)
+	}
+	httpConfig := controller.HTTPClientConfig{UserAgent: bmcHTTPUserAgent, Timeout: bmcHTTPTimeout}
+
+	if maxConcurrentPerBMC < 1 {
+		setupLog.Error(nil, "max-concurrent-per-bmc must be at least 1", "value", maxConcurrentPerBMC)
+		os.Exit(1)
+	}
+	if requeueJitter < 0 || requeueJitter > 1 {
+		setupLog.Error(nil, "requeue-jitter must be between 0 and 1", "value", requeueJitter)
+		os.Exit(1)
+	}
+	switch controller.DuplicateTaskPolicy(duplicateTaskPolicy) {
+	case controller.DuplicateTaskPolicyNone, controller.DuplicateTaskPolicyDefer, controller.DuplicateTaskPolicySkip:
+	default:
+		setupLog.Error(nil, "duplicate-task-policy must be one of none, defer or skip", "value", duplicateTaskPolicy)
+		os.Exit(1)
+	}
+	hostLimiter := controller.NewHostLimiter(maxConcurrentPerBMC)
+	var bmcLeaser *controller.BMCLeaser
+	if bmcLeaseDuration > 0 {
+		if bmcLeaseRenewInterval <= 0 || bmcLeaseRenewInterval >= bmcLeaseDuration {
+			setupLog.Error(nil, "bmc-lease-renew-interval must be positive and shorter than bmc-lease-duration", "renewInterval", bmcLeaseRenewInterval, "duration", bmcLeaseDuration)
+			os.Exit(1)
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "unable to get hostname for the BMC Lease identity")
+			os.Exit(1)
+		}
+		// The identity is unique per process, like the leader election identity.
+		identity := hostname + "_" + string(uuid.NewUUID())
+		bmcLeaser = controller.NewBMCLeaser(mgr.GetClient(), mgr.GetAPIReader(), bmcLeaseNamespace, identity, bmcLeaseDuration, bmcLeaseRenewInterval, ctrl.Log.WithName("bmc-lease"))
+	}
+	var hostBreaker *controller.HostBreaker
+	if circuitBreakerThreshold > 0 {
+		hostBreaker = controller.NewHostBreaker(circuitBreakerThreshold, circuitBreakerCooldown)
+	}
+	var inflightLimiter *controller.InflightLimiter
+	if maxInflightTasks > 0 {
+		inflightLimiter = controller.NewInflightLimiter(maxInflightTasks)
+	}
+
+	credentialProviders := map[string]controller.CredentialProvider{}
+	if vaultAddress != "" {
+		credentialProviders["vault"] = controller.NewVaultProvider(vaultAddress, vaultTokenFile, nil)
+	}
 
 	// Setup controller reconcilers
-	setupReconcilers(ctx, mgr, bmcClientFactory)
+	setupReconcilers(ctx, mgr, reconcilerOptions{
+		bmcClientFactory:           bmcClientFactory,
+		clientCache:                clientCache,
+		hostLimiter:                hostLimiter,
+		bmcLeaser:                  bmcLeaser,
+		hostBreaker:                hostBreaker,
+		inflightLimiter:            inflightLimiter,
+		credentialProviders:        credentialProviders,
+		taskFailureRequeueInterval: taskFailureRequeueInterval,
+		taskFailureRequeueWindow:   taskFailureRequeueWindow,
+		inventoryInterval:          machineInventoryInterval,
+		capabilitiesInterval:       machineCapabilitiesInterval,
+		powerCheckInterval:         machinePowerCheckInterval,
+		defaultBMCTimeout:          defaultBMCTimeout,
+		powerVerificationWindow:    powerVerificationWindow,
+		sessionKeepalive:           bmcSessionKeepalive,
+		shutdownDrainPeriod:        shutdownDrainPeriod,
+		duplicateTaskPolicy:        controller.DuplicateTaskPolicy(duplicateTaskPolicy),
+		requeueJitter:              requeueJitter,
+		httpConfig:                 httpConfig,
+	})
+
+	if enableWebhooks {
+		setupWebhooks(mgr, defaultEFIBoot)
+	}
 
 	//+kubebuilder:scaffold:builder
 
@@ -174,13 +356,47 @@ func newClientConfig(kubeAPIServer, kubeconfig string) clientcmd.ClientConfig {
 		&clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{Server: kubeAPIServer}})
 }
 
+// reconcilerOptions are the dependencies and settings, from the command line flags, of the controllers.
+type reconcilerOptions struct {
+	bmcClientFactory    controller.ClientFunc
+	clientCache         *controller.ClientCache
+	hostLimiter         *controller.HostLimiter
+	bmcLeaser           *controller.BMCLeaser
+	hostBreaker         *controller.HostBreaker
+	inflightLimiter     *controller.InflightLimiter
+	credentialProviders map[string]controller.CredentialProvider
+
+	taskFailureRequeueInterval time.Duration
+	taskFailureRequeueWindow   time.Duration
+	inventoryInterval          time.Duration
+	capabilitiesInterval       time.Duration
+	powerCheckInterval         time.Duration
+	defaultBMCTimeout          time.Duration
+	powerVerificationWindow    time.Duration
+	sessionKeepalive           time.Duration
+	shutdownDrainPeriod        time.Duration
+	duplicateTaskPolicy        controller.DuplicateTaskPolicy
+	requeueJitter              float64
+	httpConfig                 controller.HTTPClientConfig
+}
+
 // setupReconcilers initializes the controllers with the Manager.
-func setupReconcilers(ctx context.Context, mgr ctrl.Manager, bmcClientFactory controller.ClientFunc) {
+func setupReconcilers(ctx context.Context, mgr ctrl.Manager, o reconcilerOptions) {
 	err := (controller.NewMachineReconciler(
 		mgr.GetClient(),
 		mgr.GetEventRecorderFor("machine-controller"),
-		bmcClientFactory,
-	)).SetupWithManager(mgr)
+		o.bmcClientFactory,
+	)).
+		WithClientCache(o.clientCache).
+		WithHostLimiter(o.hostLimiter).
+		WithBMCLeaser(o.bmcLeaser).
+		WithCredentialProviders(o.credentialProviders).
+		WithInventoryInterval(o.inventoryInterval).
+		WithCapabilitiesInterval(o.capabilitiesInterval).
+		WithPowerCheckInterval(o.powerCheckInterval).
+		WithRequeueJitter(o.requeueJitter).
+		WithHTTPClientConfig(o.httpConfig).
+		SetupWithManager(ctx, mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
@@ -196,10 +412,46 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager, bmcClientFactory co
 
 	err = (controller.NewTaskReconciler(
 		mgr.GetClient(),
-		bmcClientFactory,
-	)).SetupWithManager(mgr)
+		mgr.GetEventRecorderFor("task-controller"),
+		o.bmcClientFactory,
+	)).
+		WithClientCache(o.clientCache).
+		WithHostLimiter(o.hostLimiter).
+		WithBMCLeaser(o.bmcLeaser).
+		WithHostBreaker(o.hostBreaker).
+		WithInflightLimiter(o.inflightLimiter).
+		WithCredentialProviders(o.credentialProviders).
+		WithAPIReader(mgr.GetAPIReader()).
+		WithFailureRequeue(o.taskFailureRequeueInterval, o.taskFailureRequeueWindow).
+		WithDefaultBMCTimeout(o.defaultBMCTimeout).
+		WithCapabilitiesInterval(o.capabilitiesInterval).
+		WithPowerVerificationWindow(o.powerVerificationWindow).
+		WithSessionKeepalive(o.sessionKeepalive).
+		WithDuplicatePolicy(o.duplicateTaskPolicy).
+		WithShutdownDrain(o.shutdownDrainPeriod).
+		WithRequeueJitter(o.requeueJitter).
+		WithHTTPClientConfig(o.httpConfig).
+		SetupWithManager(ctx, mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Task")
 		os.Exit(1)
 	}
 }
+
+// setupWebhooks initializes the admission webhooks with the Manager.
+func setupWebhooks(mgr ctrl.Manager, defaultEFIBoot bool) {
+	if err := (&v1alpha1.Task{}).SetupWebhookWithManager(mgr, defaultEFIBoot); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Task")
+		os.Exit(1)
+	}
+
+	if err := (&v1alpha1.Job{}).SetupWebhookWithManager(mgr, defaultEFIBoot); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Job")
+		os.Exit(1)
+	}
+
+	if err := (&v1alpha1.Machine{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Machine")
+		os.Exit(1)
+	}
+}